@@ -0,0 +1,200 @@
+package probe
+
+import (
+	"fmt"
+	"io"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+func (t *NestParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write([]byte{130}); err != nil {
+		return err
+	}
+
+	// t.Depth (int64) (int64)
+	depth := cbg.CborInt(t.Depth)
+	if err := depth.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.AbortDepth (int64) (int64)
+	abortDepth := cbg.CborInt(t.AbortDepth)
+	return abortDepth.MarshalCBOR(w)
+}
+
+func (t *NestParams) UnmarshalCBOR(r io.Reader) error {
+	br := cbg.GetPeeker(r)
+
+	maj, extra, err := cbg.CborReadHeader(br)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Depth (int64) (int64)
+	var depth cbg.CborInt
+	if err := depth.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.Depth: %w", err)
+	}
+	t.Depth = int64(depth)
+
+	// t.AbortDepth (int64) (int64)
+	var abortDepth cbg.CborInt
+	if err := abortDepth.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.AbortDepth: %w", err)
+	}
+	t.AbortDepth = int64(abortDepth)
+	return nil
+}
+
+func (t *RelayParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write([]byte{131}); err != nil {
+		return err
+	}
+
+	// t.Depth (int64) (int64)
+	depth := cbg.CborInt(t.Depth)
+	if err := depth.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.AbortCode (int64) (int64)
+	abortCode := cbg.CborInt(t.AbortCode)
+	if err := abortCode.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.WrapDepth (int64) (int64)
+	wrapDepth := cbg.CborInt(t.WrapDepth)
+	return wrapDepth.MarshalCBOR(w)
+}
+
+func (t *RelayParams) UnmarshalCBOR(r io.Reader) error {
+	br := cbg.GetPeeker(r)
+
+	maj, extra, err := cbg.CborReadHeader(br)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+	if extra != 3 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Depth (int64) (int64)
+	var depth cbg.CborInt
+	if err := depth.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.Depth: %w", err)
+	}
+	t.Depth = int64(depth)
+
+	// t.AbortCode (int64) (int64)
+	var abortCode cbg.CborInt
+	if err := abortCode.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.AbortCode: %w", err)
+	}
+	t.AbortCode = int64(abortCode)
+
+	// t.WrapDepth (int64) (int64)
+	var wrapDepth cbg.CborInt
+	if err := wrapDepth.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.WrapDepth: %w", err)
+	}
+	t.WrapDepth = int64(wrapDepth)
+	return nil
+}
+
+func (t *BurnParams) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write([]byte{130}); err != nil {
+		return err
+	}
+
+	// t.Gas (int64) (int64)
+	gas := cbg.CborInt(t.Gas)
+	if err := gas.MarshalCBOR(w); err != nil {
+		return err
+	}
+
+	// t.AbortCode (int64) (int64)
+	abortCode := cbg.CborInt(t.AbortCode)
+	return abortCode.MarshalCBOR(w)
+}
+
+func (t *BurnParams) UnmarshalCBOR(r io.Reader) error {
+	br := cbg.GetPeeker(r)
+
+	maj, extra, err := cbg.CborReadHeader(br)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajArray {
+		return fmt.Errorf("cbor input should be of type array")
+	}
+	if extra != 2 {
+		return fmt.Errorf("cbor input had wrong number of fields")
+	}
+
+	// t.Gas (int64) (int64)
+	var gas cbg.CborInt
+	if err := gas.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.Gas: %w", err)
+	}
+	t.Gas = int64(gas)
+
+	// t.AbortCode (int64) (int64)
+	var abortCode cbg.CborInt
+	if err := abortCode.UnmarshalCBOR(br); err != nil {
+		return fmt.Errorf("unmarshaling t.AbortCode: %w", err)
+	}
+	t.AbortCode = int64(abortCode)
+	return nil
+}
+
+func (t *Bytes) MarshalCBOR(w io.Writer) error {
+	if len(*t) > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("byte array too long (%d)", len(*t))
+	}
+	if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajByteString, uint64(len(*t)))); err != nil {
+		return err
+	}
+	_, err := w.Write(*t)
+	return err
+}
+
+func (t *Bytes) UnmarshalCBOR(r io.Reader) error {
+	br := cbg.GetPeeker(r)
+
+	maj, extra, err := cbg.CborReadHeader(br)
+	if err != nil {
+		return err
+	}
+	if maj != cbg.MajByteString {
+		return fmt.Errorf("expected byte array")
+	}
+	if extra > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("byte array too large (%d)", extra)
+	}
+	*t = make([]byte, extra)
+	_, err = io.ReadFull(br, *t)
+	return err
+}
@@ -0,0 +1,261 @@
+// Package probe implements a test actor exercising behaviors of the VM that can't be triggered through the builtin
+// actors alone. It runs in implementations whose state wrapper implements state.CustomActorsVMWrapper.
+package probe
+
+import (
+	"bytes"
+
+	abi "github.com/filecoin-project/specs-actors/actors/abi"
+	big "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin "github.com/filecoin-project/specs-actors/actors/builtin"
+	runtime "github.com/filecoin-project/specs-actors/actors/runtime"
+	exitcode "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	adt "github.com/filecoin-project/specs-actors/actors/util/adt"
+	cid "github.com/ipfs/go-cid"
+	multihash "github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+// ProbeActorCodeID is the code of the probe actor, the identity multihash of "chain-validation/probe".
+var ProbeActorCodeID = func() cid.Cid {
+	c, err := cid.NewPrefixV1(cid.Raw, multihash.IDENTITY).Sum([]byte("chain-validation/probe"))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+var MethodsProbe = struct {
+	Constructor abi.MethodNum
+	BurnGas     abi.MethodNum
+	SetValue    abi.MethodNum
+	Reenter     abi.MethodNum
+	Nest        abi.MethodNum
+	Relay       abi.MethodNum
+	CallBurnGas abi.MethodNum
+	ReturnBytes abi.MethodNum
+	Increment   abi.MethodNum
+	Double      abi.MethodNum
+}{builtin.MethodConstructor, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+// State is the probe's state: a value set by SetValue, doubled by Double, and incremented by each call to Increment,
+// Reenter, Nest and CallBurnGas.
+type State = cbg.CborInt
+
+type Actor struct{}
+
+var _ runtime.Invokee = Actor{}
+
+func (a Actor) Exports() []interface{} {
+	return []interface{}{
+		builtin.MethodConstructor: a.Constructor,
+		2:                         a.BurnGas,
+		3:                         a.SetValue,
+		4:                         a.Reenter,
+		5:                         a.Nest,
+		6:                         a.Relay,
+		7:                         a.CallBurnGas,
+		8:                         a.ReturnBytes,
+		9:                         a.Increment,
+		10:                        a.Double,
+	}
+}
+
+func (a Actor) Constructor(rt runtime.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	st := State(0)
+	rt.State().Create(&st)
+	return nil
+}
+
+type BurnParams struct {
+	// Gas is the gas burnt, in addition to the VM's charges for the call.
+	Gas int64
+	// AbortCode is the exit code the call aborts with after burning gas, or zero for none.
+	AbortCode int64
+}
+
+// BurnGas charges `Gas` gas, then aborts with `AbortCode` if it isn't zero.
+func (a Actor) BurnGas(rt runtime.Runtime, params *BurnParams) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	if params.Gas < 0 {
+		rt.Abortf(exitcode.ErrIllegalArgument, "negative gas %d", params.Gas)
+	}
+	rt.ChargeGas("probe burn", params.Gas, 0)
+	if params.AbortCode != 0 {
+		rt.Abortf(exitcode.ExitCode(params.AbortCode), "abort after burning %d gas", params.Gas)
+	}
+	return nil
+}
+
+// CallBurnGas calls BurnGas with `params` on the probe itself and, whatever the outcome, increments the probe's state
+// and returns the exit code of the nested call.
+func (a Actor) CallBurnGas(rt runtime.Runtime, params *BurnParams) *cbg.CborInt {
+	rt.ValidateImmediateCallerAcceptAny()
+	_, code := rt.Send(rt.Message().Receiver(), MethodsProbe.BurnGas, params, big.Zero())
+	var st State
+	rt.State().Transaction(&st, func() interface{} {
+		st++
+		return nil
+	})
+	ret := cbg.CborInt(code)
+	return &ret
+}
+
+// Bytes is a return value of arbitrary bytes, encoded as a CBOR byte string.
+type Bytes []byte
+
+// ReturnBytes returns `size` bytes, so that the charge for return values may be measured at any size.
+func (a Actor) ReturnBytes(rt runtime.Runtime, size *cbg.CborInt) *Bytes {
+	rt.ValidateImmediateCallerAcceptAny()
+	if *size < 0 || *size > cbg.ByteArrayMaxLen {
+		rt.Abortf(exitcode.ErrIllegalArgument, "size %d out of range", *size)
+	}
+	ret := Bytes(bytes.Repeat([]byte{0xff}, int(*size)))
+	return &ret
+}
+
+// SetValue replaces the probe's state with `value`.
+func (a Actor) SetValue(rt runtime.Runtime, value *cbg.CborInt) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	var st State
+	rt.State().Transaction(&st, func() interface{} {
+		st = *value
+		return nil
+	})
+	return nil
+}
+
+// Increment increments the probe's state. It takes no params, so that it may be called by cron.
+func (a Actor) Increment(rt runtime.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	var st State
+	rt.State().Transaction(&st, func() interface{} {
+		st++
+		return nil
+	})
+	return nil
+}
+
+// Double doubles the probe's state. With Increment, it makes the order of calls observable in the state.
+func (a Actor) Double(rt runtime.Runtime, _ *adt.EmptyValue) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	var st State
+	rt.State().Transaction(&st, func() interface{} {
+		st *= 2
+		return nil
+	})
+	return nil
+}
+
+// Reenter increments the probe's state, then calls Reenter on the probe itself with `depth` less one, until `depth` is
+// zero, so that the state is incremented `depth`+1 times by nested calls into the same actor. A failure of a nested
+// call aborts the call with its exit code.
+func (a Actor) Reenter(rt runtime.Runtime, depth *cbg.CborInt) *adt.EmptyValue {
+	rt.ValidateImmediateCallerAcceptAny()
+	var st State
+	rt.State().Transaction(&st, func() interface{} {
+		st++
+		return nil
+	})
+	if *depth <= 0 {
+		return nil
+	}
+	next := *depth - 1
+	_, code := rt.Send(rt.Message().Receiver(), MethodsProbe.Reenter, &next, big.Zero())
+	if !code.IsSuccess() {
+		rt.Abortf(code, "nested call at depth %d failed", next)
+	}
+	return nil
+}
+
+// ErrRequestedAbort is the exit code of a call to Nest aborting at its AbortDepth.
+const ErrRequestedAbort = exitcode.FirstActorSpecificExitCode
+
+// ErrStateNotVisible is the exit code of a call to Nest observing the wrong state after a nested call: a committed
+// change missing, or an aborted change present.
+const ErrStateNotVisible = exitcode.FirstActorSpecificExitCode + 1
+
+type NestParams struct {
+	// Depth is the number of nested calls below this one.
+	Depth int64
+	// AbortDepth is the depth at which the call aborts after its nested calls return, or negative for none.
+	AbortDepth int64
+}
+
+// Nest increments the probe's state, then calls Nest on the probe itself with `Depth` less one, until `Depth` is
+// zero, and returns the number of increments committed by it and its nested calls. The call at `AbortDepth` aborts
+// once its nested calls return, rolling back its increment and theirs, while its callers continue.
+//
+// After each nested call, the caller checks that the state includes the increments the nested call committed, or
+// is unchanged by the nested call if it aborted, and aborts with ErrStateNotVisible otherwise.
+func (a Actor) Nest(rt runtime.Runtime, params *NestParams) *cbg.CborInt {
+	rt.ValidateImmediateCallerAcceptAny()
+	var st State
+	rt.State().Transaction(&st, func() interface{} {
+		st++
+		return nil
+	})
+	committed := cbg.CborInt(1)
+
+	if params.Depth > 0 {
+		before := st
+		ret, code := rt.Send(rt.Message().Receiver(), MethodsProbe.Nest, &NestParams{Depth: params.Depth - 1, AbortDepth: params.AbortDepth}, big.Zero())
+		var after State
+		rt.State().Readonly(&after)
+		if code.IsSuccess() {
+			var nested cbg.CborInt
+			if err := ret.Into(&nested); err != nil {
+				rt.Abortf(exitcode.ErrSerialization, "failed to decode nested return: %v", err)
+			}
+			if after != before+nested {
+				rt.Abortf(ErrStateNotVisible, "state %d after nested call committing %d increments to %d", after, nested, before)
+			}
+			committed += nested
+		} else if after != before {
+			rt.Abortf(ErrStateNotVisible, "state %d after aborted nested call, expected %d", after, before)
+		}
+	}
+
+	if params.Depth == params.AbortDepth {
+		rt.Abortf(ErrRequestedAbort, "abort at depth %d", params.Depth)
+	}
+	return &committed
+}
+
+type RelayParams struct {
+	// Depth is the number of nested calls below this one.
+	Depth int64
+	// AbortCode is the exit code the innermost call, at depth zero, aborts with.
+	AbortCode int64
+	// WrapDepth is the depth, at least one, of the call that wraps the exit code of its failed nested call in its
+	// return value, returning successfully, or negative for none. Calls at other depths propagate the failure of
+	// their nested call by aborting with its exit code, and return the return value of a successful nested call.
+	WrapDepth int64
+}
+
+// Relay calls Relay on the probe itself with `Depth` less one, until `Depth` is zero, where the call aborts with
+// `AbortCode`. Each caller either propagates or wraps the exit code of its nested call, as described by RelayParams.
+func (a Actor) Relay(rt runtime.Runtime, params *RelayParams) *cbg.CborInt {
+	rt.ValidateImmediateCallerAcceptAny()
+	if params.Depth <= 0 {
+		rt.Abortf(exitcode.ExitCode(params.AbortCode), "abort at depth 0")
+	}
+
+	nestedParams := *params
+	nestedParams.Depth--
+	ret, code := rt.Send(rt.Message().Receiver(), MethodsProbe.Relay, &nestedParams, big.Zero())
+	if code.IsSuccess() {
+		var nested cbg.CborInt
+		if err := ret.Into(&nested); err != nil {
+			rt.Abortf(exitcode.ErrSerialization, "failed to decode nested return: %v", err)
+		}
+		return &nested
+	}
+	if params.Depth == params.WrapDepth {
+		wrapped := cbg.CborInt(code)
+		return &wrapped
+	}
+	rt.Abortf(code, "nested call at depth %d failed", nestedParams.Depth)
+	return nil
+}
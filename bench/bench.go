@@ -0,0 +1,66 @@
+package bench
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+)
+
+// DefaultWarmupIterations is the number of untimed iterations run before measurement begins.
+const DefaultWarmupIterations = 3
+
+// MessageSetup prepares a freshly built driver and returns the message whose application is measured.
+type MessageSetup func(td *drivers.TestDriver) *types.Message
+
+// MessageBenchmark measures the application of a single message, reporting ns/op, gas/op and any metrics the
+// implementation reports in ApplyMessageResult.ImplMetrics.
+func MessageBenchmark(b *testing.B, builder *drivers.TestDriverBuilder, setup MessageSetup) {
+	b.Helper()
+	MessageBenchmarkWithWarmup(b, builder, DefaultWarmupIterations, setup)
+}
+
+// MessageBenchmarkWithWarmup is like MessageBenchmark but runs `warmup` untimed iterations first, so that
+// lazy initialization inside the factory (code loading, caches, etc.) is not attributed to the first measured
+// iterations.
+// Every iteration, including the warm-up ones, runs against a freshly built driver. Driver construction and
+// setup happen with the timer stopped, so no iteration benefits from, or pays for, state left behind by another.
+func MessageBenchmarkWithWarmup(b *testing.B, builder *drivers.TestDriverBuilder, warmup int, setup MessageSetup) {
+	b.Helper()
+	for i := 0; i < warmup; i++ {
+		td := builder.Build(b)
+		apply(b, td, setup(td))
+	}
+
+	var totalGas int64
+	totalMetrics := make(map[string]int64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		td := builder.Build(b)
+		msg := setup(td)
+		b.StartTimer()
+
+		result := apply(b, td, msg)
+		totalGas += int64(result.Receipt.GasUsed)
+		for name, v := range result.ImplMetrics {
+			totalMetrics[name] += v
+		}
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(totalGas)/float64(b.N), "gas/op")
+	// Implementation specific metrics are averaged in the same way, allowing implementations to be compared on
+	// their own counters.
+	for name, total := range totalMetrics {
+		b.ReportMetric(float64(total)/float64(b.N), name+"/op")
+	}
+}
+
+func apply(b *testing.B, td *drivers.TestDriver, msg *types.Message) types.ApplyMessageResult {
+	result, err := td.Validator().ApplyMessage(*td.ExeCtx, msg)
+	require.NoError(b, err)
+	return result
+}
@@ -54,17 +54,17 @@ func init() {
 	resources.Add("/MessageTestValueTransferSimplefailtotransferwhensenderbalanceundergaslimit", []types.ApplyMessageResult{types.ApplyMessageResult{Receipt: types.MessageReceipt{ExitCode: 2, ReturnValue: []uint8{}, GasUsed: 0}, Penalty: abi.NewTokenAmount(100000000000), Reward: abi.NewTokenAmount(0), Root: "bafy2bzacedxb47pfcqtboxyx3h5rfbcdcpaenjc3q4wzu6e6hz3qvpxmxxfiu"}})
 	resources.Add("/MessageTestValueTransferSimplesuccessfullytransferfundsfromsendertoreceiver", []types.ApplyMessageResult{types.ApplyMessageResult{Receipt: types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}, Penalty: abi.NewTokenAmount(0), Reward: abi.NewTokenAmount(354268), Root: "bafy2bzacedfuwhc4bxbxl2ofxgc46m4puamh2ylwveqoyvgg4xv7gkzlztzqi"}})
 	resources.Add("/MessageTestValueTransferSimplesuccessfullytransferzerofundsfromsendertoreceiver", []types.ApplyMessageResult{types.ApplyMessageResult{Receipt: types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 165096}, Penalty: abi.NewTokenAmount(0), Reward: abi.NewTokenAmount(165096), Root: "bafy2bzaceazdv5pdhz4xpxmfr5oilbxqlw63bknxlc2ldmrrf3lvi5uoizlxu"}})
-	resources.Add("/TipSetTestBlockMessageApplicationSECPandBLSmessagescostdifferentamountsofgas", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 412268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 423268}}, Root: "bafy2bzacedzlfpt3ppo5d5ognlljuwfwal5kk4bv6b2bdwiwre4pfu6oeeixk"}})
-	resources.Add("/TipSetTestBlockMessageDeduplicationapplyaduplicatedBLSmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe"}})
-	resources.Add("/TipSetTestBlockMessageDeduplicationapplyasingleBLSmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe"}})
-	resources.Add("/TipSetTestBlockMessageDeduplicationapplyasingleSECPmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 423268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe"}})
-	resources.Add("/TipSetTestBlockMessageDeduplicationapplyduplicateBLSandSECPmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 316268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe"}})
-	resources.Add("/TipSetTestBlockMessageDeduplicationapplyduplicateSECPmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 423268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiesinsufficientgastocoverreturnvalue", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{0x83, 0x42, 0x0, 0x64, 0x42, 0x0, 0x65, 0x80}, GasUsed: 328203}}, Root: "bafy2bzaceastl2yzkimhzretrpiysgkv3ccdymzeks2eoe2xq2dg6ror4g5yy"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 7, ReturnValue: []uint8{}, GasUsed: 328202}}, Root: "bafy2bzaceb3dd7f6u6ixfk2rpzyklsmgzwawpw7vjycaufnqolerovkghosl2"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiesnopenaltyifthebalanceisnotsufficienttocovertransfer", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 321268}, types.MessageReceipt{ExitCode: 6, ReturnValue: []uint8{}, GasUsed: 321268}}, Root: "bafy2bzacebzimtzuw3bardp4crktowo6audlfdrg5dqycnk5gpcqyrbighee4"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiesoksimplesend", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}}, Root: "bafy2bzaceby4tiq56nadjr2ym6cptqkj3enwb2ilhnnmu3xyaxdr2o2q45ki2"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}}, Root: "bafy2bzaceaiorzhkpkimkbhokwlkhoxt32buwur5e2tf4v4kpiz6lspohntsc"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}}, Root: "bafy2bzaceadlvgjnlbsbuszx2jnmjika334odjwe5zatyzesjtsi3cvo5maey"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 316268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 316268}}, Root: "bafy2bzacec57j63otl3m3z2m3xcwvtge2t36typ6dp5t4bpwfhsi35n7a5bk4"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenalizesenderdoesntexist", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacebtw5lb3cu4g55apipdrq562drehz6zre5f5uislfmsqudaa5mjeq"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenalizesendernonaccount", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacebtw5lb3cu4g55apipdrq562drehz6zre5f5uislfmsqudaa5mjeq"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenalizewrongcallseqnum", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 2, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacedvokb2dvrygtdd7tlsetrgadxitvoqujqqvmgl4boib5rhor7yzi"}})
-	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenaltyifthebalanceisnotsufficienttocovergas", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 2, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacebdz7tmlqbursha3fafva7s6zuypvfbcycaojvl3iwnvgqcmozcay"}})
+	resources.Add("/TipSetTestBlockMessageApplicationSECPandBLSmessagescostdifferentamountsofgas", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 412268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 423268}}, Root: "bafy2bzacedzlfpt3ppo5d5ognlljuwfwal5kk4bv6b2bdwiwre4pfu6oeeixk", ReceiptsRoot: "bafy2bzaceculd7xdxvdmbcld42uianuu6m6tw53i5xvsbiqp3yks6it7mcmh2"}})
+	resources.Add("/TipSetTestBlockMessageDeduplicationapplyaduplicatedBLSmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe", ReceiptsRoot: "bafy2bzacedtvestmoekvyyztpdfwwaq54rlkqn5inbbzw6udpuf3gdd6tgwr2"}})
+	resources.Add("/TipSetTestBlockMessageDeduplicationapplyasingleBLSmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe", ReceiptsRoot: "bafy2bzacedtvestmoekvyyztpdfwwaq54rlkqn5inbbzw6udpuf3gdd6tgwr2"}})
+	resources.Add("/TipSetTestBlockMessageDeduplicationapplyasingleSECPmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 423268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe", ReceiptsRoot: "bafy2bzacebzx37blin34myb5r7mtjji7w7a644ex2uwhohk2ncdw6bobfvfzm"}})
+	resources.Add("/TipSetTestBlockMessageDeduplicationapplyduplicateBLSandSECPmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 316268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe", ReceiptsRoot: "bafy2bzacecvnqfumyfhbekzsbp6aivdqrxxbxwhchmut5xmcxaggjzgcwqnxk"}})
+	resources.Add("/TipSetTestBlockMessageDeduplicationapplyduplicateSECPmessage", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 423268}}, Root: "bafy2bzaced2dwf5m3rbz2uy3b4hcgsfamep7e5nio7khrgo6awdyxnezpwafe", ReceiptsRoot: "bafy2bzacebzx37blin34myb5r7mtjji7w7a644ex2uwhohk2ncdw6bobfvfzm"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiesinsufficientgastocoverreturnvalue", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{0x83, 0x42, 0x0, 0x64, 0x42, 0x0, 0x65, 0x80}, GasUsed: 328203}}, Root: "bafy2bzaceastl2yzkimhzretrpiysgkv3ccdymzeks2eoe2xq2dg6ror4g5yy", ReceiptsRoot: "bafy2bzacecenefe34gvb5fsg4np43gfmnqd5w6poq44nbqz5zakim7cv67l5i"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 7, ReturnValue: []uint8{}, GasUsed: 328202}}, Root: "bafy2bzaceb3dd7f6u6ixfk2rpzyklsmgzwawpw7vjycaufnqolerovkghosl2", ReceiptsRoot: "bafy2bzacebxnzyr5b3rg2vfuy77v6zzlzciulvost2d6w4zixw7i2fehmotoa"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiesnopenaltyifthebalanceisnotsufficienttocovertransfer", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 321268}, types.MessageReceipt{ExitCode: 6, ReturnValue: []uint8{}, GasUsed: 321268}}, Root: "bafy2bzacebzimtzuw3bardp4crktowo6audlfdrg5dqycnk5gpcqyrbighee4", ReceiptsRoot: "bafy2bzacedhiqfrstcz5sfz6cui7mq4jaztdepfmz65n6ctn6ajroyxjzrxii"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiesoksimplesend", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 354268}}, Root: "bafy2bzaceby4tiq56nadjr2ym6cptqkj3enwb2ilhnnmu3xyaxdr2o2q45ki2", ReceiptsRoot: "bafy2bzacecwfchrjjodhmwphydaj2hswjuh5mjpz3tn3q5z73ls7q754tbxou"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}}, Root: "bafy2bzaceaiorzhkpkimkbhokwlkhoxt32buwur5e2tf4v4kpiz6lspohntsc", ReceiptsRoot: "bafy2bzacechip6pfqksu24s2rqbj6xq6wfqwvj7qt4mu3resdmkxtnezqypu4"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 335268}}, Root: "bafy2bzaceadlvgjnlbsbuszx2jnmjika334odjwe5zatyzesjtsi3cvo5maey", ReceiptsRoot: "bafy2bzacechip6pfqksu24s2rqbj6xq6wfqwvj7qt4mu3resdmkxtnezqypu4"}, types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 316268}, types.MessageReceipt{ExitCode: 0, ReturnValue: []uint8{}, GasUsed: 316268}}, Root: "bafy2bzacec57j63otl3m3z2m3xcwvtge2t36typ6dp5t4bpwfhsi35n7a5bk4", ReceiptsRoot: "bafy2bzacedkweu7tvycrr6mofcvsjbgoglt5ensnehfjx3avgnmxoce3wcqv2"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenalizesenderdoesntexist", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacebtw5lb3cu4g55apipdrq562drehz6zre5f5uislfmsqudaa5mjeq", ReceiptsRoot: "bafy2bzacebzmhwebtvd5lyjpblskuxe5h6tsbvmvctl6lr45qslafqiw2mg2g"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenalizesendernonaccount", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}, types.MessageReceipt{ExitCode: 1, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacebtw5lb3cu4g55apipdrq562drehz6zre5f5uislfmsqudaa5mjeq", ReceiptsRoot: "bafy2bzacedqiktffweyl3zc4y4jr2ci7p4fiwd7hu6dwzm4fn2nay4rq5onw6"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenalizewrongcallseqnum", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 2, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacedvokb2dvrygtdd7tlsetrgadxitvoqujqqvmgl4boib5rhor7yzi", ReceiptsRoot: "bafy2bzacecc6ecgibelni535nwjukynmfgq57guo6fx6vzc33wanb3czj5wpo"}})
+	resources.Add("/TipSetTestMinerRewardsAndPenaltiespenaltyifthebalanceisnotsufficienttocovergas", []types.ApplyTipSetResult{types.ApplyTipSetResult{Receipts: []types.MessageReceipt{types.MessageReceipt{ExitCode: 2, ReturnValue: []uint8{}, GasUsed: 0}}, Root: "bafy2bzacebdz7tmlqbursha3fafva7s6zuypvfbcycaojvl3iwnvgqcmozcay", ReceiptsRoot: "bafy2bzacecc6ecgibelni535nwjukynmfgq57guo6fx6vzc33wanb3czj5wpo"}})
 }
@@ -21,6 +21,13 @@ import (
 
 const blob = "blob.go"
 
+// Must match tracker.CheckpointsFileSuffix and tracker.CommitmentsFileSuffix, which can't be imported here as the
+// tracker depends on this package.
+const (
+	checkpointsFileSuffix = ".checkpoints"
+	commitmentsFileSuffix = ".commitments"
+)
+
 var packageTemplate = template.Must(template.New("").Funcs(map[string]interface{}{"conv": ToGoSyntax, "typeString": ToContainerType}).Parse(`// Code generated by go generate; DO NOT EDIT.
 // generated using files from resources directory
 package box
@@ -76,6 +83,9 @@ func main() {
 		if info.IsDir() {
 			log.Println(walkPath, "is a directory, skipping... \U0001F47B")
 			return nil
+		} else if strings.HasSuffix(walkPath, commitmentsFileSuffix) {
+			// Metadata of a vector rather than an expectation.
+			return nil
 		} else {
 			log.Println(walkPath, "is a file, baking in... \U0001F31F")
 			f, err := os.Open(walkPath)
@@ -85,7 +95,14 @@ func main() {
 			scanner := bufio.NewScanner(f)
 			for scanner.Scan() {
 				// hacky, if a test name is changed this will break. PR's welcome :)
-				if strings.HasPrefix(path.Base(f.Name()), "TipSetTest") {
+				if strings.HasSuffix(f.Name(), checkpointsFileSuffix) {
+					var checkpoint types.Checkpoint
+					if err := json.Unmarshal(scanner.Bytes(), &checkpoint); err != nil {
+						panic(err)
+					}
+					resources[relativePath] = append(resources[relativePath], checkpoint)
+
+				} else if strings.HasPrefix(path.Base(f.Name()), "TipSetTest") {
 					var applytsres types.ApplyTipSetResult
 					if err := json.Unmarshal(scanner.Bytes(), &applytsres); err != nil {
 						panic(err)
@@ -0,0 +1,32 @@
+package chain
+
+import (
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+)
+
+// CBORLinks returns the CIDs linked from b, which must hold a single canonical CBOR item, in encoding order.
+func CBORLinks(b []byte) ([]cid.Cid, error) {
+	r := &cborValidator{buf: b}
+	if err := r.item(0); err != nil {
+		return nil, err
+	}
+	if r.pos != len(b) {
+		return nil, fmt.Errorf("%d trailing bytes after CBOR item", len(b)-r.pos)
+	}
+
+	links := make([]cid.Cid, 0, len(r.cids))
+	for _, raw := range r.cids {
+		// CIDs are prefixed with the identity multibase in DAG-CBOR.
+		if len(raw) == 0 || raw[0] != 0 {
+			return nil, fmt.Errorf("CID missing multibase prefix: %x", raw)
+		}
+		c, err := cid.Cast(raw[1:])
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, c)
+	}
+	return links, nil
+}
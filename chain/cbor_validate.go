@@ -0,0 +1,187 @@
+package chain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Nesting beyond this depth is rejected rather than risking unbounded recursion on hostile input.
+const maxCBORDepth = 64
+
+// The only tag permitted in DAG-CBOR, marking a CID.
+const cidTag = 42
+
+// ValidateCanonicalCBOR checks that b holds exactly one well-formed data item in the canonical (DAG-CBOR) encoding
+// used on chain: minimal length headers, definite lengths, valid UTF-8 text, sorted unique map keys, no tags other
+// than CIDs, and no simple values other than booleans and null.
+func ValidateCanonicalCBOR(b []byte) error {
+	r := &cborValidator{buf: b}
+	if err := r.item(0); err != nil {
+		return err
+	}
+	if r.pos != len(b) {
+		return fmt.Errorf("%d trailing bytes after CBOR item", len(b)-r.pos)
+	}
+	return nil
+}
+
+type cborValidator struct {
+	buf []byte
+	pos int
+
+	// Raw bytes of each CID encountered, including the leading multibase prefix.
+	cids [][]byte
+}
+
+func (r *cborValidator) take(n uint64) ([]byte, error) {
+	if n > uint64(len(r.buf)-r.pos) {
+		return nil, fmt.Errorf("truncated CBOR: need %d bytes at offset %d, have %d", n, r.pos, len(r.buf)-r.pos)
+	}
+	out := r.buf[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return out, nil
+}
+
+// header reads an item header, returning its major type and argument, and rejects non-minimal encodings.
+func (r *cborValidator) header() (major byte, arg uint64, err error) {
+	start := r.pos
+	first, err := r.take(1)
+	if err != nil {
+		return 0, 0, err
+	}
+	major, info := first[0]>>5, first[0]&0x1f
+
+	var width uint64
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		width = 1
+	case info == 25:
+		width = 2
+	case info == 26:
+		width = 4
+	case info == 27:
+		width = 8
+	case info == 31:
+		return 0, 0, fmt.Errorf("indefinite length item at offset %d", start)
+	default:
+		return 0, 0, fmt.Errorf("reserved additional info %d at offset %d", info, start)
+	}
+
+	raw, err := r.take(width)
+	if err != nil {
+		return 0, 0, err
+	}
+	padded := make([]byte, 8)
+	copy(padded[8-width:], raw)
+	arg = binary.BigEndian.Uint64(padded)
+
+	// Floats are exempt from minimal encoding as their width is part of their value.
+	if major == 7 {
+		return major, arg, nil
+	}
+	var min uint64
+	switch width {
+	case 1:
+		min = 24
+	case 2:
+		min = 1 << 8
+	case 4:
+		min = 1 << 16
+	case 8:
+		min = 1 << 32
+	}
+	if arg < min {
+		return 0, 0, fmt.Errorf("non-minimal header encoding of %d at offset %d", arg, start)
+	}
+	return major, arg, nil
+}
+
+// cid reads the byte string content of a CID tag.
+func (r *cborValidator) cid() error {
+	start := r.pos
+	major, arg, err := r.header()
+	if err != nil {
+		return err
+	}
+	if major != 2 {
+		return fmt.Errorf("CID tag content is not a byte string at offset %d", start)
+	}
+	b, err := r.take(arg)
+	if err != nil {
+		return err
+	}
+	r.cids = append(r.cids, b)
+	return nil
+}
+
+func (r *cborValidator) item(depth int) error {
+	if depth > maxCBORDepth {
+		return fmt.Errorf("CBOR nested deeper than %d", maxCBORDepth)
+	}
+	start := r.pos
+	major, arg, err := r.header()
+	if err != nil {
+		return err
+	}
+
+	switch major {
+	case 0, 1: // unsigned and negative integers
+		return nil
+	case 2: // byte string
+		_, err := r.take(arg)
+		return err
+	case 3: // text string
+		s, err := r.take(arg)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(s) {
+			return fmt.Errorf("invalid UTF-8 text string at offset %d", start)
+		}
+		return nil
+	case 4: // array
+		for i := uint64(0); i < arg; i++ {
+			if err := r.item(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 5: // map
+		var prevKey []byte
+		for i := uint64(0); i < arg; i++ {
+			keyStart := r.pos
+			if err := r.item(depth + 1); err != nil {
+				return err
+			}
+			key := r.buf[keyStart:r.pos]
+			// Canonical key order is by encoded length first, then bytewise.
+			if i > 0 && (len(key) < len(prevKey) || (len(key) == len(prevKey) && bytes.Compare(key, prevKey) <= 0)) {
+				return fmt.Errorf("map keys unsorted or duplicated at offset %d", keyStart)
+			}
+			prevKey = key
+			if err := r.item(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 6: // tag
+		if arg != cidTag {
+			return fmt.Errorf("unsupported tag %d at offset %d", arg, start)
+		}
+		return r.cid()
+	default: // major type 7, simple values and floats
+		info := r.buf[start] & 0x1f
+		switch {
+		case info == 20 || info == 21 || info == 22: // false, true, null
+			return nil
+		case info == 27: // 64-bit float
+			return nil
+		default:
+			return fmt.Errorf("unsupported simple value or float encoding %d at offset %d", info, start)
+		}
+	}
+}
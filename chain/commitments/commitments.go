@@ -0,0 +1,187 @@
+// Package commitments provides stable, well-known commitments and pieces for suites which need CIDs that look real
+// to actors but carry no meaning, so that every suite (and every implementation) derives the same values.
+//
+// All commitments are derived from a 32 byte token holding the uvarint encoding of an index, zero padded, with its
+// last byte marking the kind of commitment: zero for the pieces of Pieces and the sealed commitments of SealedCID,
+// dealPieceMarker for DealPiece and unsealedMarker for UnsealedCID, so that no two kinds share a CID. The token is
+// wrapped as an unsealed (data) commitment CID with commcid.DataCommitmentV1ToCID or as a sealed (replica)
+// commitment CID with commcid.ReplicaCommitmentV1ToCID. Describe recovers the derivation of any of them, so that
+// the CIDs found in a vector may be listed with it, see WellKnown.
+package commitments
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	commcid "github.com/filecoin-project/go-fil-commcid"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	cid "github.com/ipfs/go-cid"
+)
+
+// Piece is a well-known piece.
+type Piece struct {
+	Size abi.PaddedPieceSize
+	CID  cid.Cid
+}
+
+// Info returns the piece as used in deal proposals and unsealed CID computation.
+func (p Piece) Info() abi.PieceInfo {
+	return abi.PieceInfo{Size: p.Size, PieceCID: p.CID}
+}
+
+// Smallest and largest padded piece sizes of Pieces. The largest is the sector size of the test seal proof.
+const (
+	MinPieceSize = abi.PaddedPieceSize(128)
+	MaxPieceSize = abi.PaddedPieceSize(2 << 10)
+)
+
+// Pieces holds one piece of each power of two padded size from MinPieceSize to MaxPieceSize, smallest first.
+// A piece's CID is the unsealed commitment of the unmarked token with its padded size as the index.
+var Pieces []Piece
+
+func init() {
+	for size := MinPieceSize; size <= MaxPieceSize; size <<= 1 {
+		Pieces = append(Pieces, Piece{Size: size, CID: dataCID(token(uint64(size), 0))})
+	}
+}
+
+// PieceOfSize returns the well-known piece with padded size `size`, which must be a power of two between
+// MinPieceSize and MaxPieceSize.
+func PieceOfSize(size abi.PaddedPieceSize) Piece {
+	for _, p := range Pieces {
+		if p.Size == size {
+			return p
+		}
+	}
+	panic(fmt.Sprintf("no well-known piece of size %d", size))
+}
+
+// UnsealedCID returns the unsealed (data) commitment of a sector with index `n`. It is distinct from the CIDs of
+// Pieces and DealPiece.
+func UnsealedCID(n uint64) cid.Cid {
+	return dataCID(token(n, unsealedMarker))
+}
+
+// SealedCID returns the sealed (replica) commitment with index `n`.
+func SealedCID(n uint64) cid.Cid {
+	c, err := commcid.ReplicaCommitmentV1ToCID(token(n, 0))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// DealPiece returns the piece of MinPieceSize with index `n`, for suites needing many distinct pieces, such as a
+// piece per deal. Its CID is distinct from those of Pieces and from the commitments of UnsealedCID and SealedCID.
+func DealPiece(n uint64) Piece {
+	return Piece{Size: MinPieceSize, CID: dataCID(token(n, dealPieceMarker))}
+}
+
+// Markers of the kinds of commitment sharing the unsealed CID codec.
+const (
+	dealPieceMarker = 1
+	unsealedMarker  = 2
+)
+
+// token returns the token with index `n` and marker `marker`. The uvarint of an index never reaches the last byte.
+func token(n uint64, marker byte) []byte {
+	t := make([]byte, 32)
+	binary.PutUvarint(t, n)
+	t[len(t)-1] = marker
+	return t
+}
+
+func dataCID(t []byte) cid.Cid {
+	c, err := commcid.DataCommitmentV1ToCID(t)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Describe returns the call deriving the well-known commitment `c`, e.g. "UnsealedCID(3)" or "PieceOfSize(2048)",
+// or false if `c` isn't one.
+func Describe(c cid.Cid) (string, bool) {
+	var sealed bool
+	var t []byte
+	if d, err := commcid.CIDToDataCommitmentV1(c); err == nil {
+		t = d
+	} else if r, err := commcid.CIDToReplicaCommitmentV1(c); err == nil {
+		sealed, t = true, r
+	} else {
+		return "", false
+	}
+
+	n, read := binary.Uvarint(t)
+	if read <= 0 || !bytes.Equal(t, token(n, t[len(t)-1])) {
+		return "", false
+	}
+	switch marker := t[len(t)-1]; {
+	case sealed && marker == 0:
+		return fmt.Sprintf("SealedCID(%d)", n), true
+	case sealed:
+		return "", false
+	case marker == unsealedMarker:
+		return fmt.Sprintf("UnsealedCID(%d)", n), true
+	case marker == dealPieceMarker:
+		return fmt.Sprintf("DealPiece(%d)", n), true
+	case marker == 0:
+		for _, p := range Pieces {
+			if p.Size == abi.PaddedPieceSize(n) {
+				return fmt.Sprintf("PieceOfSize(%d)", n), true
+			}
+		}
+	}
+	return "", false
+}
+
+// WellKnown is a well-known commitment found in a vector, listed in its metadata.
+type WellKnown struct {
+	CID cid.Cid
+	// Derivation is the call of this package deriving the CID, see Describe.
+	Derivation string
+}
+
+// prefixes are the bytes preceding the digest in the encoding of every well-known commitment CID of each codec.
+var prefixes = func() [][]byte {
+	var out [][]byte
+	for _, c := range []cid.Cid{UnsealedCID(0), SealedCID(0)} {
+		b := c.Bytes()
+		out = append(out, b[:len(b)-32])
+	}
+	return out
+}()
+
+// Find returns the well-known commitments whose CIDs appear in `data`, such as the encoded params of a message,
+// ordered by CID and without repetition. The data needn't be decodable: CIDs are found by their encoding alone.
+func Find(data ...[]byte) []WellKnown {
+	found := make(map[cid.Cid]string)
+	for _, d := range data {
+		for _, prefix := range prefixes {
+			for off := 0; ; {
+				i := bytes.Index(d[off:], prefix)
+				if i < 0 {
+					break
+				}
+				start := off + i
+				if end := start + len(prefix) + 32; end <= len(d) {
+					if c, err := cid.Cast(d[start:end]); err == nil {
+						if desc, ok := Describe(c); ok {
+							found[c] = desc
+						}
+					}
+				}
+				off = start + 1
+			}
+		}
+	}
+
+	out := make([]WellKnown, 0, len(found))
+	for c, desc := range found {
+		out = append(out, WellKnown{CID: c, Derivation: desc})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CID.KeyString() < out[j].CID.KeyString() })
+	return out
+}
@@ -13,6 +13,12 @@ type MessageProducer struct {
 	defaults msgOpts // Note non-pointer reference.
 
 	messages []*types.Message
+
+	// Next nonce of each sender, if nonce tracking is enabled.
+	nonces map[address.Address]uint64
+
+	// Replaces the sender of each message built, if set.
+	senderMap func(address.Address) address.Address
 }
 
 // NewMessageProducer creates a new message producer, delegating message creation to `factory`.
@@ -32,6 +38,43 @@ func (mp *MessageProducer) Messages() []*types.Message {
 	return mp.messages
 }
 
+// EnableNonceTracking makes the producer track the next nonce of each sender, defaulting the nonce of each message
+// to it unless set by the Nonce option. A message with an explicit nonce moves its sender's next nonce past it.
+// Senders are tracked by the address given as `from`, after MapSenders, so a sender should be referred to by a single
+// address.
+func (mp *MessageProducer) EnableNonceTracking() {
+	if mp.nonces == nil {
+		mp.nonces = make(map[address.Address]uint64)
+	}
+}
+
+// NextNonce returns the nonce the next message from `from` will default to, if nonce tracking is enabled.
+func (mp *MessageProducer) NextNonce(from address.Address) uint64 {
+	return mp.nonces[mp.sender(from)]
+}
+
+// SetNextNonce sets the nonce the next message from `from` defaults to, e.g. after a message built by the producer
+// was never applied, if nonce tracking is enabled.
+func (mp *MessageProducer) SetNextNonce(from address.Address, nonce uint64) {
+	if mp.nonces != nil {
+		mp.nonces[mp.sender(from)] = nonce
+	}
+}
+
+// MapSenders makes the producer replace the sender of each message it builds with `fn(from)`, e.g. to address
+// senders by another of their addresses than that given by a suite.
+func (mp *MessageProducer) MapSenders(fn func(from address.Address) address.Address) {
+	mp.senderMap = fn
+}
+
+// sender returns the sender of messages built from `from`, as replaced by MapSenders.
+func (mp *MessageProducer) sender(from address.Address) address.Address {
+	if mp.senderMap == nil {
+		return from
+	}
+	return mp.senderMap(from)
+}
+
 // BuildFull creates and returns a single message.
 func (mp *MessageProducer) BuildFull(from, to address.Address, method abi_spec.MethodNum, callSeq uint64, value, gasFeeCap abi_spec.TokenAmount, gasPremium abi_spec.TokenAmount, gasLimit int64, params []byte) *types.Message {
 	fm := &types.Message{
@@ -55,21 +98,44 @@ func (mp *MessageProducer) Build(from, to address.Address, method abi_spec.Metho
 	for _, opt := range opts {
 		opt(&values)
 	}
+	if values.methodSet {
+		method = values.method
+	}
+	if values.paramsSet {
+		params = values.params
+	}
+	from = mp.sender(from)
+	if mp.nonces != nil {
+		if !values.nonceSet {
+			values.nonce = mp.nonces[from]
+		}
+		mp.nonces[from] = values.nonce + 1
+	}
 
-	return mp.BuildFull(from, to, method, values.nonce, values.value, values.gasFeeCap, values.gasPremium, values.gasLimit, params)
+	msg := mp.BuildFull(from, to, method, values.nonce, values.value, values.gasFeeCap, values.gasPremium, values.gasLimit, params)
+	msg.Version = values.version
+	return msg
 }
 
-// msgOpts specifies value and gas parameters for a message, supporting a functional options pattern
-// for concise but customizable message construction.
+// msgOpts specifies value and gas parameters for a message, and overrides of its method and params, supporting a
+// functional options pattern for concise but customizable message construction.
 type msgOpts struct {
 	nonce      uint64
+	nonceSet   bool
 	value      big_spec.Int
 	gasLimit   int64
 	gasFeeCap  abi_spec.TokenAmount
 	gasPremium abi_spec.TokenAmount
+
+	method    abi_spec.MethodNum
+	methodSet bool
+	params    []byte
+	paramsSet bool
+
+	version uint64
 }
 
-// MsgOpt is an option configuring message value or gas parameters.
+// MsgOpt is an option configuring message value or gas parameters, or overriding its method or params.
 type MsgOpt func(*msgOpts)
 
 func Value(value big_spec.Int) MsgOpt {
@@ -81,6 +147,7 @@ func Value(value big_spec.Int) MsgOpt {
 func Nonce(n uint64) MsgOpt {
 	return func(opts *msgOpts) {
 		opts.nonce = n
+		opts.nonceSet = true
 	}
 }
 
@@ -101,3 +168,27 @@ func GasPremium(premium int64) MsgOpt {
 		opts.gasPremium = abi_spec.NewTokenAmount(premium)
 	}
 }
+
+// Method overrides the method number of a message, e.g. to send a helper's params to an unknown method.
+func Method(method abi_spec.MethodNum) MsgOpt {
+	return func(opts *msgOpts) {
+		opts.method = method
+		opts.methodSet = true
+	}
+}
+
+// Version sets the version of a message, zero by default, e.g. to check that messages of unsupported versions may not
+// be included in blocks. It changes neither the message's serialization nor its CID.
+func Version(v uint64) MsgOpt {
+	return func(opts *msgOpts) {
+		opts.version = v
+	}
+}
+
+// RawParams overrides the serialized params of a message, e.g. with deliberately malformed bytes.
+func RawParams(params []byte) MsgOpt {
+	return func(opts *msgOpts) {
+		opts.params = params
+		opts.paramsSet = true
+	}
+}
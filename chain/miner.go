@@ -83,3 +83,11 @@ func (mp *MessageProducer) MinerChangeMultiaddrs(from, to address.Address, param
 	ser := MustSerialize(params)
 	return mp.Build(from, to, builtin_spec.MethodsMiner.ChangeMultiaddrs, ser, opts...)
 }
+func (mp *MessageProducer) MinerCompactPartitions(from, to address.Address, params *miner.CompactPartitionsParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsMiner.CompactPartitions, ser, opts...)
+}
+func (mp *MessageProducer) MinerCompactSectorNumbers(from, to address.Address, params *miner.CompactSectorNumbersParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsMiner.CompactSectorNumbers, ser, opts...)
+}
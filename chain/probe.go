@@ -0,0 +1,47 @@
+package chain
+
+import (
+	"github.com/filecoin-project/go-address"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+func (mp *MessageProducer) ProbeBurnGas(from, to address.Address, params *probe.BurnParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, probe.MethodsProbe.BurnGas, ser, opts...)
+}
+
+func (mp *MessageProducer) ProbeSetValue(from, to address.Address, value int64, opts ...MsgOpt) *types.Message {
+	params := cbg.CborInt(value)
+	ser := MustSerialize(&params)
+	return mp.Build(from, to, probe.MethodsProbe.SetValue, ser, opts...)
+}
+
+func (mp *MessageProducer) ProbeReenter(from, to address.Address, depth int64, opts ...MsgOpt) *types.Message {
+	params := cbg.CborInt(depth)
+	ser := MustSerialize(&params)
+	return mp.Build(from, to, probe.MethodsProbe.Reenter, ser, opts...)
+}
+
+func (mp *MessageProducer) ProbeNest(from, to address.Address, params *probe.NestParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, probe.MethodsProbe.Nest, ser, opts...)
+}
+
+func (mp *MessageProducer) ProbeRelay(from, to address.Address, params *probe.RelayParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, probe.MethodsProbe.Relay, ser, opts...)
+}
+
+func (mp *MessageProducer) ProbeCallBurnGas(from, to address.Address, params *probe.BurnParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, probe.MethodsProbe.CallBurnGas, ser, opts...)
+}
+
+func (mp *MessageProducer) ProbeReturnBytes(from, to address.Address, size int64, opts ...MsgOpt) *types.Message {
+	params := cbg.CborInt(size)
+	ser := MustSerialize(&params)
+	return mp.Build(from, to, probe.MethodsProbe.ReturnBytes, ser, opts...)
+}
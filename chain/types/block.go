@@ -1,11 +1,23 @@
 package types
 
-import "github.com/filecoin-project/go-address"
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+)
 
 // BlockMessagesInfo contains messages for one block in a tipset.
 type BlockMessagesInfo struct {
 	BLSMessages  []*Message
 	SECPMessages []*SignedMessage
 	Miner        address.Address
-	TicketCount  int64
+	// TicketCount is the block's number of election wins, each earning its miner a block reward.
+	TicketCount int64
+	// Ticket is the VRF proof of the block's ticket.
+	Ticket []byte
+	// Timestamp is the timestamp of the block's header.
+	Timestamp uint64
+
+	// BLSAggregate is the aggregate of the BLS messages' signatures over their CIDs, as carried in a block header.
+	// It is nil when the block was built without one, in which case implementations should skip verification.
+	BLSAggregate *crypto.Signature
 }
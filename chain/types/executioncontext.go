@@ -7,11 +7,15 @@ import (
 
 // ExecutionContext provides the context for execution of a message.
 type ExecutionContext struct {
-	Epoch abi.ChainEpoch  // The epoch number ("height") during which a message is executed.
-	Miner address.Address // The miner actor which earns gas fees from message execution.
+	Epoch   abi.ChainEpoch  // The epoch number ("height") during which a message is executed.
+	Miner   address.Address // The miner actor which earns gas fees from message execution.
+	BaseFee abi.TokenAmount // The base fee per unit of gas burnt by message execution.
+	// The circulating supply actors observe, e.g. when computing pledge requirements, or nil for the implementation
+	// to compute it from its state.
+	CircSupply *abi.TokenAmount
 }
 
 // NewExecutionContext builds a new execution context.
-func NewExecutionContext(epoch int64, miner address.Address) *ExecutionContext {
-	return &ExecutionContext{abi.ChainEpoch(epoch), miner}
+func NewExecutionContext(epoch int64, miner address.Address, baseFee abi.TokenAmount) *ExecutionContext {
+	return &ExecutionContext{Epoch: abi.ChainEpoch(epoch), Miner: miner, BaseFee: baseFee}
 }
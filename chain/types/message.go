@@ -17,6 +17,12 @@ import (
 )
 
 type Message struct {
+	// Version of the message format. Zero is the only version supported, and the only one the encoding below
+	// represents: the version of a message isn't part of its serialization or CID, so signatures don't cover it. It
+	// is only carried by the message passed to implementations, whose block validation must reject messages of other
+	// versions.
+	Version uint64
+
 	// Address of the receiving actor.
 	To address.Address
 	// Address of the sending actor.
@@ -1,8 +1,12 @@
 package types
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/filecoin-project/specs-actors/actors/abi/big"
 	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	cbg "github.com/whyrusleeping/cbor-gen"
 )
 
 // MessageReceipt is the return value of message application.
@@ -18,3 +22,49 @@ type GasUnits int64
 func (gu GasUnits) Big() big.Int {
 	return big.NewInt(int64(gu))
 }
+
+// MarshalCBOR encodes the receipt as it is stored in the receipts AMT of a block header.
+// Taken from lotus, as are the marshalers of Message.
+func (t *MessageReceipt) MarshalCBOR(w io.Writer) error {
+	if t == nil {
+		_, err := w.Write(cbg.CborNull)
+		return err
+	}
+	if _, err := w.Write([]byte{131}); err != nil {
+		return err
+	}
+
+	// t.ExitCode (exitcode.ExitCode) (int64)
+	if t.ExitCode >= 0 {
+		if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajUnsignedInt, uint64(t.ExitCode))); err != nil {
+			return err
+		}
+	} else {
+		if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajNegativeInt, uint64(-t.ExitCode)-1)); err != nil {
+			return err
+		}
+	}
+
+	// t.Return ([]uint8) (slice)
+	if len(t.ReturnValue) > cbg.ByteArrayMaxLen {
+		return fmt.Errorf("Byte array in field t.Return was too long")
+	}
+	if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajByteString, uint64(len(t.ReturnValue)))); err != nil {
+		return err
+	}
+	if _, err := w.Write(t.ReturnValue); err != nil {
+		return err
+	}
+
+	// t.GasUsed (int64) (int64)
+	if t.GasUsed >= 0 {
+		if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajUnsignedInt, uint64(t.GasUsed))); err != nil {
+			return err
+		}
+	} else {
+		if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajNegativeInt, uint64(-t.GasUsed)-1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"fmt"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/specs-actors/actors/abi"
 	"github.com/ipfs/go-cid"
 )
@@ -14,6 +15,7 @@ type Trackable interface {
 
 var _ Trackable = (*ApplyMessageResult)(nil)
 var _ Trackable = (*ApplyTipSetResult)(nil)
+var _ Trackable = (*Checkpoint)(nil)
 
 type ApplyMessageResult struct {
 	Msg     Message
@@ -21,10 +23,60 @@ type ApplyMessageResult struct {
 	Penalty abi.TokenAmount
 	Reward  abi.TokenAmount
 	Root    string
+
+	// ImplMetrics optionally carries implementation specific counters for the application of the message, such as
+	// actor cache hits or syscall counts. They are surfaced in reports but never recorded as expectations.
+	// Names must not contain whitespace.
+	ImplMetrics map[string]int64 `json:"-"`
+
+	// Events optionally lists the events emitted while applying the message, in order, for implementations that
+	// expose them. It is nil if the implementation doesn't, and never recorded as an expectation.
+	Events []Event `json:"-"`
+
+	// GasBreakdown optionally attributes the gas used by the message to the categories of charges made, e.g.
+	// GasChargeReturnValue, for implementations that expose them. Unlike the other optional fields it is recorded,
+	// so that a difference in gas used may be localized to the charges responsible.
+	GasBreakdown map[string]GasUnits `json:",omitempty"`
+
+	// ReceiverCode names the code of the message's receiver once the message is applied, e.g. "fil/1/account", or is
+	// empty if there is no actor at the receiver's address. It is filled in by the driver rather than the
+	// implementation, and recorded, so that recorded vectors may be composed by the types of actor they exercise.
+	ReceiverCode string `json:",omitempty"`
+}
+
+// Categories of gas charges, by which implementations may break down the gas used by a message. Implementations
+// may use other categories for charges that fit none of these.
+const (
+	// The charge for the inclusion of the message on chain, by its size.
+	GasChargeOnChainMessage = "on-chain-message"
+	// The charge for the inclusion of the return value in the receipt, by its size.
+	GasChargeReturnValue = "return-value"
+	// Charges for writing to the state store.
+	GasChargeStoragePut = "storage-put"
+	// Charges for reading from the state store.
+	GasChargeStorageGet = "storage-get"
+	// Charges for syscalls, such as signature and proof verification.
+	GasChargeSyscall = "syscall"
+	// Charges for computation, such as method invocation and actor creation.
+	GasChargeCompute = "compute"
+)
+
+// Event is an event emitted during the application of a message, such as a runtime log line.
+type Event struct {
+	// Emitter is the address of the actor that emitted the event.
+	Emitter address.Address
+	// Type names the kind of event, e.g. "log".
+	Type string
+	// Data is the payload of the event, e.g. the text of a log line.
+	Data []byte
 }
 
 func (mr ApplyMessageResult) GoSyntax() string {
-	return fmt.Sprintf("types.ApplyMessageResult{Receipt: %#v, Penalty: abi.NewTokenAmount(%d), Reward: abi.NewTokenAmount(%d), Root: \"%s\"}", mr.Receipt, mr.Penalty, mr.Reward, mr.Root)
+	breakdown := ""
+	if len(mr.GasBreakdown) > 0 {
+		breakdown = fmt.Sprintf(", GasBreakdown: %#v", mr.GasBreakdown)
+	}
+	return fmt.Sprintf("types.ApplyMessageResult{Receipt: %#v, Penalty: abi.NewTokenAmount(%d), Reward: abi.NewTokenAmount(%d), Root: \"%s\"%s}", mr.Receipt, mr.Penalty, mr.Reward, mr.Root, breakdown)
 }
 
 func (mr ApplyMessageResult) GoContainer() string {
@@ -46,6 +98,11 @@ func (mr ApplyMessageResult) GasUsed() GasUnits {
 type ApplyTipSetResult struct {
 	Receipts []MessageReceipt
 	Root     string
+
+	// ReceiptsRoot optionally is the root of the AMT of receipts the implementation builds for the tipset's blocks,
+	// as a block header commits to them, for implementations that expose it. Recorded with the tipset's results, it
+	// checks the serialization of receipts and the construction of the AMT, rather than the receipts' fields alone.
+	ReceiptsRoot string `json:",omitempty"`
 }
 
 func (tr ApplyTipSetResult) GoSyntax() string {
@@ -63,3 +120,26 @@ func (tr ApplyTipSetResult) StateRoot() cid.Cid {
 	}
 	return root
 }
+
+// Checkpoint is the state root at a point of a test named by the suite, rather than identified by the number of
+// messages or tipsets applied before it.
+type Checkpoint struct {
+	Name string
+	Root string
+}
+
+func (c Checkpoint) GoSyntax() string {
+	return fmt.Sprintf("%#v", c)
+}
+
+func (c Checkpoint) GoContainer() string {
+	return "[]types.Checkpoint"
+}
+
+func (c Checkpoint) StateRoot() cid.Cid {
+	root, err := cid.Decode(c.Root)
+	if err != nil {
+		panic(err)
+	}
+	return root
+}
@@ -1,8 +1,6 @@
 package chain
 
 import (
-	"github.com/filecoin-project/specs-actors/actors/abi"
-
 	"github.com/filecoin-project/chain-validation/chain/types"
 	"github.com/filecoin-project/chain-validation/state"
 )
@@ -18,14 +16,29 @@ func NewValidator(executor state.Applier) *Validator {
 }
 
 // ApplyMessages applies a message to a state
-func (v *Validator) ApplyMessage(epoch abi.ChainEpoch, message *types.Message) (types.ApplyMessageResult, error) {
-	return v.applier.ApplyMessage(epoch, message)
+func (v *Validator) ApplyMessage(exeCtx types.ExecutionContext, message *types.Message) (types.ApplyMessageResult, error) {
+	return v.applier.ApplyMessage(exeCtx, message)
+}
+
+func (v *Validator) ApplySignedMessage(exeCtx types.ExecutionContext, message *types.SignedMessage) (types.ApplyMessageResult, error) {
+	return v.applier.ApplySignedMessage(exeCtx, message)
+}
+
+func (v *Validator) ApplyTipSetMessages(exeCtx types.ExecutionContext, blocks []types.BlockMessagesInfo, rnd state.RandomnessSource) (types.ApplyTipSetResult, error) {
+	return v.applier.ApplyTipSetMessages(exeCtx, blocks, rnd)
 }
 
-func (v *Validator) ApplySignedMessage(epoch abi.ChainEpoch, message *types.SignedMessage) (types.ApplyMessageResult, error) {
-	return v.applier.ApplySignedMessage(epoch, message)
+// CallMessage executes a message without committing its effects.
+func (v *Validator) CallMessage(exeCtx types.ExecutionContext, message *types.Message) (types.ApplyMessageResult, error) {
+	return v.applier.CallMessage(exeCtx, message)
 }
 
-func (v *Validator) ApplyTipSetMessages(epoch abi.ChainEpoch, blocks []types.BlockMessagesInfo, rnd state.RandomnessSource) (types.ApplyTipSetResult, error) {
-	return v.applier.ApplyTipSetMessages(epoch, blocks, rnd)
+// ValidateMessageForBlock checks whether a message may be included in a block after the messages `prior`. The
+// returned bool is false if the applier doesn't implement state.MessageValidatingApplier.
+func (v *Validator) ValidateMessageForBlock(exeCtx types.ExecutionContext, prior []*types.SignedMessage, message *types.SignedMessage) (bool, error) {
+	mv, ok := v.applier.(state.MessageValidatingApplier)
+	if !ok {
+		return false, nil
+	}
+	return true, mv.ValidateMessageForBlock(exeCtx, prior, message)
 }
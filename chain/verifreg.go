@@ -0,0 +1,34 @@
+package chain
+
+import (
+	"github.com/filecoin-project/go-address"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/verifreg"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+func (mp *MessageProducer) VerifregConstructor(from, to address.Address, params *address.Address, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsVerifiedRegistry.Constructor, ser, opts...)
+}
+func (mp *MessageProducer) VerifregAddVerifier(from, to address.Address, params *verifreg.AddVerifierParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsVerifiedRegistry.AddVerifier, ser, opts...)
+}
+func (mp *MessageProducer) VerifregRemoveVerifier(from, to address.Address, params *address.Address, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsVerifiedRegistry.RemoveVerifier, ser, opts...)
+}
+func (mp *MessageProducer) VerifregAddVerifiedClient(from, to address.Address, params *verifreg.AddVerifiedClientParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsVerifiedRegistry.AddVerifiedClient, ser, opts...)
+}
+func (mp *MessageProducer) VerifregUseBytes(from, to address.Address, params *verifreg.UseBytesParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsVerifiedRegistry.UseBytes, ser, opts...)
+}
+func (mp *MessageProducer) VerifregRestoreBytes(from, to address.Address, params *verifreg.RestoreBytesParams, opts ...MsgOpt) *types.Message {
+	ser := MustSerialize(params)
+	return mp.Build(from, to, builtin_spec.MethodsVerifiedRegistry.RestoreBytes, ser, opts...)
+}
@@ -47,6 +47,26 @@ func (blsSigner) Verify(sig []byte, a address.Address, msg []byte) error {
 	return nil
 }
 
+// AggregateBLSSignatures aggregates BLS signatures into a single signature.
+func AggregateBLSSignatures(sigs []crypto.Signature) (*crypto.Signature, error) {
+	blsSigs := make([]ffi.Signature, len(sigs))
+	for i, sig := range sigs {
+		if sig.Type != crypto.SigTypeBLS {
+			return nil, fmt.Errorf("cannot aggregate signature of type %d", sig.Type)
+		}
+		copy(blsSigs[i][:], sig.Data)
+	}
+
+	agg := ffi.Aggregate(blsSigs)
+	if agg == nil {
+		return nil, fmt.Errorf("failed to aggregate %d bls signatures", len(sigs))
+	}
+	return &crypto.Signature{
+		Type: crypto.SigTypeBLS,
+		Data: agg[:],
+	}, nil
+}
+
 func init() {
 	RegisterSignature(crypto.SigTypeBLS, blsSigner{})
 }
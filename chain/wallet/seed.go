@@ -0,0 +1,35 @@
+package wallet
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/minio/blake2b-simd"
+)
+
+// Order of the secp256k1 group. Private keys must be non-zero and less than it.
+var secpOrder, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+
+// PrivateKeyFromSeed derives a private key of type `sigType` from `seed`. The derivation is part of the test vector
+// format: every implementation must derive the same key, and hence the same address, from the same seed.
+//
+// The key is the blake2b-256 hash of a domain separation tag followed by the seed. A secp256k1 key out of range is
+// rehashed until it is in range. A BLS key is a little endian scalar whose top two bits are cleared, keeping it below
+// the BLS12-381 group order.
+func PrivateKeyFromSeed(sigType crypto.SigType, seed []byte) ([]byte, error) {
+	switch sigType {
+	case crypto.SigTypeSecp256k1:
+		key := blake2b.Sum256(append([]byte("chain-validation/secp256k1/"), seed...))
+		for k := new(big.Int).SetBytes(key[:]); k.Sign() == 0 || k.Cmp(secpOrder) >= 0; k.SetBytes(key[:]) {
+			key = blake2b.Sum256(key[:])
+		}
+		return key[:], nil
+	case crypto.SigTypeBLS:
+		key := blake2b.Sum256(append([]byte("chain-validation/bls/"), seed...))
+		key[31] &= 0x3f
+		return key[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported signature type %d", sigType)
+	}
+}
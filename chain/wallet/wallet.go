@@ -111,3 +111,13 @@ func ToPublic(sigType crypto.SigType, pk []byte) ([]byte, error) {
 
 	return sv.ToPublic(pk)
 }
+
+// Verify checks that `sig` is a signature of `msg` by the key of address `a`.
+func Verify(sig crypto.Signature, a address.Address, msg []byte) error {
+	sv, ok := sigs[sig.Type]
+	if !ok {
+		return fmt.Errorf("cannot verify signature of unsupported type: %v", sig.Type)
+	}
+
+	return sv.Verify(sig.Data, a, msg)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadCodecMarshal(t *testing.T) {
+	codec := payloadCodec{}
+
+	data, err := codec.Marshal(jsonPayload(`{"a":1}`))
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{0x0a, 7}, `{"a":1}`...), data)
+
+	// A payload of 300 bytes takes a two byte length.
+	long := make(jsonPayload, 300)
+	data, err = codec.Marshal(long)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x0a, 0xac, 0x02}, data[:3])
+	assert.Len(t, data, 303)
+
+	data, err = codec.Marshal(jsonPayload(nil))
+	require.NoError(t, err)
+	assert.Empty(t, data, "empty payload")
+
+	_, err = codec.Marshal([]byte(`{}`))
+	assert.Error(t, err, "marshalling a value other than a payload")
+}
+
+func TestPayloadCodecUnmarshal(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		data     []byte
+		expected jsonPayload
+		err      bool
+	}{{
+		desc:     "payload",
+		data:     append([]byte{0x0a, 2}, `{}`...),
+		expected: jsonPayload(`{}`),
+	}, {
+		desc:     "no fields",
+		data:     nil,
+		expected: nil,
+	}, {
+		desc: "unknown fields skipped",
+		data: append([]byte{
+			0x10, 0x96, 0x01, // field 2, varint 150
+			0x19, 1, 2, 3, 4, 5, 6, 7, 8, // field 3, fixed64
+			0x25, 1, 2, 3, 4, // field 4, fixed32
+			0x2a, 3, 'a', 'b', 'c', // field 5, bytes
+			0x0a, 2}, `{}`...),
+		expected: jsonPayload(`{}`),
+	}, {
+		desc:     "last payload wins",
+		data:     append(append([]byte{0x0a, 2}, `[]`...), append([]byte{0x0a, 2}, `{}`...)...),
+		expected: jsonPayload(`{}`),
+	}, {
+		desc: "truncated payload",
+		data: append([]byte{0x0a, 5}, `{}`...),
+		err:  true,
+	}, {
+		desc: "payload length beyond the message",
+		data: []byte{0x0a, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f},
+		err:  true,
+	}, {
+		desc: "truncated fixed64",
+		data: []byte{0x19, 1, 2, 3},
+		err:  true,
+	}, {
+		desc: "unsupported wire type",
+		data: []byte{0x0b},
+		err:  true,
+	}}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			var p jsonPayload
+			err := payloadCodec{}.Unmarshal(tc.data, &p)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, p)
+		})
+	}
+
+	var p jsonPayload
+	assert.Error(t, payloadCodec{}.Unmarshal(nil, p), "unmarshalling into a value other than a payload pointer")
+}
+
+func TestPayloadCodecRoundTrip(t *testing.T) {
+	for _, payload := range []jsonPayload{jsonPayload(`null`), jsonPayload(`{"Root":"bafy"}`), make(jsonPayload, 1<<16)} {
+		data, err := payloadCodec{}.Marshal(payload)
+		require.NoError(t, err)
+		var out jsonPayload
+		require.NoError(t, payloadCodec{}.Unmarshal(data, &out))
+		assert.Equal(t, payload, out)
+	}
+}
@@ -0,0 +1,116 @@
+// Package harness launches implementations serving the validation services for the duration of a run, so that CI
+// conformance jobs need no setup of their own beyond an image of the implementation.
+package harness
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	logging "github.com/ipfs/go-log"
+
+	"github.com/filecoin-project/chain-validation/client"
+)
+
+var log = logging.Logger("client/harness")
+
+// ServicePort is the port on which implementations serve the validation services inside their container.
+const ServicePort = "8378"
+
+// DockerPrefix prefixes the specs of harnesses running an image with docker, e.g. "docker:lotus-chainval:latest".
+const DockerPrefix = "docker:"
+
+// ReadyFunc returns nil once the implementation served at `cfg` answers calls.
+type ReadyFunc func(cfg client.Config) error
+
+// Harness is an implementation launched to run the suites against.
+type Harness interface {
+	// Config returns the address of the implementation's services.
+	Config() client.Config
+	// Close stops the implementation and releases its resources.
+	Close() error
+}
+
+// Start launches the implementation described by `spec`, and waits up to `wait` for `ready` to succeed. Calls to the
+// implementation time out after `timeout`. Only docker images, with specs of the form "docker:<image>", are supported.
+func Start(spec string, timeout, wait time.Duration, ready ReadyFunc) (Harness, error) {
+	if !strings.HasPrefix(spec, DockerPrefix) {
+		return nil, fmt.Errorf("unsupported harness %q, expected %s<image>", spec, DockerPrefix)
+	}
+	return StartDocker(strings.TrimPrefix(spec, DockerPrefix), timeout, wait, ready)
+}
+
+var _ Harness = (*Docker)(nil)
+
+// Docker is an implementation run in a docker container, serving the validation services on ServicePort. Each test
+// driver seeds the genesis state of its tests through the services, so the container needs no state of its own.
+type Docker struct {
+	image     string
+	container string
+	cfg       client.Config
+}
+
+// StartDocker runs `image` in a new container, publishing its ServicePort on a free port of the loopback interface,
+// and waits up to `wait` for `ready` to succeed. The container is removed if it doesn't become ready.
+func StartDocker(image string, timeout, wait time.Duration, ready ReadyFunc) (*Docker, error) {
+	out, err := docker("run", "--detach", "--rm", "--publish", "127.0.0.1::"+ServicePort, image)
+	if err != nil {
+		return nil, fmt.Errorf("starting %s: %w", image, err)
+	}
+	d := &Docker{image: image, container: out}
+	log.Infow("started container", "image", image, "container", d.container)
+
+	out, err = docker("port", d.container, ServicePort+"/tcp")
+	if err != nil {
+		_ = d.Close()
+		return nil, fmt.Errorf("finding port of %s: %w", image, err)
+	}
+	// Published ports are listed one per line, the same port for each address family.
+	host, port, err := net.SplitHostPort(strings.SplitN(out, "\n", 2)[0])
+	if err != nil {
+		_ = d.Close()
+		return nil, fmt.Errorf("parsing port of %s: %w", image, err)
+	}
+	d.cfg = client.Config{Host: host, Port: port, Timeout: timeout}
+
+	deadline := time.Now().Add(wait)
+	for {
+		err := ready(d.cfg)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			logs, _ := docker("logs", d.container)
+			_ = d.Close()
+			return nil, fmt.Errorf("%s not ready after %s: %w\n%s", image, wait, err, logs)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	log.Infow("container ready", "image", image, "host", host, "port", port)
+	return d, nil
+}
+
+func (d *Docker) Config() client.Config {
+	return d.cfg
+}
+
+func (d *Docker) Close() error {
+	log.Infow("removing container", "image", d.image, "container", d.container)
+	_, err := docker("rm", "--force", d.container)
+	return err
+}
+
+// docker runs the docker CLI with `args`, returning its trimmed standard output.
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
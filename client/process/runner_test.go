@@ -1,17 +1,18 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
-	"reflect"
-	"runtime"
-	"strings"
 	"testing"
 	"time"
 
 	logging "github.com/ipfs/go-log"
 
 	"github.com/filecoin-project/chain-validation/client"
+	"github.com/filecoin-project/chain-validation/client/harness"
 	"github.com/filecoin-project/chain-validation/client/services"
+	"github.com/filecoin-project/chain-validation/client/services/config"
 	"github.com/filecoin-project/chain-validation/suites"
 )
 
@@ -24,12 +25,15 @@ const (
 	Env_Host    = "CHAIN_VALIDATION_HOST"
 	Env_Post    = "CHAIN_VALIDATION_PORT"
 	Env_Timeout = "CHAIN_VALIDATION_TIMEOUT"
+	// Env_Transport selects how the implementation is served: "jsonrpc" over HTTP, the default, or "grpc".
+	Env_Transport = "CHAIN_VALIDATION_TRANSPORT"
 )
 
 var (
-	host    string
-	port    string
-	timeout time.Duration
+	host      string
+	port      string
+	timeout   time.Duration
+	transport string
 )
 
 func init() {
@@ -51,7 +55,61 @@ func init() {
 			panic(err)
 		}
 	}
+	transport = os.Getenv(Env_Transport)
+}
+
+var (
+	harnessSpec = flag.String("harness", "", "implementation to launch for the run, e.g. docker:<image>, rather than the one served at $"+Env_Host+":$"+Env_Post)
+	harnessWait = flag.Duration("harness-wait", time.Minute, "time to wait for the launched implementation to serve")
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *harnessSpec == "" {
+		os.Exit(m.Run())
+	}
+
+	h, err := harness.Start(*harnessSpec, timeout, *harnessWait, func(cfg client.Config) error {
+		c, err := newClient(cfg)
+		if err != nil {
+			return err
+		}
+		_, err = config.NewConfigService(c).GetConfig()
+		return err
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg := h.Config()
+	host, port = cfg.Host, cfg.Port
+
+	code := m.Run()
+	if err := h.Close(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
+
+// newClient returns a client calling the implementation at `cfg` over the configured transport.
+func newClient(cfg client.Config) (client.Client, error) {
+	switch transport {
+	case "", "jsonrpc":
+		return client.NewRpcClient(cfg), nil
+	case "grpc":
+		return client.NewGrpcClient(cfg)
+	default:
+		return nil, fmt.Errorf("unknown transport %q, set %s to jsonrpc or grpc", transport, Env_Transport)
+	}
+}
 
+// newHandler returns a handler calling the implementation at `cfg` over the configured transport.
+func newHandler(t *testing.T, cfg client.Config) *services.ServiceHandler {
+	c, err := newClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return services.NewServiceHandler(c)
 }
 
 func TestChainValidationMessageSuite(t *testing.T) {
@@ -60,13 +118,9 @@ func TestChainValidationMessageSuite(t *testing.T) {
 		Port:    port,
 		Timeout: timeout,
 	}
-	handler := services.NewServiceHandler(client.NewRpcClient(cfg))
+	handler := newHandler(t, cfg)
 
-	for _, testCase := range suites.MessageTestCases() {
-		t.Run(caseName(testCase), func(t *testing.T) {
-			testCase(t, handler)
-		})
-	}
+	suites.RunSuite(t, handler, suites.Filter{Categories: []suites.Category{suites.CategoryMessage}})
 }
 
 func TestChainValidationTipSetSuite(t *testing.T) {
@@ -75,16 +129,31 @@ func TestChainValidationTipSetSuite(t *testing.T) {
 		Port:    port,
 		Timeout: timeout,
 	}
-	handler := services.NewServiceHandler(client.NewRpcClient(cfg))
-	for _, testCase := range suites.TipSetTestCases() {
-		t.Run(caseName(testCase), func(t *testing.T) {
-			testCase(t, handler)
-		})
+	handler := newHandler(t, cfg)
+	suites.RunSuite(t, handler, suites.Filter{Categories: []suites.Category{suites.CategoryTipSet}})
+}
+
+// TestChainValidationSenderMatrix runs the message suites again under each sender variant, see
+// suites.RunSenderMatrix. It is skipped in short mode, as it runs them three more times.
+func TestChainValidationSenderMatrix(t *testing.T) {
+	if testing.Short() {
+		t.Skip("sender matrix skipped in short mode")
 	}
+	cfg := client.Config{
+		Host:    host,
+		Port:    port,
+		Timeout: timeout,
+	}
+	handler := newHandler(t, cfg)
+	suites.RunSenderMatrix(t, handler, suites.Filter{Categories: []suites.Category{suites.CategoryMessage}})
 }
 
-func caseName(testCase suites.TestCase) string {
-	fqName := runtime.FuncForPC(reflect.ValueOf(testCase).Pointer()).Name()
-	toks := strings.Split(fqName, ".")
-	return toks[len(toks)-1]
+func TestChainValidationFactoryConformance(t *testing.T) {
+	cfg := client.Config{
+		Host:    host,
+		Port:    port,
+		Timeout: timeout,
+	}
+	handler := newHandler(t, cfg)
+	suites.FactoryConformance(t, handler)
 }
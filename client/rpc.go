@@ -13,6 +13,14 @@ import (
 
 var log = logging.Logger("client/rpc")
 
+// Client calls the methods of the services an implementation serves to run the suites against it.
+type Client interface {
+	// Do calls `method` with `args`, returning the raw result.
+	Do(method string, args interface{}) (json.RawMessage, error)
+}
+
+var _ Client = (*RpcClient)(nil)
+
 type Config struct {
 	Host string
 	Port string
@@ -20,15 +20,40 @@ type ConfigReply struct {
 	CheckReturnValue bool `json:"checkReturnValue"`
 	CheckStateRoot   bool `json:"checkStateRoot"`
 
+	CheckReturnValueEncoding bool `json:"checkReturnValueEncoding"`
+	CheckExitCodeClassOnly   bool `json:"checkExitCodeClassOnly"`
+
+	KnownFailures map[string]string `json:"knownFailures"`
+
+	// Implementation names the implementation, e.g. "lotus", so that states it constructs are cached apart from those
+	// of others.
+	Implementation string `json:"implementation,omitempty"`
+
+	// Capabilities describes the optional features the implementation supports. If it is absent, only tipset
+	// application, which implementations served before capabilities were, is taken to be supported.
+	Capabilities *CapabilitiesReply `json:"capabilities"`
+
 	TestSuite []string `json:"testSuite"`
 }
 
-func NewConfigService(rpcClient *client.RpcClient) *ConfigService {
+type CapabilitiesReply struct {
+	TipSetApplication     bool   `json:"tipSetApplication"`
+	ExecTraces            bool   `json:"execTraces"`
+	GasBreakdown          bool   `json:"gasBreakdown"`
+	StateMigration        bool   `json:"stateMigration"`
+	ReceiptsRoot          bool   `json:"receiptsRoot"`
+	BlockValidation       bool   `json:"blockValidation"`
+	MessageValidation     bool   `json:"messageValidation"`
+	SignatureVerification bool   `json:"signatureVerification"`
+	NetworkVersions       []uint `json:"networkVersions"`
+}
+
+func NewConfigService(rpcClient client.Client) *ConfigService {
 	return &ConfigService{rpcClient: rpcClient}
 }
 
 type ConfigService struct {
-	rpcClient *client.RpcClient
+	rpcClient client.Client
 }
 
 func (cs *ConfigService) GetConfig() (*ConfigReply, error) {
@@ -2,6 +2,8 @@ package services
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
 
 	"github.com/filecoin-project/go-address"
@@ -23,10 +25,13 @@ import (
 var log = logging.Logger("service/handler")
 
 var _ state.VMWrapper = (*ServiceHandler)(nil)
+var _ state.MigratingVMWrapper = (*ServiceHandler)(nil)
 var _ state.Applier = (*ServiceHandler)(nil)
+var _ state.MessageValidatingApplier = (*ServiceHandler)(nil)
 var _ state.Factories = (*ServiceHandler)(nil)
+var _ state.NamedFactories = (*ServiceHandler)(nil)
 
-func NewServiceHandler(client *client.RpcClient) *ServiceHandler {
+func NewServiceHandler(client client.Client) *ServiceHandler {
 	return &ServiceHandler{
 		vm:     vmwrapper.NewVmWrapperService(client),
 		config: config.NewConfigService(client),
@@ -69,6 +74,18 @@ func (s *ServiceHandler) NewValidationConfig() state.ValidationConfig {
 	return &configWrapper{cfg: cfg}
 }
 
+// ImplementationName returns the implementation named by the service's config, or "rpc" if it names none.
+func (s *ServiceHandler) ImplementationName() string {
+	cfg, err := s.config.GetConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.Implementation == "" {
+		return "rpc"
+	}
+	return cfg.Implementation
+}
+
 type configWrapper struct {
 	cfg *config.ConfigReply
 }
@@ -89,6 +106,37 @@ func (c configWrapper) ValidateStateRoot() bool {
 	return c.cfg.CheckStateRoot
 }
 
+func (c configWrapper) ValidateReturnValueEncoding() bool {
+	return c.cfg.CheckReturnValueEncoding
+}
+
+func (c configWrapper) ValidateExitCodeClassOnly() bool {
+	return c.cfg.CheckExitCodeClassOnly
+}
+
+func (c configWrapper) KnownFailures() map[string]string {
+	return c.cfg.KnownFailures
+}
+
+func (c configWrapper) Capabilities() state.Capabilities {
+	caps := c.cfg.Capabilities
+	if caps == nil {
+		// Implementations predating capabilities support none of the optional features, but served tipsets.
+		return state.Capabilities{TipSetApplication: true}
+	}
+	return state.Capabilities{
+		TipSetApplication:     caps.TipSetApplication,
+		ExecTraces:            caps.ExecTraces,
+		GasBreakdown:          caps.GasBreakdown,
+		StateMigration:        caps.StateMigration,
+		ReceiptsRoot:          caps.ReceiptsRoot,
+		BlockValidation:       caps.BlockValidation,
+		MessageValidation:     caps.MessageValidation,
+		SignatureVerification: caps.SignatureVerification,
+		NetworkVersions:       caps.NetworkVersions,
+	}
+}
+
 //
 // Impl VMWrapper interface
 //
@@ -139,12 +187,24 @@ func (s *ServiceHandler) CreateActor(code cid.Cid, addr address.Address, balance
 	return &actorWrapper{reply.Actor}, reply.Addr, nil
 }
 
+func (s *ServiceHandler) ImportStateTree(root cid.Cid, car io.Reader) error {
+	raw, err := ioutil.ReadAll(car)
+	if err != nil {
+		return err
+	}
+	return s.vm.ImportStateTree(root, raw)
+}
+
+func (s *ServiceHandler) Migrate(to uint) (cid.Cid, error) {
+	return s.vm.Migrate(to)
+}
+
 //
 // Impl Applier interface
 //
 
-func (s *ServiceHandler) ApplyMessage(epoch abi.ChainEpoch, msg *types.Message) (types.ApplyMessageResult, error) {
-	reply, err := s.vm.ApplyMessage(epoch, msg)
+func (s *ServiceHandler) ApplyMessage(exeCtx types.ExecutionContext, msg *types.Message) (types.ApplyMessageResult, error) {
+	reply, err := s.vm.ApplyMessage(exeCtx.Epoch, exeCtx.BaseFee, exeCtx.CircSupply, msg)
 	if err != nil {
 		return types.ApplyMessageResult{}, err
 	}
@@ -154,12 +214,16 @@ func (s *ServiceHandler) ApplyMessage(epoch abi.ChainEpoch, msg *types.Message)
 		Penalty: reply.Penalty,
 		Reward:  reply.Reward,
 		Root:    reply.Root.String(),
+
+		ImplMetrics:  reply.ImplMetrics,
+		Events:       reply.Events,
+		GasBreakdown: reply.GasBreakdown,
 	}, nil
 
 }
 
-func (s *ServiceHandler) ApplySignedMessage(epoch abi.ChainEpoch, msg *types.SignedMessage) (types.ApplyMessageResult, error) {
-	reply, err := s.vm.ApplySignedMessage(epoch, msg)
+func (s *ServiceHandler) ApplySignedMessage(exeCtx types.ExecutionContext, msg *types.SignedMessage) (types.ApplyMessageResult, error) {
+	reply, err := s.vm.ApplySignedMessage(exeCtx.Epoch, exeCtx.BaseFee, exeCtx.CircSupply, msg)
 	if err != nil {
 		return types.ApplyMessageResult{}, err
 	}
@@ -169,19 +233,56 @@ func (s *ServiceHandler) ApplySignedMessage(epoch abi.ChainEpoch, msg *types.Sig
 		Penalty: reply.Penalty,
 		Reward:  reply.Reward,
 		Root:    reply.Root.String(),
+
+		ImplMetrics:  reply.ImplMetrics,
+		Events:       reply.Events,
+		GasBreakdown: reply.GasBreakdown,
+	}, nil
+}
+
+func (s *ServiceHandler) CallMessage(exeCtx types.ExecutionContext, msg *types.Message) (types.ApplyMessageResult, error) {
+	reply, err := s.vm.CallMessage(exeCtx.Epoch, exeCtx.BaseFee, exeCtx.CircSupply, msg)
+	if err != nil {
+		return types.ApplyMessageResult{}, err
+	}
+	return types.ApplyMessageResult{
+		Msg:     *msg,
+		Receipt: reply.Receipt,
+		Penalty: reply.Penalty,
+		Reward:  reply.Reward,
+		Root:    reply.Root.String(),
+
+		ImplMetrics:  reply.ImplMetrics,
+		Events:       reply.Events,
+		GasBreakdown: reply.GasBreakdown,
 	}, nil
 }
 
 // TODO the RandomnessSource is going to be tricky to do over RPC
-func (s *ServiceHandler) ApplyTipSetMessages(epoch abi.ChainEpoch, blocks []types.BlockMessagesInfo, rnd state.RandomnessSource) (types.ApplyTipSetResult, error) {
-	reply, err := s.vm.ApplyTipSetMessages(epoch, blocks, nil)
+func (s *ServiceHandler) ApplyTipSetMessages(exeCtx types.ExecutionContext, blocks []types.BlockMessagesInfo, rnd state.RandomnessSource) (types.ApplyTipSetResult, error) {
+	reply, err := s.vm.ApplyTipSetMessages(exeCtx.Epoch, exeCtx.BaseFee, exeCtx.CircSupply, blocks, nil)
 	if err != nil {
 		return types.ApplyTipSetResult{}, err
 	}
-	return types.ApplyTipSetResult{
+	result := types.ApplyTipSetResult{
 		Receipts: reply.Receipts,
 		Root:     reply.Root.String(),
-	}, nil
+	}
+	if reply.ReceiptsRoot.Defined() {
+		result.ReceiptsRoot = reply.ReceiptsRoot.String()
+	}
+	return result, nil
+}
+
+func (s *ServiceHandler) ValidateMessageForBlock(exeCtx types.ExecutionContext, prior []*types.SignedMessage, msg *types.SignedMessage) error {
+	reply, err := s.vm.ValidateMessageForBlock(exeCtx.Epoch, exeCtx.BaseFee, exeCtx.CircSupply, prior, msg)
+	if err != nil {
+		return err
+	}
+	if !reply.Valid {
+		return fmt.Errorf("message %s invalid for block: %s", msg.Message.Cid(), reply.Reason)
+	}
+	return nil
 }
 
 //
@@ -217,6 +318,30 @@ func (k *KeyManager) NewBLSAccountAddress() address.Address {
 	return blsKey.Address
 }
 
+func (k *KeyManager) NewSECP256k1AccountKeyFromSeed(seed []byte) address.Address {
+	return k.addSeededKey(acrypto.SigTypeSecp256k1, seed)
+}
+
+func (k *KeyManager) NewBLSAccountKeyFromSeed(seed []byte) address.Address {
+	return k.addSeededKey(acrypto.SigTypeBLS, seed)
+}
+
+func (k *KeyManager) addSeededKey(sigType acrypto.SigType, seed []byte) address.Address {
+	prv, err := wallet.PrivateKeyFromSeed(sigType, seed)
+	if err != nil {
+		panic(err)
+	}
+	key, err := wallet.NewKey(wallet.KeyInfo{
+		Type:       sigType,
+		PrivateKey: prv,
+	})
+	if err != nil {
+		panic(err)
+	}
+	k.keys[key.Address] = key
+	return key.Address
+}
+
 func (k *KeyManager) Sign(addr address.Address, data []byte) (acrypto.Signature, error) {
 	ki, ok := k.keys[addr]
 	if !ok {
@@ -29,18 +29,25 @@ const (
 	Method_SetActorState = "VmWrapperService.SetActorState"
 	Method_CreateActor   = "VmWrapperService.CreateActor"
 
+	Method_ImportStateTree = "VmWrapperService.ImportStateTree"
+	Method_Migrate         = "VmWrapperService.Migrate"
+
 	// message application methods
 	Method_ApplyMessage        = "VmWrapperService.ApplyMessage"
 	Method_ApplySignedMessage  = "VmWrapperService.ApplySignedMessage"
 	Method_ApplyTipSetMessages = "VmWrapperService.ApplyTipSetMessages"
+	Method_CallMessage         = "VmWrapperService.CallMessage"
+
+	// message inclusion validation methods
+	Method_ValidateMessageForBlock = "VmWrapperService.ValidateMessageForBlock"
 )
 
-func NewVmWrapperService(client *client.RpcClient) *VmWrapperService {
+func NewVmWrapperService(client client.Client) *VmWrapperService {
 	return &VmWrapperService{rpcClient: client}
 }
 
 type VmWrapperService struct {
-	rpcClient *client.RpcClient
+	rpcClient client.Client
 }
 
 func (vs *VmWrapperService) NewVM() error {
@@ -202,22 +209,68 @@ func (vs *VmWrapperService) CreateActor(code cid.Cid, addr address.Address, bala
 	return &out, nil
 }
 
+type ImportStateTreeArgs struct {
+	Root cid.Cid
+	CAR  []byte
+}
+
+func (vs *VmWrapperService) ImportStateTree(root cid.Cid, car []byte) error {
+	resp, err := vs.rpcClient.Do(Method_ImportStateTree, &ImportStateTreeArgs{
+		Root: root,
+		CAR:  car,
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugw(Method_ImportStateTree, "response", resp)
+	return nil
+}
+
+type MigrateArgs struct {
+	To uint
+}
+
+func (vs *VmWrapperService) Migrate(to uint) (cid.Cid, error) {
+	resp, err := vs.rpcClient.Do(Method_Migrate, &MigrateArgs{To: to})
+	if err != nil {
+		return cid.Undef, err
+	}
+	log.Debugw(Method_Migrate, "response", resp)
+
+	var out RootReply
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return cid.Undef, err
+	}
+	return out.Root, nil
+}
+
 type ApplyMessageReply struct {
 	Receipt types.MessageReceipt
 	Penalty abi.TokenAmount
 	Reward  abi.TokenAmount
 	Root    cid.Cid
+	// Optional implementation specific counters.
+	ImplMetrics map[string]int64
+	// Optional events emitted by the message.
+	Events []types.Event
+	// Optional breakdown of the gas used by category of charge.
+	GasBreakdown map[string]types.GasUnits
 }
 
 type ApplyMessageArgs struct {
 	Epoch   abi.ChainEpoch
-	Message *types.Message
+	BaseFee abi.TokenAmount
+	// CircSupply is the circulating supply to apply the message with, nil for the implementation to compute it.
+	CircSupply *abi.TokenAmount `json:",omitempty"`
+	Message    *types.Message
 }
 
-func (vs *VmWrapperService) ApplyMessage(epoch abi.ChainEpoch, msg *types.Message) (*ApplyMessageReply, error) {
+func (vs *VmWrapperService) ApplyMessage(epoch abi.ChainEpoch, baseFee abi.TokenAmount, circSupply *abi.TokenAmount, msg *types.Message) (*ApplyMessageReply, error) {
 	resp, err := vs.rpcClient.Do(Method_ApplyMessage, &ApplyMessageArgs{
-		Epoch:   epoch,
-		Message: msg,
+		Epoch:      epoch,
+		BaseFee:    baseFee,
+		CircSupply: circSupply,
+		Message:    msg,
 	})
 	if err != nil {
 		return nil, err
@@ -231,14 +284,38 @@ func (vs *VmWrapperService) ApplyMessage(epoch abi.ChainEpoch, msg *types.Messag
 	return &out, err
 }
 
+// CallMessage executes a message without committing its effects. It takes the same arguments as ApplyMessage.
+func (vs *VmWrapperService) CallMessage(epoch abi.ChainEpoch, baseFee abi.TokenAmount, circSupply *abi.TokenAmount, msg *types.Message) (*ApplyMessageReply, error) {
+	resp, err := vs.rpcClient.Do(Method_CallMessage, &ApplyMessageArgs{
+		Epoch:      epoch,
+		BaseFee:    baseFee,
+		CircSupply: circSupply,
+		Message:    msg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debugw(Method_CallMessage, "response", resp)
+
+	var out ApplyMessageReply
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, err
+}
+
 type ApplySignedMessageArgs struct {
 	Epoch         abi.ChainEpoch
+	BaseFee       abi.TokenAmount
+	CircSupply    *abi.TokenAmount `json:",omitempty"`
 	SignedMessage *types.SignedMessage
 }
 
-func (vs *VmWrapperService) ApplySignedMessage(epoch abi.ChainEpoch, smsg *types.SignedMessage) (*ApplyMessageReply, error) {
+func (vs *VmWrapperService) ApplySignedMessage(epoch abi.ChainEpoch, baseFee abi.TokenAmount, circSupply *abi.TokenAmount, smsg *types.SignedMessage) (*ApplyMessageReply, error) {
 	resp, err := vs.rpcClient.Do(Method_ApplySignedMessage, &ApplySignedMessageArgs{
 		Epoch:         epoch,
+		BaseFee:       baseFee,
+		CircSupply:    circSupply,
 		SignedMessage: smsg,
 	})
 	if err != nil {
@@ -255,6 +332,8 @@ func (vs *VmWrapperService) ApplySignedMessage(epoch abi.ChainEpoch, smsg *types
 
 type ApplyTipSetMessagesArgs struct {
 	Epoch      abi.ChainEpoch
+	BaseFee    abi.TokenAmount
+	CircSupply *abi.TokenAmount `json:",omitempty"`
 	Blocks     []types.BlockMessagesInfo
 	Randomness abi.Randomness
 }
@@ -262,11 +341,15 @@ type ApplyTipSetMessagesArgs struct {
 type ApplyTipSetMessagesReply struct {
 	Receipts []types.MessageReceipt
 	Root     cid.Cid
+	// Optional root of the AMT of the receipts.
+	ReceiptsRoot cid.Cid
 }
 
-func (vs *VmWrapperService) ApplyTipSetMessages(epoch abi.ChainEpoch, blocks []types.BlockMessagesInfo, rand abi.Randomness) (*ApplyTipSetMessagesReply, error) {
+func (vs *VmWrapperService) ApplyTipSetMessages(epoch abi.ChainEpoch, baseFee abi.TokenAmount, circSupply *abi.TokenAmount, blocks []types.BlockMessagesInfo, rand abi.Randomness) (*ApplyTipSetMessagesReply, error) {
 	resp, err := vs.rpcClient.Do(Method_ApplyTipSetMessages, &ApplyTipSetMessagesArgs{
 		Epoch:      epoch,
+		BaseFee:    baseFee,
+		CircSupply: circSupply,
 		Randomness: rand,
 		Blocks:     blocks,
 	})
@@ -281,3 +364,37 @@ func (vs *VmWrapperService) ApplyTipSetMessages(epoch abi.ChainEpoch, blocks []t
 	}
 	return &out, err
 }
+
+type ValidateMessageForBlockArgs struct {
+	Epoch         abi.ChainEpoch
+	BaseFee       abi.TokenAmount
+	CircSupply    *abi.TokenAmount `json:",omitempty"`
+	Prior         []*types.SignedMessage
+	SignedMessage *types.SignedMessage
+}
+
+type ValidateMessageForBlockReply struct {
+	Valid bool
+	// Reason describes why an invalid message may not be included.
+	Reason string
+}
+
+func (vs *VmWrapperService) ValidateMessageForBlock(epoch abi.ChainEpoch, baseFee abi.TokenAmount, circSupply *abi.TokenAmount, prior []*types.SignedMessage, smsg *types.SignedMessage) (*ValidateMessageForBlockReply, error) {
+	resp, err := vs.rpcClient.Do(Method_ValidateMessageForBlock, &ValidateMessageForBlockArgs{
+		Epoch:         epoch,
+		BaseFee:       baseFee,
+		CircSupply:    circSupply,
+		Prior:         prior,
+		SignedMessage: smsg,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Debugw(Method_ValidateMessageForBlock, "response", resp)
+
+	var out ValidateMessageForBlockReply
+	if err := json.Unmarshal(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, err
+}
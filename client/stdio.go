@@ -0,0 +1,54 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	jsonrpc "github.com/gorilla/rpc/v2/json"
+)
+
+var _ Client = (*StdioClient)(nil)
+
+// StdioClient calls the services of an implementation over a pair of streams, typically the standard input and
+// output of its process, so that implementations without an HTTP server can be run. Requests and responses are the
+// JSON-RPC messages exchanged by RpcClient, one per line, and each request is answered before the next is written.
+type StdioClient struct {
+	lk  sync.Mutex
+	in  io.Writer
+	out *bufio.Reader
+}
+
+// NewStdioClient returns a client writing requests to `in` and reading responses from `out`.
+func NewStdioClient(in io.Writer, out io.Reader) *StdioClient {
+	return &StdioClient{in: in, out: bufio.NewReaderSize(out, 1<<20)}
+}
+
+func (c *StdioClient) Do(method string, args interface{}) (json.RawMessage, error) {
+	log.Debugw("Do", "method", method, "args", args)
+
+	encReq, err := jsonrpc.EncodeClientRequest(method, args)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	if _, err := c.in.Write(append(encReq, '\n')); err != nil {
+		return nil, fmt.Errorf("writing request for %s: %w", method, err)
+	}
+	line, err := c.out.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+		return nil, fmt.Errorf("reading response for %s: %w", method, err)
+	}
+
+	var out json.RawMessage
+	if err := jsonrpc.DecodeClientResponse(bytes.NewReader(line), &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
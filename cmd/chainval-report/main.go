@@ -0,0 +1,214 @@
+// Command chainval-report renders the JSON reports written by report.WriteFile for several implementations as an HTML
+// matrix of the status of each test in each implementation, for publishing conformance status.
+//
+// Usage:
+//
+//	chainval-report [-o matrix.html] [-title title] [name=]report.json...
+//
+// Each implementation is named by the prefix of its report argument, or by the report's file name.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/chain-validation/report"
+)
+
+// notRun is the status of a test missing from an implementation's report, e.g. a suite skipped before building a
+// driver or added since the report was written.
+const notRun = report.Status("not-run")
+
+// statusRank orders the statuses a test recorded by several drivers may have, the test taking the highest.
+var statusRank = map[report.Status]int{
+	notRun:              0,
+	report.Skipped:      1,
+	report.Passed:       2,
+	report.KnownFailure: 3,
+	report.Failed:       4,
+}
+
+type implementation struct {
+	Name   string
+	status map[string]report.Status
+	Counts map[report.Status]int
+}
+
+type row struct {
+	Suite    string
+	Test     string
+	Statuses []report.Status
+}
+
+type matrix struct {
+	Title           string
+	Implementations []*implementation
+	Columns         int
+	Rows            []row
+	Statuses        []report.Status
+}
+
+func main() {
+	out := flag.String("o", "", "file to write the HTML matrix to, standard output if unset")
+	title := flag.String("title", "chain-validation conformance", "title of the page")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: chainval-report [-o matrix.html] [-title title] [name=]report.json...\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	m := matrix{
+		Title:    *title,
+		Statuses: []report.Status{report.Passed, report.Failed, report.KnownFailure, report.Skipped, notRun},
+	}
+	for _, arg := range flag.Args() {
+		impl, err := loadImplementation(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to load report %s: %v\n", arg, err)
+			os.Exit(1)
+		}
+		m.Implementations = append(m.Implementations, impl)
+	}
+	m.Columns = len(m.Implementations) + 1
+	m.Rows = rows(m.Implementations)
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create %s: %v\n", *out, err)
+			os.Exit(1)
+		}
+		defer func() { _ = f.Close() }()
+		w = f
+	}
+	if err := page.Execute(w, m); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render matrix: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// loadImplementation reads the report named by `arg`, "name=path" or a path, and derives the status of each of its
+// tests, keyed by test ID. Reports written before test outcomes were recorded only list messages and failures: their
+// tests with failures are taken to have failed, and the others to have passed.
+func loadImplementation(arg string) (*implementation, error) {
+	name, path := "", arg
+	if i := strings.Index(arg, "="); i >= 0 {
+		name, path = arg[:i], arg[i+1:]
+	}
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r report.Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+
+	impl := &implementation{Name: name, status: make(map[string]report.Status), Counts: make(map[report.Status]int)}
+	set := func(test string, s report.Status) {
+		id := testID(test)
+		if cur, ok := impl.status[id]; !ok || statusRank[s] > statusRank[cur] {
+			impl.status[id] = s
+		}
+	}
+	for _, t := range r.Tests {
+		set(t.Test, t.Status)
+	}
+	if len(r.Tests) == 0 {
+		for _, m := range r.Messages {
+			set(m.Test, report.Passed)
+		}
+		for _, f := range r.Failures {
+			set(f.Test, report.Failed)
+		}
+	}
+	return impl, nil
+}
+
+// rows returns a row per test of any implementation, sorted by test ID, counting each implementation's statuses.
+func rows(impls []*implementation) []row {
+	ids := make(map[string]bool)
+	for _, impl := range impls {
+		for id := range impl.status {
+			ids[id] = true
+		}
+	}
+	sorted := make([]string, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Strings(sorted)
+
+	out := make([]row, 0, len(sorted))
+	for _, id := range sorted {
+		r := row{Suite: strings.SplitN(id, "/", 2)[0], Test: id}
+		for _, impl := range impls {
+			s, ok := impl.status[id]
+			if !ok {
+				s = notRun
+			}
+			impl.Counts[s]++
+			r.Statuses = append(r.Statuses, s)
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// testID returns the name of a test below the implementation's top-level test, which differs between
+// implementations, e.g. "MessageTest_Paych/happy path". See drivers.TestID.
+func testID(name string) string {
+	tokens := strings.SplitN(name, "/", 2)
+	return tokens[len(tokens)-1]
+}
+
+var page = template.Must(template.New("matrix").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: left; }
+td.status { text-align: center; }
+.pass { background: #c8e6c9; }
+.fail { background: #ffcdd2; }
+.known-failure { background: #ffe0b2; }
+.skip { background: #eeeeee; }
+.not-run { background: #ffffff; color: #999; }
+tr.suite th { background: #f5f5f5; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<thead>
+<tr><th>Test</th>{{range .Implementations}}<th>{{.Name}}</th>{{end}}</tr>
+{{range .Statuses}}{{$s := .}}<tr><th class="{{$s}}">{{$s}}</th>{{range $.Implementations}}<td class="status">{{index .Counts $s}}</td>{{end}}</tr>
+{{end}}</thead>
+<tbody>
+{{$suite := ""}}{{range .Rows}}{{if ne .Suite $suite}}{{$suite = .Suite}}<tr class="suite"><th colspan="{{$.Columns}}">{{.Suite}}</th></tr>
+{{end}}<tr><td>{{.Test}}</td>{{range .Statuses}}<td class="status {{.}}">{{.}}</td>{{end}}</tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,117 @@
+// Command chainval-run runs the suites against an implementation served by another process over its standard input
+// and output, so that implementations not written in Go can be validated without an HTTP server or a cgo bridge.
+//
+// Usage:
+//
+//	chainval-run [-include pattern,...] [-exclude pattern,...] [-category message|tipset] [-sender-matrix] [-test.v] -- binary [args...]
+//
+// The binary is started with its arguments and must serve the methods of client/services, as a process served over
+// HTTP would, reading one JSON-RPC request per line on its standard input and answering each with one JSON-RPC
+// response per line on its standard output. Its standard error is passed through, and it should exit once its
+// standard input is closed. Results are checked against the expectations of the resource box, as in the test runner of
+// client/process, and the environment variables of the tracker and report packages apply as they do there.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/chain-validation/client"
+	"github.com/filecoin-project/chain-validation/client/services"
+	"github.com/filecoin-project/chain-validation/report"
+	"github.com/filecoin-project/chain-validation/suites"
+	"github.com/filecoin-project/chain-validation/tracker"
+)
+
+func main() {
+	// Registers the testing flags, e.g. -test.v and -test.run, along with ours.
+	testing.Init()
+	include := flag.String("include", "", "comma-separated names of suites to run, or patterns matching them")
+	exclude := flag.String("exclude", "", "comma-separated names of suites, or patterns matching them, not to run")
+	category := flag.String("category", "", "category of suites to run, message or tipset, all if unset")
+	conformance := flag.Bool("conformance", true, "check the implementation's factories before running the suites")
+	senderMatrix := flag.Bool("sender-matrix", false, "also run the message suites under each sender variant")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "usage: chainval-run [flags] -- binary [args...]\n")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	filter := suites.Filter{Include: splitList(*include), Exclude: splitList(*exclude)}
+	if *category != "" {
+		filter.Categories = []suites.Category{suites.Category(*category)}
+	}
+
+	cmd := exec.Command(flag.Arg(0), flag.Args()[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open standard input of %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to open standard output of %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to start %s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+	handler := services.NewServiceHandler(client.NewStdioClient(stdin, stdout))
+
+	// The suites are run directly below top-level tests, as tests and their expectations are named by the path below
+	// the top-level test.
+	var tests []testing.InternalTest
+	if *conformance {
+		tests = append(tests, testing.InternalTest{
+			Name: "TestChainValidationFactoryConformance",
+			F:    func(t *testing.T) { suites.FactoryConformance(t, handler) },
+		})
+	}
+	tests = append(tests, testing.InternalTest{
+		Name: "TestChainValidationSuite",
+		F:    func(t *testing.T) { suites.RunSuite(t, handler, filter) },
+	})
+	if *senderMatrix {
+		tests = append(tests, testing.InternalTest{
+			Name: "TestChainValidationSenderMatrix",
+			F:    func(t *testing.T) { suites.RunSenderMatrix(t, handler, filter) },
+		})
+	}
+
+	code := 0
+	if !testing.RunTests(regexp.MatchString, tests) {
+		code = 1
+	}
+
+	if err := tracker.WriteSoftFailureReport(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write soft failure report: %v\n", err)
+		code = 1
+	}
+	if err := report.WriteFile(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write report: %v\n", err)
+		code = 1
+	}
+
+	_ = stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s exited: %v\n", flag.Arg(0), err)
+		code = 1
+	}
+	os.Exit(code)
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/filecoin-project/chain-validation/box"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/tracker"
+)
+
+// A vector is the file recorded by the state tracker for a single test: one result per line.
+type vector struct {
+	name string
+	path string
+	hash string
+
+	msgResults []types.ApplyMessageResult
+	tsResults  []types.ApplyTipSetResult
+}
+
+// outcome is the part of a result which is checked against expectations.
+type outcome struct {
+	receipts []types.MessageReceipt
+	root     string
+}
+
+func runCorpus(args []string) int {
+	fs := flag.NewFlagSet("corpus", flag.ExitOnError)
+	dataDir := fs.String("data", os.Getenv(tracker.ValidationDataEnvVar), "directory of vectors recorded by the state tracker")
+	remove := fs.Bool("remove-duplicates", false, "delete all but the first vector of each duplicate set")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: chainval corpus [flags] [-- binary [args...]]\n\n"+
+			"Given the binary serving the reference implementation, as for chainval-run, the suites are re-run against\n"+
+			"it and vectors it doesn't reproduce are reported.\n\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	if *dataDir == "" {
+		fmt.Fprintf(os.Stderr, "no vector directory, set -data or %s\n", tracker.ValidationDataEnvVar)
+		return 2
+	}
+
+	vectors, err := loadVectors(*dataDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load vectors: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%d vectors in %s\n\n", len(vectors), *dataDir)
+
+	if err := reportDuplicates(vectors, *remove); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to remove duplicates: %v\n", err)
+		return 1
+	}
+	reportComposition(vectors)
+	code := 0
+	if fs.NArg() > 0 {
+		rerun, err := rerunVectors(fs.Args())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to re-run suites against the reference implementation: %v\n", err)
+			return 1
+		}
+		if reportReproduction(vectors, rerun) > 0 {
+			code = 1
+		}
+	}
+	if reportBoxMismatches(vectors) > 0 {
+		code = 1
+	}
+	return code
+}
+
+// loadVectors reads every vector in dir, sorted by name. File names follow the convention enforced by box/gen.go.
+func loadVectors(dir string) ([]*vector, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []*vector
+	for _, info := range infos {
+		// Checkpoints and commitments only accompany a vector, and are migrated along with it.
+		if info.IsDir() || strings.HasSuffix(info.Name(), tracker.CheckpointsFileSuffix) || strings.HasSuffix(info.Name(), tracker.CommitmentsFileSuffix) {
+			continue
+		}
+		v, err := loadVector(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", info.Name(), err)
+		}
+		vectors = append(vectors, v)
+	}
+	sort.Slice(vectors, func(i, j int) bool { return vectors[i].name < vectors[j].name })
+	return vectors, nil
+}
+
+func loadVector(path string) (*vector, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	v := &vector{name: filepath.Base(path), path: path}
+	// The hash is taken over re-encoded results so that formatting differences between recordings are ignored.
+	h := sha256.New()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		var res interface{}
+		switch {
+		case strings.HasPrefix(v.name, "TipSetTest"):
+			var r types.ApplyTipSetResult
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				return nil, err
+			}
+			v.tsResults = append(v.tsResults, r)
+			res = r
+		case strings.HasPrefix(v.name, "MessageTest"):
+			var r types.ApplyMessageResult
+			if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+				return nil, err
+			}
+			v.msgResults = append(v.msgResults, r)
+			res = r
+		default:
+			return nil, fmt.Errorf("vector name must start with MessageTest or TipSetTest")
+		}
+		canonical, err := json.Marshal(res)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = h.Write(canonical)
+		_, _ = h.Write([]byte{'\n'})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	v.hash = hex.EncodeToString(h.Sum(nil))
+	return v, nil
+}
+
+func (v *vector) outcomes() []outcome {
+	var out []outcome
+	for _, r := range v.msgResults {
+		out = append(out, outcome{receipts: []types.MessageReceipt{r.Receipt}, root: r.Root})
+	}
+	for _, r := range v.tsResults {
+		out = append(out, outcome{receipts: r.Receipts, root: r.Root})
+	}
+	return out
+}
+
+// reportDuplicates prints each set of vectors sharing a canonical hash, optionally removing all but the first.
+func reportDuplicates(vectors []*vector, remove bool) error {
+	byHash := make(map[string][]*vector)
+	var hashes []string
+	for _, v := range vectors {
+		if _, ok := byHash[v.hash]; !ok {
+			hashes = append(hashes, v.hash)
+		}
+		byHash[v.hash] = append(byHash[v.hash], v)
+	}
+
+	fmt.Println("duplicates:")
+	sets := 0
+	for _, hash := range hashes {
+		dups := byHash[hash]
+		if len(dups) < 2 {
+			continue
+		}
+		sets++
+		fmt.Printf("  %s\n", hash[:16])
+		for i, v := range dups {
+			action := "keep"
+			if i > 0 && remove {
+				if err := os.Remove(v.path); err != nil {
+					return err
+				}
+				action = "removed"
+			}
+			fmt.Printf("    %-8s %s\n", action, v.name)
+		}
+	}
+	if sets == 0 {
+		fmt.Println("  none")
+	}
+	fmt.Println()
+	return nil
+}
+
+// reportComposition prints how many results target each type of actor and method, and how many receipts carry each
+// exit code. Results are keyed by the code of their receiver, as recorded by the driver, so that results of the same
+// method of actors at different addresses are counted together; results recorded without it are counted as unknown.
+// Tipset results don't record their messages so only contribute exit codes.
+func reportComposition(vectors []*vector) {
+	byMethod := make(map[string]int)
+	byExitCode := make(map[string]int)
+	var numMsg, numTs int
+	for _, v := range vectors {
+		if len(v.tsResults) > 0 {
+			numTs++
+		} else {
+			numMsg++
+		}
+		for _, r := range v.msgResults {
+			code := "unknown"
+			if r.ReceiverCode != "" {
+				code = r.ReceiverCode
+			}
+			byMethod[fmt.Sprintf("%s\t%d", code, r.Msg.Method)]++
+		}
+		for _, o := range v.outcomes() {
+			for _, rct := range o.receipts {
+				byExitCode[rct.ExitCode.String()]++
+			}
+		}
+	}
+
+	fmt.Printf("composition: %d message vectors, %d tipset vectors\n", numMsg, numTs)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  ACTOR\tMETHOD\tRESULTS")
+	for _, k := range sortedKeys(byMethod) {
+		fmt.Fprintf(w, "  %s\t%d\n", k, byMethod[k])
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "  EXIT CODE\tRECEIPTS")
+	for _, k := range sortedKeys(byExitCode) {
+		fmt.Fprintf(w, "  %s\t%d\n", k, byExitCode[k])
+	}
+	_ = w.Flush()
+	fmt.Println()
+}
+
+// reportBoxMismatches compares each recorded vector with the expectation baked into the box, returning the number of
+// vectors whose expectation is missing or differs. The vectors are compared as recorded, so a mismatch only says that
+// the box and the recordings disagree, not which of them is right; re-running against the reference, see
+// reportReproduction, tells.
+func reportBoxMismatches(vectors []*vector) int {
+	fmt.Println("vectors differing from box expectations:")
+	mismatched := 0
+	for _, v := range vectors {
+		data, found := box.Get("/" + v.name)
+		if !found {
+			mismatched++
+			fmt.Printf("  %s: no expectation\n", v.name)
+			continue
+		}
+		if reason := compareOutcomes(expectedOutcomes(data), v.outcomes()); reason != "" {
+			mismatched++
+			fmt.Printf("  %s: %s\n", v.name, reason)
+		}
+	}
+	if mismatched == 0 {
+		fmt.Println("  none")
+	}
+	return mismatched
+}
+
+func expectedOutcomes(data interface{}) []outcome {
+	v := &vector{}
+	switch d := data.(type) {
+	case types.ApplyMessageResult:
+		v.msgResults = []types.ApplyMessageResult{d}
+	case []types.ApplyMessageResult:
+		v.msgResults = d
+	case types.ApplyTipSetResult:
+		v.tsResults = []types.ApplyTipSetResult{d}
+	case []types.ApplyTipSetResult:
+		v.tsResults = d
+	}
+	return v.outcomes()
+}
+
+// compareOutcomes returns a description of the first difference between expected and actual, or "" if none.
+func compareOutcomes(expected, actual []outcome) string {
+	if len(expected) != len(actual) {
+		return fmt.Sprintf("expected %d results, recorded %d", len(expected), len(actual))
+	}
+	for i := range expected {
+		exp, act := expected[i], actual[i]
+		if len(exp.receipts) != len(act.receipts) {
+			return fmt.Sprintf("result %d: expected %d receipts, recorded %d", i, len(exp.receipts), len(act.receipts))
+		}
+		for j := range exp.receipts {
+			e, a := exp.receipts[j], act.receipts[j]
+			switch {
+			case e.ExitCode != a.ExitCode:
+				return fmt.Sprintf("result %d receipt %d: expected exit code %s, recorded %s", i, j, e.ExitCode, a.ExitCode)
+			case e.GasUsed != a.GasUsed:
+				return fmt.Sprintf("result %d receipt %d: expected gas %d, recorded %d", i, j, e.GasUsed, a.GasUsed)
+			case !bytes.Equal(e.ReturnValue, a.ReturnValue):
+				return fmt.Sprintf("result %d receipt %d: return value differs", i, j)
+			}
+		}
+		if exp.root != act.root {
+			return fmt.Sprintf("result %d: expected root %s, recorded %s", i, exp.root, act.root)
+		}
+	}
+	return ""
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
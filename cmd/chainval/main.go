@@ -0,0 +1,40 @@
+// Command chainval provides maintenance tooling for chain-validation test data.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands = []command{
+	{name: "corpus", usage: "deduplicate recorded vectors, report corpus composition and vectors differing from the box or the reference", run: runCorpus},
+	{name: "migrate", usage: "rewrite expectations from vectors recorded at a new actors version", run: runMigrate},
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: chainval <command> [flags]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			os.Exit(c.run(os.Args[2:]))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command: %s\n", os.Args[1])
+	usage()
+	os.Exit(2)
+}
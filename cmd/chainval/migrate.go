@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/filecoin-project/chain-validation/tracker"
+)
+
+type changeKind int
+
+const (
+	unchanged changeKind = iota
+	// Only gas used and state roots differ, as expected when gas pricing changes.
+	gasChange
+	// Exit codes, return values or the number of results differ.
+	behaviorChange
+)
+
+func runMigrate(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	resourcesDir := fs.String("resources", filepath.Join("box", "resources"), "directory of expectations to migrate")
+	recordedDir := fs.String("recorded", os.Getenv(tracker.ValidationDataEnvVar), "directory of vectors freshly recorded against the reference implementation")
+	acceptBehavior := fs.Bool("accept-behavior-changes", false, "also rewrite expectations whose behavior changed")
+	_ = fs.Parse(args)
+
+	if *recordedDir == "" {
+		fmt.Fprintf(os.Stderr, "no recorded vector directory, set -recorded or %s\n", tracker.ValidationDataEnvVar)
+		return 2
+	}
+
+	recorded, err := loadVectors(*recordedDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load recorded vectors: %v\n", err)
+		return 1
+	}
+
+	var migrated, added, flagged int
+	for _, rec := range recorded {
+		// Vectors are identified by name, so an expectation is always rewritten in place.
+		dest := filepath.Join(*resourcesDir, rec.name)
+
+		var kind changeKind
+		var reason string
+		if _, err := os.Stat(dest); os.IsNotExist(err) {
+			added++
+			fmt.Printf("new       %s\n", rec.name)
+		} else {
+			old, err := loadVector(dest)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "failed to load expectation %s: %v\n", rec.name, err)
+				return 1
+			}
+			kind, reason = classifyChange(old.outcomes(), rec.outcomes())
+			switch kind {
+			case unchanged:
+				continue
+			case gasChange:
+				migrated++
+				fmt.Printf("gas       %s: %s\n", rec.name, reason)
+			case behaviorChange:
+				flagged++
+				fmt.Printf("BEHAVIOR  %s: %s\n", rec.name, reason)
+				if !*acceptBehavior {
+					continue
+				}
+			}
+		}
+
+		if err := copyFile(rec.path, dest); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write expectation %s: %v\n", rec.name, err)
+			return 1
+		}
+		for _, suffix := range []string{tracker.CheckpointsFileSuffix, tracker.CommitmentsFileSuffix} {
+			if err := copyAccompanying(rec.path, dest, suffix); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to write %s of %s: %v\n", strings.TrimPrefix(suffix, "."), rec.name, err)
+				return 1
+			}
+		}
+	}
+
+	fmt.Printf("\n%d vectors recorded: %d gas migrations, %d new, %d behavior changes", len(recorded), migrated, added, flagged)
+	if flagged > 0 && !*acceptBehavior {
+		fmt.Printf(" left for manual review")
+	}
+	fmt.Println()
+	fmt.Println("run `make resources` to regenerate the resource box")
+
+	if flagged > 0 && !*acceptBehavior {
+		return 1
+	}
+	return 0
+}
+
+// classifyChange compares the outcomes of an expectation with those recorded, describing the first difference.
+// Behavior changes take precedence over gas changes, and a state root changing before any gas did is a behavior change.
+func classifyChange(old, recorded []outcome) (changeKind, string) {
+	if len(old) != len(recorded) {
+		return behaviorChange, fmt.Sprintf("expected %d results, recorded %d", len(old), len(recorded))
+	}
+	kind, reason := unchanged, ""
+	for i := range old {
+		o, r := old[i], recorded[i]
+		if len(o.receipts) != len(r.receipts) {
+			return behaviorChange, fmt.Sprintf("result %d: expected %d receipts, recorded %d", i, len(o.receipts), len(r.receipts))
+		}
+		for j := range o.receipts {
+			e, a := o.receipts[j], r.receipts[j]
+			if e.ExitCode != a.ExitCode {
+				return behaviorChange, fmt.Sprintf("result %d receipt %d: exit code %s became %s", i, j, e.ExitCode, a.ExitCode)
+			}
+			if !bytes.Equal(e.ReturnValue, a.ReturnValue) {
+				return behaviorChange, fmt.Sprintf("result %d receipt %d: return value changed", i, j)
+			}
+			if kind == unchanged && e.GasUsed != a.GasUsed {
+				kind, reason = gasChange, fmt.Sprintf("result %d receipt %d: gas %d became %d", i, j, e.GasUsed, a.GasUsed)
+			}
+		}
+		// Gas charged from balances changes the state roots from then on, a root changing without it doesn't.
+		if kind == unchanged && o.root != r.root {
+			return behaviorChange, fmt.Sprintf("result %d: state root changed with no change in gas", i)
+		}
+	}
+	return kind, reason
+}
+
+func copyFile(src, dest string) error {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dest, data, 0644)
+}
+
+// copyAccompanying copies the file with suffix `suffix` recorded along with the vector at src, such as its
+// checkpoints, if any, replacing that of dest.
+func copyAccompanying(src, dest, suffix string) error {
+	src, dest = src+suffix, dest+suffix
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		if err := os.Remove(dest); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	return copyFile(src, dest)
+}
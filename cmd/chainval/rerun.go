@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"regexp"
+	"testing"
+
+	"github.com/filecoin-project/chain-validation/client"
+	"github.com/filecoin-project/chain-validation/client/services"
+	"github.com/filecoin-project/chain-validation/suites"
+	"github.com/filecoin-project/chain-validation/tracker"
+)
+
+// rerunVectors runs the suites against the reference implementation served by the binary `args` over its standard
+// input and output, as chainval-run does, recording their vectors in a temporary directory, and returns them. Failing
+// suites still record their vectors, which are compared like any other.
+func rerunVectors(args []string) ([]*vector, error) {
+	dir, err := ioutil.TempDir("", "chainval-corpus")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+	for k, v := range map[string]string{tracker.RecordEnvVar: "1", tracker.ValidationDataEnvVar: dir} {
+		if err := os.Setenv(k, v); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting %s: %w", args[0], err)
+	}
+	handler := services.NewServiceHandler(client.NewStdioClient(stdin, stdout))
+
+	// The suites must be run directly below a top-level test for their vectors to be named as those of the corpus.
+	// The testing flags are registered and parsed, as suites may consult them, e.g. through testing.Short.
+	testing.Init()
+	if err := flag.CommandLine.Parse(nil); err != nil {
+		return nil, err
+	}
+	testing.RunTests(regexp.MatchString, []testing.InternalTest{{
+		Name: "TestChainValidationSuite",
+		F:    func(t *testing.T) { suites.RunSuite(t, handler, suites.Filter{}) },
+	}})
+
+	_ = stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("%s exited: %w", args[0], err)
+	}
+	return loadVectors(dir)
+}
+
+// reportReproduction compares each vector of the corpus with that recorded by re-running its test against the reference
+// implementation, returning the number of vectors the reference didn't reproduce, either because it recorded
+// different results or none at all.
+func reportReproduction(vectors, rerun []*vector) int {
+	byName := make(map[string]*vector, len(rerun))
+	for _, v := range rerun {
+		byName[v.name] = v
+	}
+
+	fmt.Println("vectors not reproduced by the reference implementation:")
+	unreproduced := 0
+	for _, v := range vectors {
+		ref, found := byName[v.name]
+		if !found {
+			unreproduced++
+			fmt.Printf("  %s: not recorded\n", v.name)
+			continue
+		}
+		if reason := compareOutcomes(v.outcomes(), ref.outcomes()); reason != "" {
+			unreproduced++
+			fmt.Printf("  %s: %s\n", v.name, reason)
+		}
+	}
+	if unreproduced == 0 {
+		fmt.Println("  none")
+	}
+	fmt.Println()
+	return unreproduced
+}
@@ -0,0 +1,165 @@
+package drivers
+
+import (
+	"fmt"
+
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// ActorKind is the kind of a builtin actor, independent of the version of its code.
+type ActorKind string
+
+const (
+	SystemActor   = ActorKind("system")
+	InitActor     = ActorKind("init")
+	CronActor     = ActorKind("cron")
+	AccountActor  = ActorKind("account")
+	PowerActor    = ActorKind("storagepower")
+	MinerActor    = ActorKind("storageminer")
+	MarketActor   = ActorKind("storagemarket")
+	PaychActor    = ActorKind("paymentchannel")
+	MultisigActor = ActorKind("multisig")
+	RewardActor   = ActorKind("reward")
+	VerifregActor = ActorKind("verifiedregistry")
+)
+
+// ActorKinds lists every kind of builtin actor.
+var ActorKinds = []ActorKind{
+	SystemActor, InitActor, CronActor, AccountActor, PowerActor, MinerActor, MarketActor, PaychActor, MultisigActor,
+	RewardActor, VerifregActor,
+}
+
+// NetworkVersion is a version of the network's rules, which determines the version of the builtin actors.
+type NetworkVersion uint
+
+// AtNetworkVersion returns factories whose drivers are built for network version `nv`, as if by
+// TestDriverBuilder.WithNetworkVersion. RunSuite wraps the implementation's factories with it when its filter selects
+// a network version, so that suites get the code CIDs of that version from TestDriver.ActorCode.
+func AtNetworkVersion(factory state.Factories, nv NetworkVersion) state.Factories {
+	return &networkVersionFactories{Factories: factory, version: nv}
+}
+
+// NetworkVersionOf returns the network version drivers built from `factory` are built for, zero unless set by
+// AtNetworkVersion.
+func NetworkVersionOf(factory state.Factories) NetworkVersion {
+	for {
+		switch f := factory.(type) {
+		case *networkVersionFactories:
+			return f.version
+		case wrappedFactories:
+			factory = f.unwrap()
+		default:
+			return 0
+		}
+	}
+}
+
+type networkVersionFactories struct {
+	state.Factories
+	version NetworkVersion
+}
+
+var _ state.ParallelFactories = (*networkVersionFactories)(nil)
+
+func (f *networkVersionFactories) SupportsParallel() bool {
+	pf, ok := f.Factories.(state.ParallelFactories)
+	return ok && pf.SupportsParallel()
+}
+
+func (f *networkVersionFactories) unwrap() state.Factories {
+	return f.Factories
+}
+
+// wrappedFactories are factories wrapping the implementation's to configure the drivers built from them.
+type wrappedFactories interface {
+	unwrap() state.Factories
+}
+
+// implementationFactories returns the implementation's factories wrapped by `factory`, if any.
+func implementationFactories(factory state.Factories) state.Factories {
+	for {
+		f, ok := factory.(wrappedFactories)
+		if !ok {
+			return factory
+		}
+		factory = f.unwrap()
+	}
+}
+
+// ActorsVersion is a version of the code of the builtin actors.
+type ActorsVersion uint
+
+const (
+	ActorsV0 = ActorsVersion(0)
+	ActorsV2 = ActorsVersion(2)
+)
+
+// actorsV2Network is the first network version running actors v2.
+const actorsV2Network = NetworkVersion(4)
+
+// ActorsVersionOf returns the version of the builtin actors at network version `nv`.
+func ActorsVersionOf(nv NetworkVersion) ActorsVersion {
+	if nv >= actorsV2Network {
+		return ActorsV2
+	}
+	return ActorsV0
+}
+
+// ActorCode returns the code CID of actors of kind `kind` at actors version `v`. Builtin code CIDs are the identity
+// multihash of "fil/<version>/<kind>", except that actors v0 are at version 1.
+func ActorCode(kind ActorKind, v ActorsVersion) cid.Cid {
+	if v == ActorsV0 {
+		c, ok := actorsV0Codes[kind]
+		if !ok {
+			panic(fmt.Sprintf("unknown actor kind %q", kind))
+		}
+		return c
+	}
+	c, err := cid.NewPrefixV1(cid.Raw, multihash.IDENTITY).Sum([]byte(fmt.Sprintf("fil/%d/%s", v, kind)))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// KindOf returns the kind and actors version of the builtin actor code `code`, or false if it isn't builtin code.
+func KindOf(code cid.Cid) (ActorKind, ActorsVersion, bool) {
+	for _, v := range []ActorsVersion{ActorsV0, ActorsV2} {
+		for _, kind := range ActorKinds {
+			if ActorCode(kind, v).Equals(code) {
+				return kind, v, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+var actorsV0Codes = map[ActorKind]cid.Cid{
+	SystemActor:   builtin_spec.SystemActorCodeID,
+	InitActor:     builtin_spec.InitActorCodeID,
+	CronActor:     builtin_spec.CronActorCodeID,
+	AccountActor:  builtin_spec.AccountActorCodeID,
+	PowerActor:    builtin_spec.StoragePowerActorCodeID,
+	MinerActor:    builtin_spec.StorageMinerActorCodeID,
+	MarketActor:   builtin_spec.StorageMarketActorCodeID,
+	PaychActor:    builtin_spec.PaymentChannelActorCodeID,
+	MultisigActor: builtin_spec.MultisigActorCodeID,
+	RewardActor:   builtin_spec.RewardActorCodeID,
+	VerifregActor: builtin_spec.VerifiedRegistryActorCodeID,
+}
+
+// ActorCode returns the code CID of actors of kind `kind` at the driver's network version. Suites should use it
+// rather than the code CIDs of a particular version of specs-actors, so that they apply to every version. The
+// drivers' own actor states are those of actors v0.
+func (td *TestDriver) ActorCode(kind ActorKind) cid.Cid {
+	return ActorCode(kind, ActorsVersionOf(td.networkVersion))
+}
+
+// NetworkVersion returns the network version the driver was built for.
+func (td *TestDriver) NetworkVersion() NetworkVersion {
+	return td.networkVersion
+}
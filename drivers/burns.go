@@ -0,0 +1,186 @@
+package drivers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/report"
+)
+
+// BurnCategory is a pathway by which funds are burnt, i.e. sent to the burnt funds actor.
+type BurnCategory string
+
+const (
+	// BurnBaseFee is the base fee burnt for the gas used by a message, and for gas over-estimation.
+	BurnBaseFee = BurnCategory("base-fee")
+	// BurnPenalty is a penalty charged to a block miner, e.g. for including an invalid message.
+	BurnPenalty = BurnCategory("penalty")
+	// BurnSlash is a penalty charged to a storage miner, e.g. for a consensus fault or faulty sectors.
+	BurnSlash = BurnCategory("slash")
+	// BurnPreCommitForfeit is a pre-commit deposit forfeited for a sector that wasn't proven in time.
+	BurnPreCommitForfeit = BurnCategory("precommit-forfeit")
+)
+
+// burnAmbiguous attributes the discrepancy of a step expecting burns in none or several categories.
+const burnAmbiguous = BurnCategory("ambiguous")
+
+// BurnLedger tracks the funds a test expects to be burnt by each message or tipset it applies, and reconciles them
+// with the balance of the burnt funds actor when the test completes. Base fee burns of messages applied directly
+// are expected automatically; suites expect other burns with Expect after applying the step that burns them.
+//
+// Each step's expected burns are checked against the change in burnt funds across it, so that a discrepancy may be
+// attributed to a category, localizing which burn pathway an implementation got wrong.
+type BurnLedger struct {
+	td *TestDriver
+
+	last  abi_spec.TokenAmount
+	steps []*burnStep
+}
+
+type burnStep struct {
+	desc     string
+	expected map[BurnCategory]abi_spec.TokenAmount
+	actual   abi_spec.TokenAmount
+}
+
+func newBurnLedger(td *TestDriver) *BurnLedger {
+	balance := td.GetBalance(builtin_spec.BurntFundsActorAddr)
+	return &BurnLedger{td: td, last: balance}
+}
+
+// Burns returns the driver's burn ledger, failing the test unless the driver was built WithBurnReconciliation.
+func (td *TestDriver) Burns() *BurnLedger {
+	if td.burns == nil {
+		td.T.Fatal("burn reconciliation isn't enabled for this driver")
+	}
+	return td.burns
+}
+
+// Expect records that the most recently applied message or tipset is expected to burn `amount` in `category`.
+func (l *BurnLedger) Expect(category BurnCategory, amount abi_spec.TokenAmount) {
+	if len(l.steps) == 0 {
+		l.td.T.Fatalf("expected %s burn of %s before any message was applied", category, amount)
+	}
+	l.steps[len(l.steps)-1].expect(category, amount)
+}
+
+func (s *burnStep) expect(category BurnCategory, amount abi_spec.TokenAmount) {
+	if amount.IsZero() {
+		return
+	}
+	s.expected[category] = big_spec.Add(orZero(s.expected[category]), amount)
+}
+
+func (s *burnStep) totalExpected() abi_spec.TokenAmount {
+	total := big_spec.Zero()
+	for _, amount := range s.expected {
+		total = big_spec.Add(total, amount)
+	}
+	return total
+}
+
+// track records a step that changed the state, and the change in burnt funds across it.
+func (l *BurnLedger) track(desc string) *burnStep {
+	balance := l.td.GetBalance(builtin_spec.BurntFundsActorAddr)
+	step := &burnStep{
+		desc:     desc,
+		expected: make(map[BurnCategory]abi_spec.TokenAmount),
+		actual:   big_spec.Sub(balance, l.last),
+	}
+	l.last = balance
+	l.steps = append(l.steps, step)
+	return step
+}
+
+// trackMessage records the application of a message outside a tipset, expecting the base fee burnt for its gas.
+func (l *BurnLedger) trackMessage(result types.ApplyMessageResult) {
+	step := l.track(fmt.Sprintf("message %d from %s", result.Msg.CallSeqNum, result.Msg.From))
+	// Messages rejected before execution use no gas and burn nothing.
+	if result.Receipt.GasUsed > 0 {
+		step.expect(BurnBaseFee, NewFeeModel(l.td.ExeCtx.BaseFee, result.Msg, result.Receipt.GasUsed).Burn())
+	}
+}
+
+// trackTipSet records the application of a tipset. Its burns must be expected by the suite.
+func (l *BurnLedger) trackTipSet() {
+	l.track(fmt.Sprintf("tipset at epoch %d", l.td.ExeCtx.Epoch))
+}
+
+// BurnDiscrepancy is the difference between the funds burnt in a category and those expected.
+type BurnDiscrepancy struct {
+	Category BurnCategory
+	Expected abi_spec.TokenAmount
+	// Actual is the amount expected plus the discrepancies attributed to the category.
+	Actual abi_spec.TokenAmount
+	// Steps describes the steps whose burns differ from those expected.
+	Steps []string
+}
+
+// Reconcile returns the categories whose burns differ from those expected, sorted by category. The discrepancy of a
+// step is attributed to the category of the burns it expected, or to the category "ambiguous" if it expected burns
+// in none or several categories.
+func (l *BurnLedger) Reconcile() []BurnDiscrepancy {
+	byCategory := make(map[BurnCategory]*BurnDiscrepancy)
+	get := func(category BurnCategory) *BurnDiscrepancy {
+		d, ok := byCategory[category]
+		if !ok {
+			d = &BurnDiscrepancy{Category: category, Expected: big_spec.Zero(), Actual: big_spec.Zero()}
+			byCategory[category] = d
+		}
+		return d
+	}
+
+	for _, step := range l.steps {
+		for category, amount := range step.expected {
+			d := get(category)
+			d.Expected = big_spec.Add(d.Expected, amount)
+			d.Actual = big_spec.Add(d.Actual, amount)
+		}
+		diff := big_spec.Sub(step.actual, step.totalExpected())
+		if diff.IsZero() {
+			continue
+		}
+		category := burnAmbiguous
+		if len(step.expected) == 1 {
+			for c := range step.expected {
+				category = c
+			}
+		}
+		d := get(category)
+		d.Actual = big_spec.Add(d.Actual, diff)
+		d.Steps = append(d.Steps, fmt.Sprintf("%s: expected %s, burnt %s", step.desc, step.totalExpected(), step.actual))
+	}
+
+	var discrepancies []BurnDiscrepancy
+	for _, d := range byCategory {
+		if !d.Expected.Equals(d.Actual) {
+			discrepancies = append(discrepancies, *d)
+		}
+	}
+	sort.Slice(discrepancies, func(i, j int) bool { return discrepancies[i].Category < discrepancies[j].Category })
+	return discrepancies
+}
+
+// assertReconciled fails the test with a report of any discrepancy between expected and actual burns.
+func (l *BurnLedger) assertReconciled() {
+	discrepancies := l.Reconcile()
+
+	var sb strings.Builder
+	for _, d := range discrepancies {
+		fmt.Fprintf(&sb, "\n  %s: expected %s, burnt %s", d.Category, d.Expected, d.Actual)
+		for _, step := range d.Steps {
+			fmt.Fprintf(&sb, "\n    %s", step)
+		}
+	}
+	assert.Empty(l.td.T, discrepancies, "burnt funds don't reconcile with expected burns:%s", sb.String())
+	for _, d := range discrepancies {
+		l.td.reportFailure(false, report.BurntFunds, report.NoMessage, string(d.Category), d.Expected, d.Actual)
+	}
+}
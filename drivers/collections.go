@@ -0,0 +1,237 @@
+package drivers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	market_spec "github.com/filecoin-project/specs-actors/actors/builtin/market"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	paych_spec "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	power_spec "github.com/filecoin-project/specs-actors/actors/builtin/power"
+	verifreg_spec "github.com/filecoin-project/specs-actors/actors/builtin/verifreg"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+// CollectionKind is the kind of a collection in the state.
+type CollectionKind string
+
+const (
+	HAMT = CollectionKind("hamt")
+	AMT  = CollectionKind("amt")
+)
+
+// Collection is a HAMT or AMT in the state, such as the state tree or the sectors of a miner.
+type Collection struct {
+	// Name identifies the collection in reports, e.g. "f04 (fil/1/storagepower) Claims".
+	Name string
+	Kind CollectionKind
+	Root cid.Cid
+	// Inner is the kind of the collections whose roots are the values of a multimap, such as the sets of deals of
+	// the market's DealOpsByEpoch, or empty if the values are not collections.
+	Inner CollectionKind
+}
+
+// CollectionDivergence is a collection whose root differs from that of the same entries in a collection built with
+// the parameters of the specs, e.g. because the implementation built it with another HAMT bitwidth.
+type CollectionDivergence struct {
+	Collection
+	// Canonical is the root of the collection built with the parameters of the specs, undefined if the entries
+	// couldn't be read.
+	Canonical cid.Cid
+	Err       error
+}
+
+func (d CollectionDivergence) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s %s %s: %v", d.Kind, d.Name, d.Root, d.Err)
+	}
+	return fmt.Sprintf("%s %s is %s, expected %s", d.Kind, d.Name, d.Root, d.Canonical)
+}
+
+// StateCollections returns the collections of the current state: the state tree, and the HAMTs and AMTs of the states
+// of builtin actors, by actor address.
+func (td *TestDriver) StateCollections() []Collection {
+	collections := []Collection{{Name: "state tree", Kind: HAMT, Root: td.State().Root()}}
+	for k := range td.actorEntries(td.State().Root()) {
+		addr := td.actorEntryAddress(k)
+		actor, err := td.State().Actor(addr)
+		require.NoError(td.T, err)
+		named := func(kind CollectionKind, field string, root cid.Cid) Collection {
+			return Collection{Name: fmt.Sprintf("%s (%s) %s", addr, actorCodeName(actor.Code()), field), Kind: kind, Root: root}
+		}
+
+		switch actor.Code() {
+		case builtin_spec.InitActorCodeID:
+			var st init_spec.State
+			td.GetState(actor.Head(), &st)
+			collections = append(collections, named(HAMT, "AddressMap", st.AddressMap))
+		case builtin_spec.StoragePowerActorCodeID:
+			var st power_spec.State
+			td.GetState(actor.Head(), &st)
+			queue := named(HAMT, "CronEventQueue", st.CronEventQueue)
+			queue.Inner = AMT
+			collections = append(collections, named(HAMT, "Claims", st.Claims), queue)
+		case builtin_spec.StorageMarketActorCodeID:
+			var st market_spec.State
+			td.GetState(actor.Head(), &st)
+			dealOps := named(HAMT, "DealOpsByEpoch", st.DealOpsByEpoch)
+			dealOps.Inner = HAMT
+			collections = append(collections,
+				named(AMT, "Proposals", st.Proposals),
+				named(AMT, "States", st.States),
+				named(HAMT, "PendingProposals", st.PendingProposals),
+				named(HAMT, "EscrowTable", st.EscrowTable),
+				named(HAMT, "LockedTable", st.LockedTable),
+				dealOps)
+		case builtin_spec.StorageMinerActorCodeID:
+			var st miner_spec.State
+			td.GetState(actor.Head(), &st)
+			collections = append(collections,
+				named(HAMT, "PreCommittedSectors", st.PreCommittedSectors),
+				named(AMT, "PreCommittedSectorsExpiry", st.PreCommittedSectorsExpiry),
+				named(AMT, "Sectors", st.Sectors))
+		case builtin_spec.MultisigActorCodeID:
+			var st multisig_spec.State
+			td.GetState(actor.Head(), &st)
+			collections = append(collections, named(HAMT, "PendingTxns", st.PendingTxns))
+		case builtin_spec.PaymentChannelActorCodeID:
+			var st paych_spec.State
+			td.GetState(actor.Head(), &st)
+			collections = append(collections, named(AMT, "LaneStates", st.LaneStates))
+		case builtin_spec.VerifiedRegistryActorCodeID:
+			var st verifreg_spec.State
+			td.GetState(actor.Head(), &st)
+			collections = append(collections,
+				named(HAMT, "Verifiers", st.Verifiers),
+				named(HAMT, "VerifiedClients", st.VerifiedClients))
+		}
+	}
+	return collections
+}
+
+// CollectionDivergences reads the entries of each of `collections` from the state, and rebuilds the collection from
+// them with the parameters of the specs, in a store of its own. It returns, sorted by name, the collections whose
+// roots differ from the rebuilt ones and those whose entries couldn't be read, so that an implementation whose state
+// root differs learns which of its collections are built differently. The entries of a collection are read whatever
+// its bitwidth, so a divergence localizes such a difference to the collection. The inner collections of multimaps are
+// checked as collections of their own, named after their key.
+func (td *TestDriver) CollectionDivergences(collections []Collection) []CollectionDivergence {
+	store := AsStore(td.State())
+	var divergences []CollectionDivergence
+	for len(collections) > 0 {
+		c := collections[0]
+		collections = collections[1:]
+
+		canonical, values, err := canonicalRoot(store, c.Kind, c.Root)
+		if err != nil {
+			divergences = append(divergences, CollectionDivergence{Collection: c, Err: err})
+			continue
+		}
+		if !canonical.Equals(c.Root) {
+			divergences = append(divergences, CollectionDivergence{Collection: c, Canonical: canonical})
+		}
+		if c.Inner == "" {
+			continue
+		}
+		for key, raw := range values {
+			var root cbg.CborCid
+			if err := root.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+				divergences = append(divergences, CollectionDivergence{Collection: c, Err: fmt.Errorf("value at %s isn't a collection root: %w", key, err)})
+				continue
+			}
+			collections = append(collections, Collection{Name: fmt.Sprintf("%s[%s]", c.Name, key), Kind: c.Inner, Root: cid.Cid(root)})
+		}
+	}
+	sort.Slice(divergences, func(i, j int) bool { return divergences[i].Name < divergences[j].Name })
+	return divergences
+}
+
+// AssertCollectionsCanonical asserts that every collection of the current state is built with the parameters of the
+// specs, listing those that aren't.
+func (td *TestDriver) AssertCollectionsCanonical() {
+	divergences := td.CollectionDivergences(td.StateCollections())
+	if len(divergences) == 0 {
+		return
+	}
+	var sb strings.Builder
+	for _, d := range divergences {
+		fmt.Fprintf(&sb, "\n  %s", d)
+	}
+	assert.Fail(td.T, "collections not built with the parameters of the specs", sb.String())
+}
+
+// diagnoseStateRoot logs the collections of the current state not built with the parameters of the specs, after its
+// root differed from that expected. Only the first difference of a test is diagnosed, as later ones usually follow
+// from it.
+func (td *TestDriver) diagnoseStateRoot() {
+	if td.stateRootDiagnosed {
+		return
+	}
+	td.stateRootDiagnosed = true
+	divergences := td.CollectionDivergences(td.StateCollections())
+	if len(divergences) == 0 {
+		td.T.Logf("every collection of the state is built with the parameters of the specs, the difference is in their entries")
+		return
+	}
+	for _, d := range divergences {
+		td.T.Logf("collection not built with the parameters of the specs: %s", d)
+	}
+}
+
+// canonicalRoot returns the root of the collection of kind `kind` holding the entries of that at `root` in `store`,
+// built with the parameters of the specs, along with its entries' encoded values, keyed by their printed keys.
+func canonicalRoot(store adt_spec.Store, kind CollectionKind, root cid.Cid) (cid.Cid, map[string][]byte, error) {
+	values := make(map[string][]byte)
+	var value cbg.Deferred
+	switch kind {
+	case HAMT:
+		m, err := adt_spec.AsMap(store, root)
+		if err != nil {
+			return cid.Undef, nil, err
+		}
+		canonical := adt_spec.MakeEmptyMap(newMockStore())
+		err = m.ForEach(&value, func(k string) error {
+			values[printKey(k)] = append([]byte(nil), value.Raw...)
+			return canonical.Put(adt_spec.StringKey(k), &value)
+		})
+		if err != nil {
+			return cid.Undef, nil, err
+		}
+		c, err := canonical.Root()
+		return c, values, err
+	case AMT:
+		a, err := adt_spec.AsArray(store, root)
+		if err != nil {
+			return cid.Undef, nil, err
+		}
+		canonical := adt_spec.MakeEmptyArray(newMockStore())
+		err = a.ForEach(&value, func(i int64) error {
+			values[fmt.Sprint(i)] = append([]byte(nil), value.Raw...)
+			return canonical.Set(uint64(i), &value)
+		})
+		if err != nil {
+			return cid.Undef, nil, err
+		}
+		c, err := canonical.Root()
+		return c, values, err
+	}
+	return cid.Undef, nil, fmt.Errorf("unknown collection kind %q", kind)
+}
+
+// printKey returns a HAMT key printed as the address it is, if it is one, and in hex otherwise.
+func printKey(k string) string {
+	if addr, err := address.NewFromBytes([]byte(k)); err == nil {
+		return addr.String()
+	}
+	return fmt.Sprintf("%x", k)
+}
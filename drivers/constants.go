@@ -1,6 +1,7 @@
 package drivers
 
 import (
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
 	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
 )
 
@@ -12,4 +13,30 @@ const (
 var (
 	TotalNetworkBalance = big_spec.Mul(big_spec.NewInt(totalFilecoin), big_spec.NewInt(filecoinPrecision))
 	EmptyReturnValue    = []byte{}
+
+	// ExpectAnyReturn, passed as the expected return value to an applier helper or block builder, skips comparison
+	// of a return value that can't be predicted, e.g. one depending on randomness. The exit code and state root are
+	// still validated, as is the return value's encoding.
+	ExpectAnyReturn = []byte("<any return value>")
+)
+
+// FIL returns `n` FIL in attoFIL, the unit of token amounts.
+func FIL(n int64) abi_spec.TokenAmount {
+	return big_spec.Mul(big_spec.NewInt(n), big_spec.NewInt(filecoinPrecision))
+}
+
+// isAnyReturn reports whether `retval` is ExpectAnyReturn itself, rather than a return value equal to it.
+func isAnyReturn(retval []byte) bool {
+	return len(retval) > 0 && &retval[0] == &ExpectAnyReturn[0]
+}
+
+// Consensus limits on the messages of a block. A block exceeding any is invalid, and its tipset rejected.
+const (
+	// BlockMessageLimit is the most messages, BLS and SECP together, a block may include.
+	BlockMessageLimit = 10_000
+	// BlockGasLimit is the most gas the messages of a block may be allowed, summing their gas limits.
+	BlockGasLimit = 10_000_000_000
+	// MessageSizeLimit is the largest a message included in a block may be, in bytes of its serialization, signed
+	// for SECP messages. Applying a message doesn't check its size, but charges for it.
+	MessageSizeLimit = 32 << 10
 )
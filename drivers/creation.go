@@ -0,0 +1,66 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	paych_spec "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// ActorCreation pairs the expected return value of a message creating an actor with assertions on the created
+// actor's initial state.
+type ActorCreation struct {
+	td *TestDriver
+
+	IDAddress     address.Address
+	RobustAddress address.Address
+	ReturnValue   []byte
+
+	verify func()
+}
+
+// Apply applies a message expected to create the actor, then asserts the actor's initial state.
+func (c *ActorCreation) Apply(msg *types.Message) types.ApplyMessageResult {
+	result := c.td.ApplyExpect(msg, c.ReturnValue)
+	c.Verify()
+	return result
+}
+
+// Verify asserts the created actor's initial state.
+func (c *ActorCreation) Verify() {
+	c.verify()
+}
+
+// ExpectPaychCreated describes the creation of a payment channel actor at `expectedID`, through the init actor, by
+// a message from `from` with sequence number `callSeq` transferring `value`. The channel's parties are expected to
+// be resolved to `fromID` and `toID`.
+func (td *TestDriver) ExpectPaychCreated(from address.Address, callSeq uint64, expectedID, fromID, toID address.Address, value abi_spec.TokenAmount) *ActorCreation {
+	ret := td.ComputeInitActorExecReturn(from, callSeq, 0, expectedID)
+	return &ActorCreation{
+		td:            td,
+		IDAddress:     ret.IDAddress,
+		RobustAddress: ret.RobustAddress,
+		ReturnValue:   chain.MustSerialize(&ret),
+		verify: func() {
+			var st paych_spec.State
+			td.GetActorState(expectedID, &st)
+			assert.Equal(td.T, fromID, st.From)
+			assert.Equal(td.T, toID, st.To)
+			assert.Equal(td.T, big_spec.Zero(), st.ToSend)
+			assert.Equal(td.T, abi_spec.ChainEpoch(0), st.SettlingAt)
+			assert.Equal(td.T, abi_spec.ChainEpoch(0), st.MinSettleHeight)
+
+			lanes, err := adt_spec.AsArray(AsStore(td.State()), st.LaneStates)
+			require.NoError(td.T, err)
+			assert.Equal(td.T, uint64(0), lanes.Length())
+
+			td.AssertBalance(expectedID, value)
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	runtime_spec "github.com/filecoin-project/specs-actors/actors/runtime"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// RegisterActor registers the actor code `code`, whose methods are `exports`, with the implementation. The test is
+// skipped if the implementation can't run actors other than the builtin ones.
+func (td *TestDriver) RegisterActor(code cid.Cid, exports []interface{}) {
+	cw, ok := td.State().(state.CustomActorsVMWrapper)
+	if !ok {
+		td.T.Skip("implementation doesn't support custom actors")
+	}
+	require.NoError(td.T, cw.RegisterActor(code, exports), "registering actor code %s", code)
+}
+
+// NewCustomActor registers the actor code `code`, whose methods are `exports`, and installs an actor running it with
+// `balance` and state `st`, at the actor address derived from `seed`. It returns the actor's ID address.
+func (td *TestDriver) NewCustomActor(code cid.Cid, exports []interface{}, seed string, balance abi_spec.TokenAmount, st runtime_spec.CBORMarshaler) address.Address {
+	td.RegisterActor(code, exports)
+	_, id, err := td.State().CreateActor(code, utils.NewActorAddr(td.T, seed), balance, st)
+	require.NoError(td.T, err)
+	return id
+}
+
+// NewProbeActor installs a probe actor with `balance` and a zero state, returning its ID address. See the probe
+// package.
+func (td *TestDriver) NewProbeActor(balance abi_spec.TokenAmount) address.Address {
+	st := probe.State(0)
+	return td.NewCustomActor(probe.ProbeActorCodeID, probe.Actor{}.Exports(), "probe", balance, &st)
+}
@@ -0,0 +1,43 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+)
+
+// ExitCodeClass is a class of exit codes that a message's exit code may be checked against, when only the class of
+// its failure is normative and the exact code may legitimately differ between implementations.
+type ExitCodeClass struct {
+	Name    string
+	Matches func(code exitcode.ExitCode) bool
+}
+
+func (c ExitCodeClass) String() string {
+	return c.Name
+}
+
+var (
+	// AnyError matches every exit code but Ok.
+	AnyError = ExitCodeClass{"any error", func(code exitcode.ExitCode) bool {
+		return code != exitcode.Ok
+	}}
+	// AnySystemError matches the exit codes reserved for the VM, below exitcode.FirstActorErrorCode.
+	AnySystemError = ExitCodeClass{"any system error", func(code exitcode.ExitCode) bool {
+		return code != exitcode.Ok && code < exitcode.FirstActorErrorCode
+	}}
+	// AnyActorError matches the exit codes of actor aborts, from exitcode.FirstActorErrorCode.
+	AnyActorError = ExitCodeClass{"any actor error", func(code exitcode.ExitCode) bool {
+		return code >= exitcode.FirstActorErrorCode
+	}}
+)
+
+// ClassOf returns the class of `code`: Ok only matches itself, and errors match the class of system or actor errors.
+func ClassOf(code exitcode.ExitCode) ExitCodeClass {
+	switch {
+	case code == exitcode.Ok:
+		return ExitCodeClass{"ok", func(c exitcode.ExitCode) bool { return c == exitcode.Ok }}
+	case code < exitcode.FirstActorErrorCode:
+		return AnySystemError
+	default:
+		return AnyActorError
+	}
+}
@@ -0,0 +1,106 @@
+package drivers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/tracker"
+)
+
+// ExportStateEnvVar, when set to a directory, causes test drivers to export their final state tree on completion to
+// a CAR file in that directory, named after the test.
+const ExportStateEnvVar = "CHAIN_VALIDATION_EXPORT_STATE"
+
+// ExportState writes the current state tree to `w` as a CAR (v1) file rooted at the state root. Only DAG-CBOR
+// blocks are followed; links to other codecs, such as sector commitments, aren't stored and are left dangling.
+func (td *TestDriver) ExportState(w io.Writer) error {
+	return writeStateCAR(td.State(), w)
+}
+
+// writeStateCAR writes the state tree of `st` to `w` as ExportState does.
+func writeStateCAR(st state.VMWrapper, w io.Writer) error {
+	root := st.Root()
+	hdr, err := cbor.DumpObject(&carHeader{Roots: []cid.Cid{root}, Version: 1})
+	if err != nil {
+		return err
+	}
+	if err := writeCARSection(w, hdr); err != nil {
+		return err
+	}
+
+	seen := map[cid.Cid]struct{}{root: {}}
+	queue := []cid.Cid{root}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+
+		var blk cbg.Deferred
+		if err := st.StoreGet(c, &blk); err != nil {
+			return fmt.Errorf("loading block %s: %w", c, err)
+		}
+		if err := writeCARSection(w, c.Bytes(), blk.Raw); err != nil {
+			return err
+		}
+
+		links, err := chain.CBORLinks(blk.Raw)
+		if err != nil {
+			return fmt.Errorf("reading links of block %s: %w", c, err)
+		}
+		for _, l := range links {
+			if _, ok := seen[l]; ok || l.Prefix().Codec != cid.DagCBOR {
+				continue
+			}
+			seen[l] = struct{}{}
+			queue = append(queue, l)
+		}
+	}
+	return nil
+}
+
+// exportState exports the state tree to ExportStateEnvVar, if set.
+func (td *TestDriver) exportState() {
+	dir := os.Getenv(ExportStateEnvVar)
+	if dir == "" {
+		return
+	}
+	path := filepath.Join(dir, tracker.TestFileName(td.T)+".car")
+	f, err := os.Create(path)
+	if err != nil {
+		td.T.Errorf("failed to export state: %v", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if err := td.ExportState(f); err != nil {
+		td.T.Errorf("failed to export state to %s: %v", path, err)
+		return
+	}
+	td.T.Logf("exported state tree to %s", path)
+}
+
+// writeCARSection writes the concatenation of `parts` prefixed with its length.
+func writeCARSection(w io.Writer, parts ...[]byte) error {
+	size := 0
+	for _, p := range parts {
+		size += len(p)
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(size))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	for _, p := range parts {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
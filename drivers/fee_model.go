@@ -0,0 +1,101 @@
+package drivers
+
+import (
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// Gas used beyond which a message's unused gas limit is partly burnt, as a fraction of the gas used.
+const (
+	overuseNum = 11
+	overuseDen = 10
+)
+
+// FeeModel computes how the gas charges of an executed message are split between the sender, the block miner and
+// the burnt funds actor, given the base fee of the tipset including it.
+//
+// The sender is charged the base fee for the gas it used and for part of the gas it over-estimated, both burnt, and
+// the miner tip for its whole gas limit. The base fee charged is capped by the message's fee cap, any shortfall
+// being charged to the miner as a penalty, and the tip per unit of gas is capped such that the sender never pays
+// more than its fee cap.
+type FeeModel struct {
+	BaseFee    abi_spec.TokenAmount
+	GasFeeCap  abi_spec.TokenAmount
+	GasPremium abi_spec.TokenAmount
+	GasLimit   types.GasUnits
+	GasUsed    types.GasUnits
+}
+
+// NewFeeModel returns the fee model of `msg` having used `gasUsed`, included in a tipset with base fee `baseFee`.
+func NewFeeModel(baseFee abi_spec.TokenAmount, msg *types.Message, gasUsed types.GasUnits) FeeModel {
+	return FeeModel{
+		BaseFee:    baseFee,
+		GasFeeCap:  msg.GasFeeCap,
+		GasPremium: msg.GasPremium,
+		GasLimit:   types.GasUnits(msg.GasLimit),
+		GasUsed:    gasUsed,
+	}
+}
+
+// BaseFeeToPay returns the base fee charged per unit of gas, which is capped by the fee cap.
+func (m FeeModel) BaseFeeToPay() abi_spec.TokenAmount {
+	return big_spec.Min(m.BaseFee, m.GasFeeCap)
+}
+
+// OverestimationGas returns the amount of gas over-estimated by the gas limit for which the base fee is burnt. No
+// gas is burnt for a gas limit within overuseNum/overuseDen of the gas used. Above that, a growing fraction of the
+// unused gas is burnt, up to all of it at twice the gas used.
+func (m FeeModel) OverestimationGas() types.GasUnits {
+	if m.GasUsed == 0 {
+		return m.GasLimit
+	}
+	over := m.GasLimit - (overuseNum*m.GasUsed)/overuseDen
+	if over < 0 {
+		return 0
+	}
+	if over > m.GasUsed {
+		over = m.GasUsed
+	}
+	gas := big_spec.Mul(big_spec.NewInt(int64(m.GasLimit-m.GasUsed)), big_spec.NewInt(int64(over)))
+	return types.GasUnits(big_spec.Div(gas, m.GasUsed.Big()).Int64())
+}
+
+// BaseFeeBurn returns the base fee burnt for the gas used.
+func (m FeeModel) BaseFeeBurn() abi_spec.TokenAmount {
+	return big_spec.Mul(m.BaseFeeToPay(), m.GasUsed.Big())
+}
+
+// OverestimationBurn returns the base fee burnt for over-estimated gas.
+func (m FeeModel) OverestimationBurn() abi_spec.TokenAmount {
+	return big_spec.Mul(m.BaseFeeToPay(), m.OverestimationGas().Big())
+}
+
+// Burn returns the total charged to the sender and burnt.
+func (m FeeModel) Burn() abi_spec.TokenAmount {
+	return big_spec.Add(m.BaseFeeBurn(), m.OverestimationBurn())
+}
+
+// MinerTip returns the amount charged to the sender and paid to the block miner.
+func (m FeeModel) MinerTip() abi_spec.TokenAmount {
+	headroom := big_spec.Sub(m.GasFeeCap, m.BaseFeeToPay())
+	return big_spec.Mul(big_spec.Min(m.GasPremium, headroom), m.GasLimit.Big())
+}
+
+// MinerPenalty returns the amount charged to the block miner and burnt for the shortfall of the fee cap below the
+// base fee, for each unit of gas used and of over-estimated gas burnt.
+func (m FeeModel) MinerPenalty() abi_spec.TokenAmount {
+	shortfall := big_spec.Sub(m.BaseFee, m.BaseFeeToPay())
+	return big_spec.Mul(shortfall, (m.GasUsed + m.OverestimationGas()).Big())
+}
+
+// GasCost returns the total charged to the sender for gas, excluding any value transferred.
+func (m FeeModel) GasCost() abi_spec.TokenAmount {
+	return big_spec.Add(m.Burn(), m.MinerTip())
+}
+
+// Refund returns the part of the sender's maximum gas cost, its fee cap for its whole gas limit, that it isn't charged.
+func (m FeeModel) Refund() abi_spec.TokenAmount {
+	return big_spec.Sub(big_spec.Mul(m.GasFeeCap, m.GasLimit.Big()), m.GasCost())
+}
@@ -0,0 +1,101 @@
+package drivers
+
+import (
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+func TestFeeModel(t *testing.T) {
+	tokens := abi_spec.NewTokenAmount
+
+	testCases := []struct {
+		desc  string
+		model FeeModel
+
+		overestimationGas types.GasUnits
+		burn              int64
+		minerTip          int64
+		minerPenalty      int64
+		refund            int64
+	}{{
+		desc:              "gas limit within overuse of gas used",
+		model:             FeeModel{BaseFee: tokens(100), GasFeeCap: tokens(200), GasPremium: tokens(1), GasLimit: 1_050, GasUsed: 1_000},
+		overestimationGas: 0,
+		burn:              100 * 1_000,
+		minerTip:          1 * 1_050,
+		refund:            200*1_050 - 100*1_000 - 1*1_050,
+	}, {
+		desc:  "gas limit partly over-estimated",
+		model: FeeModel{BaseFee: tokens(100), GasFeeCap: tokens(200), GasPremium: tokens(1), GasLimit: 2_000, GasUsed: 1_000},
+		// (2000 - 1000) * (2000 - 1100) / 1000
+		overestimationGas: 900,
+		burn:              100 * (1_000 + 900),
+		minerTip:          1 * 2_000,
+		refund:            200*2_000 - 100*(1_000+900) - 1*2_000,
+	}, {
+		desc:  "gas limit over twice gas used",
+		model: FeeModel{BaseFee: tokens(100), GasFeeCap: tokens(200), GasPremium: tokens(1), GasLimit: 5_000, GasUsed: 1_000},
+		// All unused gas is burnt.
+		overestimationGas: 4_000,
+		burn:              100 * 5_000,
+		minerTip:          1 * 5_000,
+		refund:            200*5_000 - 100*5_000 - 1*5_000,
+	}, {
+		desc:  "no gas used",
+		model: FeeModel{BaseFee: tokens(100), GasFeeCap: tokens(200), GasPremium: tokens(1), GasLimit: 1_000, GasUsed: 0},
+		// The whole gas limit is over-estimated.
+		overestimationGas: 1_000,
+		burn:              100 * 1_000,
+		minerTip:          1 * 1_000,
+		refund:            200*1_000 - 100*1_000 - 1*1_000,
+	}, {
+		desc:              "premium capped by fee cap",
+		model:             FeeModel{BaseFee: tokens(100), GasFeeCap: tokens(105), GasPremium: tokens(10), GasLimit: 1_000, GasUsed: 1_000},
+		overestimationGas: 0,
+		burn:              100 * 1_000,
+		minerTip:          5 * 1_000,
+		refund:            0,
+	}, {
+		desc:              "fee cap below base fee",
+		model:             FeeModel{BaseFee: tokens(100), GasFeeCap: tokens(80), GasPremium: tokens(10), GasLimit: 2_000, GasUsed: 1_000},
+		overestimationGas: 900,
+		burn:              80 * (1_000 + 900),
+		minerTip:          0,
+		minerPenalty:      20 * (1_000 + 900),
+		refund:            80*2_000 - 80*(1_000+900),
+	}}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			m := tc.model
+			assert.Equal(t, tc.overestimationGas, m.OverestimationGas())
+			assert.Equal(t, big_spec.Mul(m.BaseFeeToPay(), m.GasUsed.Big()), m.BaseFeeBurn())
+			assert.Equal(t, tokens(tc.burn), m.Burn())
+			assert.Equal(t, m.Burn(), big_spec.Add(m.BaseFeeBurn(), m.OverestimationBurn()))
+			assert.Equal(t, tokens(tc.minerTip), m.MinerTip())
+			assert.Equal(t, tokens(tc.minerPenalty), m.MinerPenalty())
+			assert.Equal(t, tokens(tc.burn+tc.minerTip), m.GasCost())
+			assert.Equal(t, tokens(tc.refund), m.Refund())
+		})
+	}
+}
+
+func TestNewFeeModel(t *testing.T) {
+	msg := &types.Message{
+		GasLimit:   1_000,
+		GasFeeCap:  abi_spec.NewTokenAmount(200),
+		GasPremium: abi_spec.NewTokenAmount(3),
+	}
+	assert.Equal(t, FeeModel{
+		BaseFee:    abi_spec.NewTokenAmount(100),
+		GasFeeCap:  abi_spec.NewTokenAmount(200),
+		GasPremium: abi_spec.NewTokenAmount(3),
+		GasLimit:   1_000,
+		GasUsed:    600,
+	}, NewFeeModel(abi_spec.NewTokenAmount(100), msg, 600))
+}
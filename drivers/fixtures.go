@@ -0,0 +1,228 @@
+package drivers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+
+	"github.com/filecoin-project/chain-validation/chain/commitments"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// FixturesEnvVar names the directory in which the states of WithCachedGenesis are cached. It defaults to a
+// directory in the system's temporary directory.
+const FixturesEnvVar = "CHAIN_VALIDATION_FIXTURES"
+
+// Size of the state of LargeStateGenesis.
+const (
+	LargeStateAccounts        = 10_000
+	LargeStateMiners          = 1_000
+	LargeStateSectorsPerMiner = 10
+	LargeStateDeals           = 50_000
+)
+
+// LargeStateGenesis returns a genesis of the size of a busy network, LargeStateAccounts accounts and LargeStateMiners
+// miners of LargeStateSectorsPerMiner sectors each, with LargeStateDeals deals published between them, for suites
+// measuring the performance of an implementation on realistic states. Pass it to WithCachedGenesis, as it takes
+// minutes to construct. Its deals start far beyond the epochs suites apply messages at, so cron leaves them alone.
+func LargeStateGenesis() *GenesisBuilder {
+	g := NewGenesisBuilder().
+		WithAccounts(LargeStateAccounts, SECP, FIL(1_000)).
+		WithMiners(LargeStateMiners, GenesisMinerSpec{Sectors: LargeStateSectorsPerMiner, Expiration: 1_000_000})
+	for i := 0; i < LargeStateDeals; i++ {
+		g.WithDeal(GenesisDealSpec{
+			Client:   i % LargeStateAccounts,
+			Provider: i % LargeStateMiners,
+			// Each deal is of its own piece, so that no two proposals are alike.
+			Piece:                commitments.DealPiece(uint64(i)),
+			StartEpoch:           100_000,
+			EndEpoch:             300_000,
+			StoragePricePerEpoch: abi_spec.NewTokenAmount(1),
+			ProviderCollateral:   abi_spec.NewTokenAmount(1),
+			ClientCollateral:     abi_spec.NewTokenAmount(1),
+		})
+	}
+	return g
+}
+
+// WithCachedGenesis is WithGenesis for states costly to construct. The state described by `g` is constructed once,
+// with the keys of its accounts derived from seeds, and cached in the directory named by FixturesEnvVar as a CAR
+// file along with the addresses of its actors. Drivers built later, in the same run or another, import the cached
+// state and derive the same keys, so the genesis accounts, miner owners and workers can still sign messages. States
+// are cached by the description of their genesis, so changing it constructs a new state; clear the directory when
+// changing how states are constructed, or the implementation's store. It replaces
+// WithActorState(DefaultBuiltinActorsState...), WithGenesis and WithGenesisCAR. States are cached apart for each
+// implementation, see state.NamedFactories.
+func (b *TestDriverBuilder) WithCachedGenesis(g *GenesisBuilder) *TestDriverBuilder {
+	b.cachedGenesis = g
+	return b
+}
+
+// fixtureFormat is the version of the construction and encoding of fixtures, part of their cache keys. Bump it when
+// changing either, so that fixtures cached before aren't used.
+const fixtureFormat = 2
+
+// fixture is a genesis state cached by WithCachedGenesis.
+type fixture struct {
+	// Path of the CAR file holding the state tree.
+	CAR string `json:"-"`
+	// Address protocols of the keys derived from seeds for the fixture's accounts, in the order they were created.
+	Keys    []address.Protocol
+	Genesis *Genesis
+}
+
+// fixturesLock serializes the construction of fixtures, so that drivers built in parallel construct each only once.
+var fixturesLock sync.Mutex
+
+// loadFixture returns the fixture of `g`, constructing it with `factory` and caching it first if necessary.
+func loadFixture(t testing.TB, factory state.Factories, g *GenesisBuilder) (*fixture, error) {
+	fixturesLock.Lock()
+	defer fixturesLock.Unlock()
+
+	dir := os.Getenv(FixturesEnvVar)
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "chain-validation-fixtures")
+	}
+	key, err := g.fixtureKey(factory)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, key)
+	raw, err := ioutil.ReadFile(base + ".json")
+	if os.IsNotExist(err) {
+		t.Logf("constructing genesis fixture %s", base)
+		if err := buildFixture(t, factory, g, base); err != nil {
+			return nil, fmt.Errorf("constructing genesis fixture: %w", err)
+		}
+		raw, err = ioutil.ReadFile(base + ".json")
+	}
+	if err != nil {
+		return nil, err
+	}
+	f := &fixture{CAR: base + ".car"}
+	if err := json.Unmarshal(raw, f); err != nil {
+		return nil, fmt.Errorf("decoding genesis fixture %s: %w", base, err)
+	}
+	return f, nil
+}
+
+// buildFixture constructs the state described by `g` in a new state made by `factory`, and writes it to `base` with
+// the extension ".car", and the fixture describing it with the extension ".json". The description is written last,
+// once the state is complete, so that the fixture is only found once both are.
+func buildFixture(t testing.TB, factory state.Factories, g *GenesisBuilder, base string) error {
+	st, _ := factory.NewStateAndApplier(NewChainValidationSysCalls())
+	km := &seededKeyManager{KeyManager: factory.NewKeyManager()}
+	sd := NewStateDriver(t, st, km)
+	st.NewVM()
+	if _, err := PutEmptyRoots(AsStore(st)); err != nil {
+		return err
+	}
+	for _, acts := range DefaultBuiltinActorsState {
+		if _, _, err := st.CreateActor(acts.Code, acts.Addr, acts.Balance, acts.State); err != nil {
+			return fmt.Errorf("creating actor at %s: %w", acts.Addr, err)
+		}
+	}
+	gen := g.build(sd)
+
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		return err
+	}
+	car, err := ioutil.TempFile(filepath.Dir(base), filepath.Base(base)+".car.*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(car.Name()) }()
+	if err := writeStateCAR(st, car); err != nil {
+		_ = car.Close()
+		return err
+	}
+	if err := car.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(car.Name(), base+".car"); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(&fixture{Keys: km.keys, Genesis: gen})
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(base+".json", raw, 0644)
+}
+
+// fixtureKey returns the name under which the state described by `g` and constructed by `factory` is cached, a digest
+// of the description, the fixture format and the implementation. Implementations are identified by their
+// ImplementationName if they implement state.NamedFactories, and by the type of their factories otherwise, so that a
+// state constructed by one implementation is never imported by another.
+func (g *GenesisBuilder) fixtureKey(factory state.Factories) (string, error) {
+	// Sender variants and network versions don't apply to fixtures, whose actors are constructed apart from any driver.
+	factory = implementationFactories(factory)
+	impl := fmt.Sprintf("%T", factory)
+	if nf, ok := factory.(state.NamedFactories); ok {
+		impl = nf.ImplementationName()
+	}
+	desc, err := json.Marshal(struct {
+		Format           int
+		Implementation   string
+		Accounts         []GenesisAccountSpec
+		Miners           []GenesisMinerSpec
+		Deals            []GenesisDealSpec
+		VerifiedRegistry bool
+	}{fixtureFormat, impl, g.accounts, g.miners, g.deals, g.verifiedRegistry})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(desc)
+	return "genesis-" + hex.EncodeToString(sum[:8]), nil
+}
+
+// restore derives the keys of the fixture's accounts in the key manager of `d`, and records the public keys of its
+// actors, as if `d` had constructed it.
+func (f *fixture) restore(d *StateDriver) {
+	for i, protocol := range f.Keys {
+		switch protocol {
+		case address.SECP256K1:
+			d.w.NewSECP256k1AccountKeyFromSeed(fixtureSeed(i))
+		case address.BLS:
+			d.w.NewBLSAccountKeyFromSeed(fixtureSeed(i))
+		}
+	}
+	for _, a := range f.Genesis.Accounts {
+		d.actorIDMap[a.ID] = a.PubKey
+	}
+	for _, m := range f.Genesis.Miners {
+		d.actorIDMap[m.Info.OwnerID] = m.Info.Owner
+		d.actorIDMap[m.Info.WorkerID] = m.Info.Worker
+	}
+}
+
+// fixtureSeed returns the seed from which the key of a fixture's `i`th account is derived.
+func fixtureSeed(i int) []byte {
+	return []byte(fmt.Sprintf("chain-validation fixture key %d", i))
+}
+
+// seededKeyManager derives the keys of new accounts from successive fixture seeds, recording their protocols, so
+// that they can be derived again from the fixture.
+type seededKeyManager struct {
+	state.KeyManager
+	keys []address.Protocol
+}
+
+func (k *seededKeyManager) NewSECP256k1AccountAddress() address.Address {
+	k.keys = append(k.keys, address.SECP256K1)
+	return k.NewSECP256k1AccountKeyFromSeed(fixtureSeed(len(k.keys) - 1))
+}
+
+func (k *seededKeyManager) NewBLSAccountAddress() address.Address {
+	k.keys = append(k.keys, address.BLS)
+	return k.NewBLSAccountKeyFromSeed(fixtureSeed(len(k.keys) - 1))
+}
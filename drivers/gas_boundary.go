@@ -0,0 +1,54 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// FindGasBoundary returns the least gas limit with which `msg` succeeds at the current epoch, found by binary search
+// with read-only calls, so the state is unchanged. The message must succeed with its own gas limit, and run out of
+// gas with any limit less than the boundary.
+func (td *TestDriver) FindGasBoundary(msg *types.Message) int64 {
+	result := td.Call(msg)
+	require.Equal(td.T, exitcode.Ok, result.Receipt.ExitCode, "message fails with its own gas limit %d", msg.GasLimit)
+
+	// The message runs out of gas with limit lo and succeeds with limit hi.
+	lo, hi := int64(0), msg.GasLimit
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		switch code := td.callWithGasLimit(msg, mid).Receipt.ExitCode; code {
+		case exitcode.Ok:
+			hi = mid
+		case exitcode.SysErrOutOfGas:
+			lo = mid
+		default:
+			td.T.Fatalf("message with gas limit %d failed with exit code %s, expected it to succeed or run out of gas", mid, code)
+		}
+	}
+	return hi
+}
+
+// AssertGasBoundary finds the gas boundary of `msg` and asserts either side of it: with a gas limit one less, the
+// message runs out of gas and is charged the whole limit; with the boundary limit, it succeeds using exactly that
+// gas. It returns the boundary.
+func (td *TestDriver) AssertGasBoundary(msg *types.Message) int64 {
+	boundary := td.FindGasBoundary(msg)
+
+	below := td.callWithGasLimit(msg, boundary-1).Receipt
+	assert.Equal(td.T, exitcode.SysErrOutOfGas, below.ExitCode, "exit code with gas limit %d", boundary-1)
+	assert.Equal(td.T, types.GasUnits(boundary-1), below.GasUsed, "gas used with gas limit %d", boundary-1)
+
+	at := td.callWithGasLimit(msg, boundary).Receipt
+	assert.Equal(td.T, exitcode.Ok, at.ExitCode, "exit code with gas limit %d", boundary)
+	assert.Equal(td.T, types.GasUnits(boundary), at.GasUsed, "gas used with gas limit %d", boundary)
+	return boundary
+}
+
+func (td *TestDriver) callWithGasLimit(msg *types.Message, gasLimit int64) types.ApplyMessageResult {
+	limited := *msg
+	limited.GasLimit = gasLimit
+	return td.Call(&limited)
+}
@@ -0,0 +1,288 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	market_spec "github.com/filecoin-project/specs-actors/actors/builtin/market"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	power_spec "github.com/filecoin-project/specs-actors/actors/builtin/power"
+	verifreg_spec "github.com/filecoin-project/specs-actors/actors/builtin/verifreg"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain/commitments"
+)
+
+// GenesisBuilder declaratively describes a genesis state: the builtin actors, plus accounts, miners with committed
+// sectors and power, published deals and a verified registry. It is passed to TestDriverBuilder.WithGenesis in place
+// of WithActorState(DefaultBuiltinActorsState...), and the state is constructed directly, without applying messages.
+type GenesisBuilder struct {
+	accounts []GenesisAccountSpec
+	miners   []GenesisMinerSpec
+	deals    []GenesisDealSpec
+
+	verifiedRegistry bool
+}
+
+// GenesisAccountSpec describes an account actor.
+type GenesisAccountSpec struct {
+	Type    address.Protocol
+	Balance abi_spec.TokenAmount
+}
+
+// GenesisMinerSpec describes a miner actor of the test seal proof type, along with its owner and worker.
+type GenesisMinerSpec struct {
+	// Number of committed sectors. Each adds one sector size of raw and quality adjusted power.
+	Sectors uint64
+	// Epoch at which the committed sectors expire.
+	Expiration abi_spec.ChainEpoch
+}
+
+// GenesisDealSpec describes a deal published but not yet activated. Client and provider escrow exactly covers the
+// deal, and is locked.
+type GenesisDealSpec struct {
+	// Index of the client in the genesis accounts.
+	Client int
+	// Index of the provider in the genesis miners.
+	Provider int
+
+	Piece                commitments.Piece
+	StartEpoch           abi_spec.ChainEpoch
+	EndEpoch             abi_spec.ChainEpoch
+	StoragePricePerEpoch abi_spec.TokenAmount
+	ProviderCollateral   abi_spec.TokenAmount
+	ClientCollateral     abi_spec.TokenAmount
+}
+
+// Genesis holds the addresses and identifiers of the actors and deals created from a GenesisBuilder, in the order
+// they were specified.
+type Genesis struct {
+	Accounts []GenesisAccount
+	Miners   []GenesisMiner
+	Deals    []abi_spec.DealID
+
+	// ID address of the verified registry's root key, or address.Undef if there is no verified registry.
+	VerifiedRegistryRoot address.Address
+}
+
+type GenesisAccount struct {
+	PubKey address.Address
+	ID     address.Address
+}
+
+type GenesisMiner struct {
+	ID      address.Address
+	Info    *MinerInfo
+	Sectors []abi_spec.SectorNumber
+}
+
+func NewGenesisBuilder() *GenesisBuilder {
+	return &GenesisBuilder{}
+}
+
+// WithAccounts adds `n` accounts with address protocol `addrType` and balance `balance`.
+func (g *GenesisBuilder) WithAccounts(n int, addrType address.Protocol, balance abi_spec.TokenAmount) *GenesisBuilder {
+	for i := 0; i < n; i++ {
+		g.accounts = append(g.accounts, GenesisAccountSpec{Type: addrType, Balance: balance})
+	}
+	return g
+}
+
+// WithMiners adds `n` miners described by `spec`.
+func (g *GenesisBuilder) WithMiners(n int, spec GenesisMinerSpec) *GenesisBuilder {
+	for i := 0; i < n; i++ {
+		g.miners = append(g.miners, spec)
+	}
+	return g
+}
+
+// WithDeal adds a published deal between a genesis account and a genesis miner.
+func (g *GenesisBuilder) WithDeal(spec GenesisDealSpec) *GenesisBuilder {
+	g.deals = append(g.deals, spec)
+	return g
+}
+
+// WithVerifiedRegistry adds the verified registry actor, with a new SECP account as its root key.
+func (g *GenesisBuilder) WithVerifiedRegistry() *GenesisBuilder {
+	g.verifiedRegistry = true
+	return g
+}
+
+// build constructs the described state in `d`, which must already hold the builtin actors.
+func (g *GenesisBuilder) build(d *StateDriver) *Genesis {
+	gen := &Genesis{VerifiedRegistryRoot: address.Undef}
+
+	for _, spec := range g.accounts {
+		pk, id := d.NewAccountActor(spec.Type, spec.Balance)
+		gen.Accounts = append(gen.Accounts, GenesisAccount{PubKey: pk, ID: id})
+	}
+
+	sealedSeq := uint64(0)
+	for _, spec := range g.miners {
+		id, info := d.NewDefaultMinerActor()
+		miner := GenesisMiner{ID: id, Info: info}
+		for i := uint64(0); i < spec.Sectors; i++ {
+			miner.Sectors = append(miner.Sectors, abi_spec.SectorNumber(i))
+		}
+		g.commitSectors(d, id, miner.Sectors, spec.Expiration, &sealedSeq)
+		g.claimPower(d, id, uint64(len(miner.Sectors)))
+		gen.Miners = append(gen.Miners, miner)
+	}
+
+	for _, spec := range g.deals {
+		require.True(d.tb, spec.Client < len(gen.Accounts), "deal client %d is not a genesis account", spec.Client)
+		require.True(d.tb, spec.Provider < len(gen.Miners), "deal provider %d is not a genesis miner", spec.Provider)
+		gen.Deals = append(gen.Deals, g.publishDeal(d, gen.Accounts[spec.Client].ID, gen.Miners[spec.Provider].ID, spec))
+	}
+
+	if g.verifiedRegistry {
+		_, root := d.NewAccountActor(SECP, big_spec.Zero())
+		_, _, err := d.State().CreateActor(builtin_spec.VerifiedRegistryActorCodeID, builtin_spec.VerifiedRegistryActorAddr, big_spec.Zero(), verifreg_spec.ConstructState(emptyRoots.Map, root))
+		require.NoError(d.tb, err)
+		gen.VerifiedRegistryRoot = root
+	}
+	return gen
+}
+
+// commitSectors adds active sectors to a miner, assigning them to deadlines and partitions as ConfirmSectorProofsValid
+// does, so the miner is expected to prove them from its first proving period on.
+func (g *GenesisBuilder) commitSectors(d *StateDriver, minerID address.Address, sectors []abi_spec.SectorNumber, expiration abi_spec.ChainEpoch, sealedSeq *uint64) {
+	if len(sectors) == 0 {
+		return
+	}
+	store := AsStore(d.State())
+
+	var st miner_spec.State
+	d.GetActorState(minerID, &st)
+	infos := make([]*miner_spec.SectorOnChainInfo, len(sectors))
+	allocated := make([]uint64, len(sectors))
+	for i, num := range sectors {
+		infos[i] = &miner_spec.SectorOnChainInfo{
+			SectorNumber:          num,
+			SealProof:             TestSealProofType,
+			SealedCID:             commitments.SealedCID(*sealedSeq),
+			DealIDs:               nil,
+			Activation:            0,
+			Expiration:            expiration,
+			DealWeight:            big_spec.Zero(),
+			VerifiedDealWeight:    big_spec.Zero(),
+			InitialPledge:         big_spec.Zero(),
+			ExpectedDayReward:     big_spec.Zero(),
+			ExpectedStoragePledge: big_spec.Zero(),
+		}
+		allocated[i] = uint64(num)
+		*sealedSeq++
+	}
+	require.NoError(d.tb, st.PutSectors(store, infos...))
+	allocatedBf := bitfield.NewFromSet(allocated)
+	st.AllocatedSectors = d.PutState(&allocatedBf)
+
+	info, err := st.GetInfo(store)
+	require.NoError(d.tb, err)
+	_, err = st.AssignSectorsToDeadlines(store, 0, infos, info.WindowPoStPartitionSectors, info.SectorSize)
+	require.NoError(d.tb, err)
+
+	minerAct, err := d.State().Actor(minerID)
+	require.NoError(d.tb, err)
+	_, err = d.State().SetActorState(minerID, minerAct.Balance(), &st)
+	require.NoError(d.tb, err)
+}
+
+// claimPower registers a miner with the power actor, claiming the power of `sectors` committed sectors.
+// NewMinerActor doesn't write the power actor's state back, so the claim and miner count are recorded here.
+func (g *GenesisBuilder) claimPower(d *StateDriver, minerID address.Address, sectors uint64) {
+	// Sectors without deals have quality adjusted power equal to their size.
+	ss, err := TestSealProofType.SectorSize()
+	require.NoError(d.tb, err)
+	power := big_spec.NewInt(int64(ss) * int64(sectors))
+
+	var spa power_spec.State
+	d.GetActorState(builtin_spec.StoragePowerActorAddr, &spa)
+	claims, err := adt_spec.AsMap(AsStore(d.State()), spa.Claims)
+	require.NoError(d.tb, err)
+	require.NoError(d.tb, claims.Put(adt_spec.AddrKey(minerID), &power_spec.Claim{
+		RawBytePower:    power,
+		QualityAdjPower: power,
+	}))
+	spa.Claims, err = claims.Root()
+	require.NoError(d.tb, err)
+	spa.MinerCount++
+	// Test sectors are far below the consensus minimum power, so MinerAboveMinPowerCount is left alone.
+	spa.TotalRawBytePower = big_spec.Add(spa.TotalRawBytePower, power)
+	spa.TotalBytesCommitted = big_spec.Add(spa.TotalBytesCommitted, power)
+	spa.TotalQualityAdjPower = big_spec.Add(spa.TotalQualityAdjPower, power)
+	spa.TotalQABytesCommitted = big_spec.Add(spa.TotalQABytesCommitted, power)
+	spa.ThisEpochRawBytePower = spa.TotalRawBytePower
+	spa.ThisEpochQualityAdjPower = spa.TotalQualityAdjPower
+	powerAct, err := d.State().Actor(builtin_spec.StoragePowerActorAddr)
+	require.NoError(d.tb, err)
+	_, err = d.State().SetActorState(builtin_spec.StoragePowerActorAddr, powerAct.Balance(), &spa)
+	require.NoError(d.tb, err)
+}
+
+// publishDeal records a deal as PublishStorageDeals would, escrowing and locking exactly the funds it requires.
+func (g *GenesisBuilder) publishDeal(d *StateDriver, client, provider address.Address, spec GenesisDealSpec) abi_spec.DealID {
+	store := AsStore(d.State())
+	proposal := market_spec.DealProposal{
+		PieceCID:             spec.Piece.CID,
+		PieceSize:            spec.Piece.Size,
+		VerifiedDeal:         false,
+		Client:               client,
+		Provider:             provider,
+		StartEpoch:           spec.StartEpoch,
+		EndEpoch:             spec.EndEpoch,
+		StoragePricePerEpoch: spec.StoragePricePerEpoch,
+		ProviderCollateral:   spec.ProviderCollateral,
+		ClientCollateral:     spec.ClientCollateral,
+	}
+	storageFee := proposal.TotalStorageFee()
+	clientFunds := big_spec.Add(storageFee, spec.ClientCollateral)
+
+	var st market_spec.State
+	d.GetActorState(builtin_spec.StorageMarketActorAddr, &st)
+	dealID := st.NextID
+
+	proposals, err := adt_spec.AsArray(store, st.Proposals)
+	require.NoError(d.tb, err)
+	require.NoError(d.tb, proposals.Set(uint64(dealID), &proposal))
+	st.Proposals, err = proposals.Root()
+	require.NoError(d.tb, err)
+
+	pcid, err := proposal.Cid()
+	require.NoError(d.tb, err)
+	pending, err := adt_spec.AsMap(store, st.PendingProposals)
+	require.NoError(d.tb, err)
+	require.NoError(d.tb, pending.Put(adt_spec.CidKey(pcid), &proposal))
+	st.PendingProposals, err = pending.Root()
+	require.NoError(d.tb, err)
+
+	dealOps, err := market_spec.AsSetMultimap(store, st.DealOpsByEpoch)
+	require.NoError(d.tb, err)
+	require.NoError(d.tb, dealOps.Put(spec.StartEpoch, dealID))
+	st.DealOpsByEpoch, err = dealOps.Root()
+	require.NoError(d.tb, err)
+
+	for _, table := range []*cid.Cid{&st.EscrowTable, &st.LockedTable} {
+		bt, err := adt_spec.AsBalanceTable(store, *table)
+		require.NoError(d.tb, err)
+		require.NoError(d.tb, bt.Add(client, clientFunds))
+		require.NoError(d.tb, bt.Add(provider, spec.ProviderCollateral))
+		*table, err = bt.Root()
+		require.NoError(d.tb, err)
+	}
+	st.TotalClientStorageFee = big_spec.Add(st.TotalClientStorageFee, storageFee)
+	st.TotalClientLockedCollateral = big_spec.Add(st.TotalClientLockedCollateral, spec.ClientCollateral)
+	st.TotalProviderLockedCollateral = big_spec.Add(st.TotalProviderLockedCollateral, spec.ProviderCollateral)
+	st.NextID++
+
+	marketAct, err := d.State().Actor(builtin_spec.StorageMarketActorAddr)
+	require.NoError(d.tb, err)
+	balance := big_spec.Sum(marketAct.Balance(), clientFunds, spec.ProviderCollateral)
+	_, err = d.State().SetActorState(builtin_spec.StorageMarketActorAddr, balance, &st)
+	require.NoError(d.tb, err)
+	return dealID
+}
@@ -0,0 +1,53 @@
+package drivers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+)
+
+// carHeader is the header of a CAR (v1) file, a varint length prefixed DAG-CBOR map.
+type carHeader struct {
+	Roots   []cid.Cid
+	Version uint64
+}
+
+func init() {
+	cbor.RegisterCborType(carHeader{})
+}
+
+// Headers larger than this are rejected rather than read into memory.
+const maxCARHeaderSize = 32 << 20
+
+// readCARRoot returns the single root of the CAR file in `car`, which must hold a state tree.
+func readCARRoot(car []byte) (cid.Cid, error) {
+	r := bufio.NewReader(bytes.NewReader(car))
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return cid.Undef, fmt.Errorf("reading CAR header length: %w", err)
+	}
+	if size == 0 || size > maxCARHeaderSize {
+		return cid.Undef, fmt.Errorf("invalid CAR header length %d", size)
+	}
+	raw := make([]byte, size)
+	if _, err := io.ReadFull(r, raw); err != nil {
+		return cid.Undef, fmt.Errorf("reading CAR header: %w", err)
+	}
+
+	var hdr carHeader
+	if err := cbor.DecodeInto(raw, &hdr); err != nil {
+		return cid.Undef, fmt.Errorf("decoding CAR header: %w", err)
+	}
+	if hdr.Version != 1 {
+		return cid.Undef, fmt.Errorf("unsupported CAR version %d", hdr.Version)
+	}
+	if len(hdr.Roots) != 1 {
+		return cid.Undef, fmt.Errorf("expected a single state tree root in CAR, found %d", len(hdr.Roots))
+	}
+	return hdr.Roots[0], nil
+}
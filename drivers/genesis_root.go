@@ -0,0 +1,56 @@
+package drivers
+
+import (
+	"fmt"
+	"io"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+// CanonicalStateRoot returns the root of the state tree holding the actors `actors`, each with a call sequence number
+// of zero, as the specs encode it: a HAMT mapping the bytes of each actor's address to its code, the CID of its state,
+// its call sequence number and its balance. It is built in a store of its own, independent of the implementation, so
+// it is the root an implementation must construct from the same actors.
+func CanonicalStateRoot(actors []ActorState) (cid.Cid, error) {
+	store := newMockStore()
+	tree := adt_spec.MakeEmptyMap(store)
+	for _, acts := range actors {
+		head, err := store.Put(store.Context(), acts.State)
+		if err != nil {
+			return cid.Undef, fmt.Errorf("putting state of actor at %s: %w", acts.Addr, err)
+		}
+		entry := &stateTreeActor{Code: acts.Code, Head: head, Balance: acts.Balance}
+		if err := tree.Put(adt_spec.AddrKey(acts.Addr), entry); err != nil {
+			return cid.Undef, fmt.Errorf("putting actor at %s: %w", acts.Addr, err)
+		}
+	}
+	return tree.Root()
+}
+
+// stateTreeActor is an actor as it is encoded in the state tree.
+type stateTreeActor struct {
+	Code       cid.Cid
+	Head       cid.Cid
+	CallSeqNum uint64
+	Balance    abi_spec.TokenAmount
+}
+
+// MarshalCBOR encodes the actor as a tuple of its fields, in order, as lotus does.
+func (t *stateTreeActor) MarshalCBOR(w io.Writer) error {
+	if _, err := w.Write([]byte{132}); err != nil {
+		return err
+	}
+	if err := cbg.WriteCid(w, t.Code); err != nil {
+		return fmt.Errorf("failed to write cid field t.Code: %w", err)
+	}
+	if err := cbg.WriteCid(w, t.Head); err != nil {
+		return fmt.Errorf("failed to write cid field t.Head: %w", err)
+	}
+	if _, err := w.Write(cbg.CborEncodeMajorType(cbg.MajUnsignedInt, t.CallSeqNum)); err != nil {
+		return err
+	}
+	return t.Balance.MarshalCBOR(w)
+}
@@ -0,0 +1,88 @@
+package drivers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/tracker"
+)
+
+// TestID returns the identifier of a test in known failure lists: its name below the implementation's top-level test,
+// e.g. "MessageTest_Paych/happy path".
+func TestID(t testing.TB) string {
+	tokens := strings.SplitN(t.Name(), "/", 2)
+	return tokens[len(tokens)-1]
+}
+
+// knownFailureReason returns the reason `cfg` gives for the test `t` failing, if it is a known failure. A known
+// failure listed for a test applies to its subtests too.
+func knownFailureReason(t testing.TB, cfg state.ValidationConfig) (string, bool) {
+	id := TestID(t)
+	for known, reason := range cfg.KnownFailures() {
+		if id == known || strings.HasPrefix(id, known+"/") {
+			return reason, true
+		}
+	}
+	return "", false
+}
+
+// knownFailureTB wraps the testing.TB of a known failing test, logging its failures rather than failing it. The test
+// is skipped once it fails, so that the run reports it as an expected failure.
+type knownFailureTB struct {
+	testing.TB
+	reason string
+	failed bool
+}
+
+func (k *knownFailureTB) Fail() {
+	k.TB.Helper()
+	k.failed = true
+}
+
+func (k *knownFailureTB) FailNow() {
+	k.TB.Helper()
+	k.failed = true
+	k.TB.Skipf("known failure: %s", k.reason)
+}
+
+func (k *knownFailureTB) Failed() bool {
+	return k.failed || k.TB.Failed()
+}
+
+func (k *knownFailureTB) Error(args ...interface{}) {
+	k.TB.Helper()
+	k.TB.Log(append([]interface{}{"known failure:"}, args...)...)
+	k.failed = true
+}
+
+func (k *knownFailureTB) Errorf(format string, args ...interface{}) {
+	k.TB.Helper()
+	k.TB.Logf("known failure: "+format, args...)
+	k.failed = true
+}
+
+func (k *knownFailureTB) Fatal(args ...interface{}) {
+	k.TB.Helper()
+	k.Error(args...)
+	k.FailNow()
+}
+
+func (k *knownFailureTB) Fatalf(format string, args ...interface{}) {
+	k.TB.Helper()
+	k.Errorf(format, args...)
+	k.FailNow()
+}
+
+// complete skips the test if it failed as expected, and otherwise reports that it should be removed from the list.
+func (k *knownFailureTB) complete() {
+	if k.TB.Skipped() {
+		// Skipped by FailNow already.
+		return
+	}
+	if k.failed {
+		k.TB.Skipf("known failure: %s", k.reason)
+	}
+	tracker.ReportSoftFailure(k.TB, tracker.UnexpectedPass, fmt.Sprintf("known failure %q passed, remove it from the list (listed as: %s)", TestID(k.TB), k.reason))
+}
@@ -0,0 +1,117 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	market_spec "github.com/filecoin-project/specs-actors/actors/builtin/market"
+	runtime_spec "github.com/filecoin-project/specs-actors/actors/runtime"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	cid "github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MarketStateChecker makes assertions against the state of the storage market actor, as decoded when the checker
+// was created. Assertions return the checker so that they may be chained.
+type MarketStateChecker struct {
+	td *TestDriver
+	st market_spec.State
+}
+
+// Market decodes the current state of the storage market actor.
+func (td *TestDriver) Market() *MarketStateChecker {
+	c := &MarketStateChecker{td: td}
+	td.GetActorState(builtin_spec.StorageMarketActorAddr, &c.st)
+	return c
+}
+
+// State returns the decoded market state.
+func (c *MarketStateChecker) State() *market_spec.State {
+	return &c.st
+}
+
+// Escrow returns the escrow balance of `addr`, zero if it has none.
+func (c *MarketStateChecker) Escrow(addr address.Address) abi_spec.TokenAmount {
+	return c.balance(c.st.EscrowTable, addr)
+}
+
+// Locked returns the locked balance of `addr`, zero if it has none.
+func (c *MarketStateChecker) Locked(addr address.Address) abi_spec.TokenAmount {
+	return c.balance(c.st.LockedTable, addr)
+}
+
+// DealProposal returns the proposal of the deal with `dealID`, and whether it exists.
+func (c *MarketStateChecker) DealProposal(dealID abi_spec.DealID) (*market_spec.DealProposal, bool) {
+	var proposal market_spec.DealProposal
+	found := c.arrayGet(c.st.Proposals, uint64(dealID), &proposal)
+	return &proposal, found
+}
+
+// DealState returns the state of the deal with `dealID`, and whether it exists.
+func (c *MarketStateChecker) DealState(dealID abi_spec.DealID) (*market_spec.DealState, bool) {
+	var dealState market_spec.DealState
+	found := c.arrayGet(c.st.States, uint64(dealID), &dealState)
+	return &dealState, found
+}
+
+// AssertEscrow asserts the escrow balance of `addr`.
+func (c *MarketStateChecker) AssertEscrow(addr address.Address, expected abi_spec.TokenAmount) *MarketStateChecker {
+	assert.Equal(c.td.T, expected, c.Escrow(addr), "escrow balance of %s", addr)
+	return c
+}
+
+// AssertLocked asserts the locked balance of `addr`.
+func (c *MarketStateChecker) AssertLocked(addr address.Address, expected abi_spec.TokenAmount) *MarketStateChecker {
+	assert.Equal(c.td.T, expected, c.Locked(addr), "locked balance of %s", addr)
+	return c
+}
+
+// AssertNextDealID asserts the ID the next published deal will receive.
+func (c *MarketStateChecker) AssertNextDealID(expected abi_spec.DealID) *MarketStateChecker {
+	assert.Equal(c.td.T, expected, c.st.NextID, "next deal ID")
+	return c
+}
+
+// AssertDealProposal asserts the proposal of the deal with `dealID`.
+func (c *MarketStateChecker) AssertDealProposal(dealID abi_spec.DealID, expected market_spec.DealProposal) *MarketStateChecker {
+	proposal, found := c.DealProposal(dealID)
+	if assert.True(c.td.T, found, "no proposal for deal %d", dealID) {
+		assert.Equal(c.td.T, expected, *proposal, "proposal of deal %d", dealID)
+	}
+	return c
+}
+
+// AssertDealState asserts the state of the deal with `dealID`.
+func (c *MarketStateChecker) AssertDealState(dealID abi_spec.DealID, expected market_spec.DealState) *MarketStateChecker {
+	dealState, found := c.DealState(dealID)
+	if assert.True(c.td.T, found, "no state for deal %d", dealID) {
+		assert.Equal(c.td.T, expected, *dealState, "state of deal %d", dealID)
+	}
+	return c
+}
+
+// AssertNoDeal asserts that neither a proposal nor a state exists for the deal with `dealID`.
+func (c *MarketStateChecker) AssertNoDeal(dealID abi_spec.DealID) *MarketStateChecker {
+	_, found := c.DealProposal(dealID)
+	assert.False(c.td.T, found, "unexpected proposal for deal %d", dealID)
+	_, found = c.DealState(dealID)
+	assert.False(c.td.T, found, "unexpected state for deal %d", dealID)
+	return c
+}
+
+func (c *MarketStateChecker) balance(table cid.Cid, addr address.Address) abi_spec.TokenAmount {
+	bt, err := adt_spec.AsBalanceTable(AsStore(c.td.State()), table)
+	require.NoError(c.td.T, err)
+	amt, err := bt.Get(addr)
+	require.NoError(c.td.T, err)
+	return amt
+}
+
+func (c *MarketStateChecker) arrayGet(root cid.Cid, key uint64, out runtime_spec.CBORUnmarshaler) bool {
+	arr, err := adt_spec.AsArray(AsStore(c.td.State()), root)
+	require.NoError(c.td.T, err)
+	found, err := arr.Get(key, out)
+	require.NoError(c.td.T, err)
+	return found
+}
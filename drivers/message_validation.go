@@ -0,0 +1,44 @@
+package drivers
+
+import (
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// ValidateForBlock signs `msg` and the messages `prior` with the keys of their senders, and returns the
+// implementation's verdict on whether `msg` may be included in a block after `prior`. The state is checked to be
+// unchanged. The test is skipped if the implementation doesn't validate messages apart from applying them.
+func (td *TestDriver) ValidateForBlock(prior []*types.Message, msg *types.Message) error {
+	td.checkContext()
+	signed := make([]*types.SignedMessage, len(prior))
+	for i, m := range prior {
+		signed[i] = td.SignMessage(m.From, m)
+	}
+	return td.ValidateSignedForBlock(signed, td.SignMessage(msg.From, msg))
+}
+
+// ValidateSignedForBlock is ValidateForBlock for messages already signed.
+func (td *TestDriver) ValidateSignedForBlock(prior []*types.SignedMessage, smsg *types.SignedMessage) error {
+	td.checkContext()
+	if !td.Config.Capabilities().MessageValidation {
+		td.T.Skip("implementation doesn't support message validation")
+	}
+	prevRoot := td.State().Root()
+	ok, err := td.validator.ValidateMessageForBlock(td.executionContext(), prior, smsg)
+	if !ok {
+		td.T.Skip("implementation doesn't support message validation")
+	}
+	assert.Equal(td.T, prevRoot, td.State().Root(), "validating a message changed the state")
+	return err
+}
+
+// AssertValidForBlock asserts that `msg` may be included in a block after `prior`.
+func (td *TestDriver) AssertValidForBlock(prior []*types.Message, msg *types.Message) {
+	assert.NoError(td.T, td.ValidateForBlock(prior, msg), "message %d from %s", msg.CallSeqNum, msg.From)
+}
+
+// AssertInvalidForBlock asserts that `msg` may not be included in a block after `prior`, for the reason `why`.
+func (td *TestDriver) AssertInvalidForBlock(prior []*types.Message, msg *types.Message, why string) {
+	assert.Error(td.T, td.ValidateForBlock(prior, msg), "message %d from %s: %s", msg.CallSeqNum, msg.From, why)
+}
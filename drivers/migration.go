@@ -0,0 +1,62 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// migratedActor is what a state migration must preserve of an actor.
+type migratedActor struct {
+	kind       ActorKind
+	callSeqNum uint64
+	balance    abi_spec.TokenAmount
+}
+
+// Migrate migrates the state tree to network version `to`, as at the network upgrade to it, and moves the driver to
+// that version, so that ActorCode returns the code of the migrated actors. The test is skipped if the implementation
+// doesn't support state migration.
+//
+// Every actor is checked to survive the migration at the same address, with the same call sequence number and
+// balance, and builtin actors to keep their kind with the code of the new actors version. Suites check the states of
+// the migrated actors themselves.
+func (td *TestDriver) Migrate(to NetworkVersion) {
+	mw, ok := td.State().(state.MigratingVMWrapper)
+	if !ok || !td.Config.Capabilities().StateMigration {
+		td.T.Skip("implementation doesn't support state migration")
+	}
+	require.True(td.T, to > td.networkVersion, "can't migrate from network version %d to %d", td.networkVersion, to)
+
+	before := make(map[address.Address]migratedActor)
+	for k := range td.actorEntries(mw.Root()) {
+		addr := td.actorEntryAddress(k)
+		act, err := mw.Actor(addr)
+		require.NoError(td.T, err)
+		kind, _, _ := KindOf(act.Code())
+		before[addr] = migratedActor{kind: kind, callSeqNum: act.CallSeqNum(), balance: act.Balance()}
+	}
+
+	root, err := mw.Migrate(uint(to))
+	require.NoError(td.T, err, "migrating to network version %d", to)
+	require.Equal(td.T, root, mw.Root(), "migrated state root")
+	td.networkVersion = to
+
+	after := td.actorEntries(root)
+	assert.Equal(td.T, len(before), len(after), "number of actors after migration")
+	for addr, b := range before {
+		act, err := mw.Actor(addr)
+		if !assert.NoError(td.T, err, "actor %s after migration", addr) {
+			continue
+		}
+		if b.kind != "" {
+			assert.Equal(td.T, td.ActorCode(b.kind), act.Code(), "code of %s actor %s", b.kind, addr)
+		}
+		assert.Equal(td.T, b.callSeqNum, act.CallSeqNum(), "call sequence number of actor %s", addr)
+		assert.True(td.T, big_spec.Cmp(b.balance, act.Balance()) == 0, "balance of actor %s: expected %s, actual %s",
+			addr, b.balance, act.Balance())
+	}
+}
@@ -0,0 +1,132 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-bitfield"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Upper bound on sector numbers when expanding bitfields for comparison.
+const maxComparedSectorNumber = 1 << 20
+
+// MinerStateChecker makes assertions against the state of a miner actor, as decoded when the checker was
+// created. Assertions return the checker so that they may be chained.
+type MinerStateChecker struct {
+	td   *TestDriver
+	addr address.Address
+	st   miner_spec.State
+}
+
+// Miner decodes the current state of the miner actor at `addr`.
+func (td *TestDriver) Miner(addr address.Address) *MinerStateChecker {
+	c := &MinerStateChecker{td: td, addr: addr}
+	td.GetActorState(addr, &c.st)
+	return c
+}
+
+// State returns the decoded miner state.
+func (c *MinerStateChecker) State() *miner_spec.State {
+	return &c.st
+}
+
+// Info returns the decoded miner info.
+func (c *MinerStateChecker) Info() *miner_spec.MinerInfo {
+	var info miner_spec.MinerInfo
+	c.td.GetState(c.st.Info, &info)
+	return &info
+}
+
+// Sector returns the on-chain info of the miner's committed sector `num`, failing the test if there is none.
+func (c *MinerStateChecker) Sector(num abi_spec.SectorNumber) *miner_spec.SectorOnChainInfo {
+	info, found, err := c.st.GetSector(AsStore(c.td.State()), num)
+	require.NoError(c.td.T, err)
+	require.True(c.td.T, found, "miner %s has no sector %d", c.addr, num)
+	return info
+}
+
+// AssertSectorCount asserts the number of sectors committed by the miner.
+func (c *MinerStateChecker) AssertSectorCount(n uint64) *MinerStateChecker {
+	sectors, err := adt_spec.AsArray(AsStore(c.td.State()), c.st.Sectors)
+	require.NoError(c.td.T, err)
+	assert.Equal(c.td.T, n, sectors.Length(), "miner %s sector count", c.addr)
+	return c
+}
+
+// AssertFaults asserts the union of faulty sectors across all of the miner's partitions.
+func (c *MinerStateChecker) AssertFaults(expected bitfield.BitField) *MinerStateChecker {
+	store := AsStore(c.td.State())
+	deadlines, err := c.st.LoadDeadlines(store)
+	require.NoError(c.td.T, err)
+
+	faults := bitfield.New()
+	err = deadlines.ForEach(store, func(_ uint64, dl *miner_spec.Deadline) error {
+		partitions, err := dl.PartitionsArray(store)
+		if err != nil {
+			return err
+		}
+		var partition miner_spec.Partition
+		return partitions.ForEach(&partition, func(_ int64) error {
+			faults, err = bitfield.MergeBitFields(faults, partition.Faults)
+			return err
+		})
+	})
+	require.NoError(c.td.T, err)
+
+	expectedSectors, err := expected.All(maxComparedSectorNumber)
+	require.NoError(c.td.T, err)
+	actualSectors, err := faults.All(maxComparedSectorNumber)
+	require.NoError(c.td.T, err)
+	assert.Equal(c.td.T, expectedSectors, actualSectors, "miner %s faults", c.addr)
+	return c
+}
+
+// AssertLockedFunds asserts the miner's funds locked for vesting.
+func (c *MinerStateChecker) AssertLockedFunds(expected abi_spec.TokenAmount) *MinerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.LockedFunds, "miner %s locked funds", c.addr)
+	return c
+}
+
+// AssertPreCommitDeposits asserts the miner's total pre-commit deposits.
+func (c *MinerStateChecker) AssertPreCommitDeposits(expected abi_spec.TokenAmount) *MinerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.PreCommitDeposits, "miner %s pre-commit deposits", c.addr)
+	return c
+}
+
+// AssertInitialPledge asserts the sum of the initial pledge requirements of the miner's active sectors.
+func (c *MinerStateChecker) AssertInitialPledge(expected abi_spec.TokenAmount) *MinerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.InitialPledgeRequirement, "miner %s initial pledge", c.addr)
+	return c
+}
+
+// VestingFunds returns the miner's schedule of locked funds vesting, in order of epoch.
+func (c *MinerStateChecker) VestingFunds() []miner_spec.VestingFund {
+	vf, err := c.st.LoadVestingFunds(AsStore(c.td.State()))
+	require.NoError(c.td.T, err)
+	return vf.Funds
+}
+
+// AssertDeadlineInfo asserts the miner's deadline info at the driver's current epoch.
+func (c *MinerStateChecker) AssertDeadlineInfo(expected *miner_spec.DeadlineInfo) *MinerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.DeadlineInfo(c.td.CurrentEpoch()), "miner %s deadline info", c.addr)
+	return c
+}
+
+// AssertSectorCommitted asserts whether the miner has committed sector `num`.
+func (c *MinerStateChecker) AssertSectorCommitted(num abi_spec.SectorNumber, expected bool) *MinerStateChecker {
+	found, err := c.st.HasSectorNo(AsStore(c.td.State()), num)
+	require.NoError(c.td.T, err)
+	assert.Equal(c.td.T, expected, found, "miner %s sector %d committed", c.addr, num)
+	return c
+}
+
+// AssertSectorPreCommitted asserts whether the miner has pre-committed sector `num` without yet proving it.
+func (c *MinerStateChecker) AssertSectorPreCommitted(num abi_spec.SectorNumber, expected bool) *MinerStateChecker {
+	_, found, err := c.st.GetPrecommittedSector(AsStore(c.td.State()), num)
+	require.NoError(c.td.T, err)
+	assert.Equal(c.td.T, expected, found, "miner %s sector %d pre-committed", c.addr, num)
+	return c
+}
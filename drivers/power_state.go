@@ -0,0 +1,96 @@
+package drivers
+
+import (
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	power_spec "github.com/filecoin-project/specs-actors/actors/builtin/power"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// PowerStateChecker makes assertions against the state of the storage power actor, as decoded when the checker
+// was created. Assertions return the checker so that they may be chained.
+type PowerStateChecker struct {
+	td *TestDriver
+	st power_spec.State
+}
+
+// Power decodes the current state of the storage power actor.
+func (td *TestDriver) Power() *PowerStateChecker {
+	c := &PowerStateChecker{td: td}
+	td.GetActorState(builtin_spec.StoragePowerActorAddr, &c.st)
+	return c
+}
+
+// State returns the decoded power state.
+func (c *PowerStateChecker) State() *power_spec.State {
+	return &c.st
+}
+
+// Claim returns the claim of `miner`, and whether one exists.
+func (c *PowerStateChecker) Claim(miner address.Address) (*power_spec.Claim, bool) {
+	claims, err := adt_spec.AsMap(AsStore(c.td.State()), c.st.Claims)
+	require.NoError(c.td.T, err)
+
+	var claim power_spec.Claim
+	found, err := claims.Get(adt_spec.AddrKey(miner), &claim)
+	require.NoError(c.td.T, err)
+	return &claim, found
+}
+
+// AssertTotalRawPower asserts the network's total raw byte power.
+func (c *PowerStateChecker) AssertTotalRawPower(expected abi_spec.StoragePower) *PowerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.TotalRawBytePower, "total raw byte power")
+	return c
+}
+
+// AssertTotalQualityAdjPower asserts the network's total quality adjusted power.
+func (c *PowerStateChecker) AssertTotalQualityAdjPower(expected abi_spec.StoragePower) *PowerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.TotalQualityAdjPower, "total quality adjusted power")
+	return c
+}
+
+// AssertTotalBytesCommitted asserts the raw byte and quality adjusted power committed by all miners, whether or not
+// they meet the consensus minimum power.
+func (c *PowerStateChecker) AssertTotalBytesCommitted(raw, qa abi_spec.StoragePower) *PowerStateChecker {
+	assert.Equal(c.td.T, raw, c.st.TotalBytesCommitted, "total raw bytes committed")
+	assert.Equal(c.td.T, qa, c.st.TotalQABytesCommitted, "total quality adjusted bytes committed")
+	return c
+}
+
+// AssertMinerAboveMinPowerCount asserts the number of miners whose claims meet the consensus minimum power.
+func (c *PowerStateChecker) AssertMinerAboveMinPowerCount(n int64) *PowerStateChecker {
+	assert.Equal(c.td.T, n, c.st.MinerAboveMinPowerCount, "miners above consensus minimum power")
+	return c
+}
+
+// AssertTotalPledgeCollateral asserts the network's total pledge collateral.
+func (c *PowerStateChecker) AssertTotalPledgeCollateral(expected abi_spec.TokenAmount) *PowerStateChecker {
+	assert.Equal(c.td.T, expected, c.st.TotalPledgeCollateral, "total pledge collateral")
+	return c
+}
+
+// AssertMinerCount asserts the number of miners registered with the power actor.
+func (c *PowerStateChecker) AssertMinerCount(n int64) *PowerStateChecker {
+	assert.Equal(c.td.T, n, c.st.MinerCount, "miner count")
+	return c
+}
+
+// AssertClaim asserts the raw byte and quality adjusted power claimed by `miner`.
+func (c *PowerStateChecker) AssertClaim(miner address.Address, raw, qa abi_spec.StoragePower) *PowerStateChecker {
+	claim, found := c.Claim(miner)
+	if assert.True(c.td.T, found, "no claim for miner %s", miner) {
+		assert.Equal(c.td.T, raw, claim.RawBytePower, "miner %s raw byte power", miner)
+		assert.Equal(c.td.T, qa, claim.QualityAdjPower, "miner %s quality adjusted power", miner)
+	}
+	return c
+}
+
+// AssertNoClaim asserts that `miner` has no claim.
+func (c *PowerStateChecker) AssertNoClaim(miner address.Address) *PowerStateChecker {
+	_, found := c.Claim(miner)
+	assert.False(c.td.T, found, "unexpected claim for miner %s", miner)
+	return c
+}
@@ -0,0 +1,136 @@
+package drivers
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/report"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// ReceiptMatcher is the expected receipt of a message, accepted by ApplyMatching and ApplySignedMatching. It expects
+// exit code Ok and an empty return value unless configured otherwise, e.g.
+//
+//	td.ApplyMatching(msg, drivers.ExpectReceipt().WithReturn(&ret).WithGasBetween(1_000, 2_000))
+//
+// Each part of the receipt is only validated if the driver's config validates it.
+type ReceiptMatcher struct {
+	code   exitcode.ExitCode
+	class  *ExitCodeClass
+	retval []byte
+
+	gasRange     bool
+	gasLo, gasHi types.GasUnits
+}
+
+// ExpectReceipt returns a matcher of a receipt with exit code Ok and an empty return value.
+func ExpectReceipt() *ReceiptMatcher {
+	return &ReceiptMatcher{code: exitcode.Ok, retval: EmptyReturnValue}
+}
+
+// WithExitCode expects the receipt's exit code to be `code`.
+func (m *ReceiptMatcher) WithExitCode(code exitcode.ExitCode) *ReceiptMatcher {
+	m.code = code
+	m.class = nil
+	return m
+}
+
+// WithExitCodeClass expects the receipt's exit code to be of class `class`, for failures whose exact exit code isn't
+// normative.
+func (m *ReceiptMatcher) WithExitCodeClass(class ExitCodeClass) *ReceiptMatcher {
+	m.class = &class
+	return m
+}
+
+// WithReturn expects the receipt's return value to be the serialization of `obj`.
+func (m *ReceiptMatcher) WithReturn(obj cbg.CBORMarshaler) *ReceiptMatcher {
+	m.retval = chain.MustSerialize(obj)
+	return m
+}
+
+// WithReturnBytes expects the receipt's return value to be `retval`, which may be ExpectAnyReturn.
+func (m *ReceiptMatcher) WithReturnBytes(retval []byte) *ReceiptMatcher {
+	m.retval = retval
+	return m
+}
+
+// WithGasBetween expects the receipt's gas used to be between `lo` and `hi` inclusive, for a bound that holds for every
+// implementation in addition to the exact gas recorded for the message.
+func (m *ReceiptMatcher) WithGasBetween(lo, hi types.GasUnits) *ReceiptMatcher {
+	m.gasRange = true
+	m.gasLo, m.gasHi = lo, hi
+	return m
+}
+
+// receiptMismatch is a part of a receipt that doesn't match that expected.
+type receiptMismatch struct {
+	kind     report.Kind
+	name     string
+	expected interface{}
+	actual   interface{}
+}
+
+func (mm receiptMismatch) String() string {
+	kind := string(mm.kind)
+	if mm.name != "" {
+		kind += " " + mm.name
+	}
+	return fmt.Sprintf("%s: expected %v, actual %v", kind, mm.expected, mm.actual)
+}
+
+// mismatches returns the parts of `rct` that don't match `m`, among those validated by `cfg`.
+func (m *ReceiptMatcher) mismatches(cfg state.ValidationConfig, rct types.MessageReceipt) []receiptMismatch {
+	var mms []receiptMismatch
+	if cfg.ValidateExitCode() {
+		class := m.class
+		if class == nil && cfg.ValidateExitCodeClassOnly() {
+			c := ClassOf(m.code)
+			class = &c
+		}
+		if class != nil && !class.Matches(rct.ExitCode) {
+			mms = append(mms, receiptMismatch{report.ExitCode, "", class, rct.ExitCode})
+		} else if class == nil && rct.ExitCode != m.code {
+			mms = append(mms, receiptMismatch{report.ExitCode, "", m.code, rct.ExitCode})
+		}
+	}
+	if cfg.ValidateReturnValue() && !isAnyReturn(m.retval) && !bytes.Equal(m.retval, rct.ReturnValue) {
+		mms = append(mms, receiptMismatch{report.ReturnValue, "", fmt.Sprintf("%x", m.retval), fmt.Sprintf("%x", rct.ReturnValue)})
+	}
+	if cfg.ValidateReturnValueEncoding() && len(rct.ReturnValue) > 0 {
+		if err := chain.ValidateCanonicalCBOR(rct.ReturnValue); err != nil {
+			mms = append(mms, receiptMismatch{report.ReturnValueEncoding, "", "canonical CBOR", err})
+		}
+	}
+	if m.gasRange && cfg.ValidateGas() && (rct.GasUsed < m.gasLo || rct.GasUsed > m.gasHi) {
+		mms = append(mms, receiptMismatch{report.GasUsed, "range", fmt.Sprintf("[%d, %d]", m.gasLo, m.gasHi), rct.GasUsed})
+	}
+	return mms
+}
+
+// validateReceipt validates the receipt of the last message applied against `m`.
+func (td *TestDriver) validateReceipt(result types.ApplyMessageResult, m *ReceiptMatcher) {
+	for _, mm := range m.mismatches(td.Config, result.Receipt) {
+		assert.Fail(td.T, "receipt doesn't match expectation", mm.String())
+		td.reportFailure(false, mm.kind, td.applied-1, mm.name, mm.expected, mm.actual)
+	}
+}
+
+// ReceiptsRoot returns the root of the AMT of `receipts`, indexed in order, as a block header commits to the receipts
+// of its tipset's messages. It is built in a store of its own, independent of the implementation.
+func ReceiptsRoot(receipts []types.MessageReceipt) (cid.Cid, error) {
+	arr := adt_spec.MakeEmptyArray(newMockStore())
+	for i := range receipts {
+		if err := arr.Set(uint64(i), &receipts[i]); err != nil {
+			return cid.Undef, fmt.Errorf("setting receipt %d: %w", i, err)
+		}
+	}
+	return arr.Root()
+}
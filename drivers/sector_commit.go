@@ -0,0 +1,73 @@
+package drivers
+
+import (
+	"fmt"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+
+	"github.com/filecoin-project/chain-validation/chain/commitments"
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// Epochs at which PreCommitSector and ProveCommitSector onboard sectors.
+const (
+	SectorPreCommitEpoch = abi_spec.ChainEpoch(10)
+	// SectorProveCommitEpoch is the first epoch at which a sector pre-committed at SectorPreCommitEpoch may be proven.
+	SectorProveCommitEpoch = SectorPreCommitEpoch + miner_spec.PreCommitChallengeDelay + 1
+	// SectorExpiration is comfortably within the bounds on the lifetime of sectors pre-committed at
+	// SectorPreCommitEpoch.
+	SectorExpiration = SectorPreCommitEpoch + 200*builtin_spec.EpochsInDay
+)
+
+// SectorPreCommitInfo returns the pre-commit info of sector `num` of proof type `sealProof` holding the deals
+// `dealIDs`, sealed at the epoch before SectorPreCommitEpoch and expiring at SectorExpiration.
+func SectorPreCommitInfo(sealProof abi_spec.RegisteredSealProof, num abi_spec.SectorNumber, dealIDs ...abi_spec.DealID) *miner_spec.SectorPreCommitInfo {
+	return &miner_spec.SectorPreCommitInfo{
+		SealProof:     sealProof,
+		SectorNumber:  num,
+		SealedCID:     commitments.SealedCID(uint64(num)),
+		SealRandEpoch: SectorPreCommitEpoch - 1,
+		DealIDs:       dealIDs,
+		Expiration:    SectorExpiration,
+	}
+}
+
+// SectorProveCommitParams returns the parameters proving sector `num`, whose proof the driver's syscalls accept
+// unless told otherwise.
+func SectorProveCommitParams(num abi_spec.SectorNumber) *miner_spec.ProveCommitSectorParams {
+	return &miner_spec.ProveCommitSectorParams{
+		SectorNumber: num,
+		Proof:        []byte(fmt.Sprintf("proof of sector %d", num)),
+	}
+}
+
+// PreCommitSector sets the epoch to SectorPreCommitEpoch and pre-commits sector `num` of the miner at `minerAddr` from
+// its worker `worker`, as described by SectorPreCommitInfo. The driver must track nonces, see WithNonceTracking.
+func (td *TestDriver) PreCommitSector(worker, minerAddr address.Address, sealProof abi_spec.RegisteredSealProof, num abi_spec.SectorNumber, dealIDs ...abi_spec.DealID) {
+	td.SetEpoch(SectorPreCommitEpoch)
+	td.ApplyOk(td.MessageProducer.MinerPreCommitSector(worker, minerAddr, SectorPreCommitInfo(sealProof, num, dealIDs...)))
+	td.Miner(minerAddr).AssertSectorPreCommitted(num, true)
+}
+
+// ProveCommitSector sets the epoch to SectorProveCommitEpoch and proves sector `num` of the miner at `minerAddr` from
+// its worker `worker`, in a tipset at the end of which cron verifies the proof and confirms the sector. It returns
+// the message and the tipset's result. The driver must track nonces, see WithNonceTracking.
+func (td *TestDriver) ProveCommitSector(worker, minerAddr address.Address, num abi_spec.SectorNumber) (*types.Message, types.ApplyTipSetResult) {
+	td.SetEpoch(SectorProveCommitEpoch)
+	msg := td.MessageProducer.MinerProveCommitSector(worker, minerAddr, SectorProveCommitParams(num))
+	result := NewTipSetMessageBuilder(td).WithBlockBuilder(
+		NewBlockBuilder(td, td.ExeCtx.Miner).WithBLSMessageOk(msg),
+	).ApplyAndValidate()
+	td.Miner(minerAddr).AssertSectorCommitted(num, true)
+	return msg, result
+}
+
+// CommitSector pre-commits and proves sector `num` of the miner at `minerAddr`, see PreCommitSector and
+// ProveCommitSector.
+func (td *TestDriver) CommitSector(worker, minerAddr address.Address, sealProof abi_spec.RegisteredSealProof, num abi_spec.SectorNumber, dealIDs ...abi_spec.DealID) {
+	td.PreCommitSector(worker, minerAddr, sealProof, num, dealIDs...)
+	td.ProveCommitSector(worker, minerAddr, num)
+}
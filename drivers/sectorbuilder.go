@@ -1,15 +1,14 @@
 package drivers
 
 import (
-	"encoding/binary"
 	"testing"
 
 	"github.com/filecoin-project/go-address"
-	commcid "github.com/filecoin-project/go-fil-commcid"
 	"github.com/filecoin-project/specs-actors/actors/abi"
 	"github.com/filecoin-project/specs-actors/actors/abi/big"
 	"github.com/filecoin-project/specs-actors/actors/builtin/market"
 
+	"github.com/filecoin-project/chain-validation/chain/commitments"
 	"github.com/filecoin-project/chain-validation/chain/types"
 )
 
@@ -33,11 +32,8 @@ func (msb *MockSectorBuilder) NewPreSealedSector(miner, client address.Address,
 	minerSectors := msb.MinerSectors[miner]
 	sectorID := len(minerSectors)
 
-	token := make([]byte, 32)
-	binary.PutUvarint(token, msb.sectorSeq)
-	// the only error we could get is if token isn't 32 long, ignore
-	D, _ := commcid.DataCommitmentV1ToCID(token)
-	R, _ := commcid.ReplicaCommitmentV1ToCID(token)
+	D := commitments.UnsealedCID(msb.sectorSeq)
+	R := commitments.SealedCID(msb.sectorSeq)
 	msb.sectorSeq++
 
 	preseal := &types.PreSeal{
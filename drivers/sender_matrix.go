@@ -0,0 +1,91 @@
+package drivers
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// SenderVariant is a way of creating and addressing the SECP accounts of a suite, which most suites send from. Running
+// a suite under each variant covers senders of both key types, addressed by either their public key or ID address,
+// without the suite enumerating them itself.
+type SenderVariant struct {
+	// Protocol is the protocol of the accounts created in place of SECP accounts.
+	Protocol address.Protocol
+	// ByID is whether messages from the accounts are sent from their ID address rather than their public key address.
+	ByID bool
+}
+
+func (v SenderVariant) String() string {
+	name := "secp"
+	if v.Protocol == BLS {
+		name = "bls"
+	}
+	if v.ByID {
+		name += "-id"
+	}
+	return name
+}
+
+// SenderVariants lists the sender variants, the first being the default of SECP accounts addressed by public key.
+var SenderVariants = []SenderVariant{
+	{Protocol: SECP},
+	{Protocol: BLS},
+	{Protocol: SECP, ByID: true},
+	{Protocol: BLS, ByID: true},
+}
+
+// WithSenderVariant returns factories whose drivers create and address the SECP accounts of suites according to `v`.
+func WithSenderVariant(factory state.Factories, v SenderVariant) state.Factories {
+	return &senderVariantFactories{Factories: factory, variant: v}
+}
+
+// RunWithSenderVariants runs `tc` as a subtest of `t` under each sender variant other than the default, named after
+// the variant, e.g. "bls-id". Results are recorded and checked per variant, as their gas and state roots differ.
+func RunWithSenderVariants(t *testing.T, factory state.Factories, tc func(t *testing.T, factory state.Factories)) {
+	for _, v := range SenderVariants[1:] {
+		v := v
+		t.Run(v.String(), func(t *testing.T) {
+			tc(t, WithSenderVariant(factory, v))
+		})
+	}
+}
+
+type senderVariantFactories struct {
+	state.Factories
+	variant SenderVariant
+}
+
+var _ state.ParallelFactories = (*senderVariantFactories)(nil)
+
+func (f *senderVariantFactories) SupportsParallel() bool {
+	pf, ok := f.Factories.(state.ParallelFactories)
+	return ok && pf.SupportsParallel()
+}
+
+func (f *senderVariantFactories) unwrap() state.Factories {
+	return f.Factories
+}
+
+// applySenderVariant makes SECP accounts created from now on follow `v`, so that the builder's own accounts, such as
+// the default miner's owner, are the same under every variant. Under ByID, messages from any account created by the
+// driver are sent from its ID address.
+func (td *TestDriver) applySenderVariant(v SenderVariant) {
+	td.senderVariant = &v
+	if !v.ByID {
+		return
+	}
+	td.MessageProducer.MapSenders(func(from address.Address) address.Address {
+		if from.Protocol() == address.ID {
+			return from
+		}
+		for id, pubkey := range td.actorIDMap {
+			if pubkey == from {
+				return id
+			}
+		}
+		return from
+	})
+}
@@ -19,6 +19,7 @@ import (
 	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
 	account_spec "github.com/filecoin-project/specs-actors/actors/builtin/account"
 
+	"github.com/filecoin-project/chain-validation/chain"
 	"github.com/filecoin-project/chain-validation/state"
 	"github.com/filecoin-project/chain-validation/suites/utils"
 )
@@ -48,8 +49,17 @@ type StateDriver struct {
 
 	minerInfo *MinerInfo
 
+	// Named identities, created on first use.
+	identities *Wallet
+
 	// Mapping for IDAddresses to their pubkey/actor addresses. Used for lookup when signing messages.
 	actorIDMap map[address.Address]address.Address
+
+	// Start of the first proving period of miners created, nil for them to start at epoch 0 without a cron event.
+	provingPeriodStart *abi_spec.ChainEpoch
+
+	// Variant of the accounts created as SECP senders, nil for none.
+	senderVariant *SenderVariant
 }
 
 // info about the state drivers builtin miner
@@ -59,11 +69,14 @@ type MinerInfo struct {
 
 	Worker   address.Address
 	WorkerID address.Address
+
+	// Robust address of the miner actor itself, as assigned by the init actor.
+	Robust address.Address
 }
 
 // NewStateDriver creates a new state driver for a state.
 func NewStateDriver(tb testing.TB, st state.VMWrapper, w state.KeyManager) *StateDriver {
-	return &StateDriver{tb, st, w, NewRandomnessSource(), nil, make(map[address.Address]address.Address)}
+	return &StateDriver{tb, st, w, NewRandomnessSource(), nil, nil, make(map[address.Address]address.Address), nil, nil}
 }
 
 // State returns the state.
@@ -98,8 +111,12 @@ func (d *StateDriver) GetActorState(actorAddr address.Address, out cbg.CBORUnmar
 	d.GetState(actor.Head(), out)
 }
 
-// NewAccountActor installs a new account actor, returning the address.
+// NewAccountActor installs a new account actor, returning the address. Under a sender variant, SECP accounts are
+// created with the variant's protocol instead.
 func (d *StateDriver) NewAccountActor(addrType address.Protocol, balanceAttoFil abi_spec.TokenAmount) (pubkey address.Address, id address.Address) {
+	if d.senderVariant != nil && addrType == SECP {
+		addrType = d.senderVariant.Protocol
+	}
 	var addr address.Address
 	switch addrType {
 	case address.SECP256K1:
@@ -109,11 +126,29 @@ func (d *StateDriver) NewAccountActor(addrType address.Protocol, balanceAttoFil
 	default:
 		require.FailNowf(d.tb, "unsupported address", "protocol for account actor: %v", addrType)
 	}
+	return addr, d.createAccountActor(addr, balanceAttoFil)
+}
 
+// NewAccountActorFromSeed creates an account actor like NewAccountActor, with a key derived from `seed`. Its public key
+// address is the same in every run and implementation.
+func (d *StateDriver) NewAccountActorFromSeed(addrType address.Protocol, seed []byte, balanceAttoFil abi_spec.TokenAmount) (pubkey address.Address, id address.Address) {
+	var addr address.Address
+	switch addrType {
+	case address.SECP256K1:
+		addr = d.w.NewSECP256k1AccountKeyFromSeed(seed)
+	case address.BLS:
+		addr = d.w.NewBLSAccountKeyFromSeed(seed)
+	default:
+		require.FailNowf(d.tb, "unsupported address", "protocol for account actor: %v", addrType)
+	}
+	return addr, d.createAccountActor(addr, balanceAttoFil)
+}
+
+func (d *StateDriver) createAccountActor(addr address.Address, balanceAttoFil abi_spec.TokenAmount) address.Address {
 	_, idAddr, err := d.st.CreateActor(builtin_spec.AccountActorCodeID, addr, balanceAttoFil, &account_spec.State{Address: addr})
 	require.NoError(d.tb, err)
 	d.actorIDMap[idAddr] = addr
-	return addr, idAddr
+	return idAddr
 }
 
 func (d *StateDriver) ActorPubKey(idAddress address.Address) address.Address {
@@ -131,19 +166,134 @@ func (d *StateDriver) BuiltinMinerInfo() *MinerInfo {
 	return d.minerInfo
 }
 
-// create miner without sending a message. modify the init and power actor manually
-func (d *StateDriver) newMinerAccountActor(sealProofType abi_spec.RegisteredSealProof, periodBoundary abi_spec.ChainEpoch) address.Address {
+// NewMinerActor creates a miner actor with no power alongside the builtin miner, for tests needing miners of another
+// seal proof type. Its owner is a new SECP account holding `ownerBalance`, and its worker a new BLS account. The miner
+// is registered with the power actor, so that it may commit sectors. It returns the miner's ID address and its owner
+// and worker accounts.
+func (d *StateDriver) NewMinerActor(sealProof abi_spec.RegisteredSealProof, ownerBalance abi_spec.TokenAmount, peerID abi_spec.PeerID, multiaddrs []abi_spec.Multiaddrs) (address.Address, *MinerInfo) {
+	id, info := d.newMinerActor(sealProof, ownerBalance, peerID, multiaddrs)
+	d.registerMinerClaim(id)
+	return id, info
+}
+
+// NewDefaultMinerActor creates a miner actor of the test seal proof type and without addresses, for tests needing
+// blocks mined by more than one miner. Unlike NewMinerActor, it records no claim with the power actor, so the miner
+// has none until one is recorded, e.g. by the genesis builder. With WithProvingPeriodStart, its proving deadline cron
+// event is still enrolled in the power actor's cron queue.
+func (d *StateDriver) NewDefaultMinerActor() (address.Address, *MinerInfo) {
+	return d.newMinerActor(TestSealProofType, big_spec.NewInt(1_000_000_000), abi_spec.PeerID("chain-validation"), nil)
+}
+
+func (d *StateDriver) newMinerActor(sealProof abi_spec.RegisteredSealProof, ownerBalance abi_spec.TokenAmount, peerID abi_spec.PeerID, multiaddrs []abi_spec.Multiaddrs) (address.Address, *MinerInfo) {
+	if d.provingPeriodStart == nil {
+		return d.newMinerAccountActor(sealProof, ownerBalance, peerID, multiaddrs, abi_spec.ChainEpoch(0))
+	}
+	start := *d.provingPeriodStart
+	id, info := d.newMinerAccountActor(sealProof, ownerBalance, peerID, multiaddrs, start)
+	d.enrollProvingDeadlineCron(id, start-1)
+	return id, info
+}
+
+// registerMinerClaim records an empty claim for the miner with the power actor, as its CreateMiner method would.
+func (d *StateDriver) registerMinerClaim(minerID address.Address) {
+	var spa power_spec.State
+	d.GetActorState(builtin_spec.StoragePowerActorAddr, &spa)
+	claims, err := adt_spec.AsMap(AsStore(d.State()), spa.Claims)
+	require.NoError(d.tb, err)
+	require.NoError(d.tb, claims.Put(adt_spec.AddrKey(minerID), &power_spec.Claim{
+		RawBytePower:    abi_spec.NewStoragePower(0),
+		QualityAdjPower: abi_spec.NewStoragePower(0),
+	}))
+	spa.Claims, err = claims.Root()
+	require.NoError(d.tb, err)
+	spa.MinerCount++
+	powerAct, err := d.State().Actor(builtin_spec.StoragePowerActorAddr)
+	require.NoError(d.tb, err)
+	_, err = d.State().SetActorState(builtin_spec.StoragePowerActorAddr, powerAct.Balance(), &spa)
+	require.NoError(d.tb, err)
+}
+
+// AddMinerClaim adds `raw` and `qa` power to the claim of a registered miner, as the power actor does for sectors the
+// miner proves, without committing any sectors. It places a miner near the consensus minimum power without the many
+// sectors that would take. The network's total power counts a miner's claim only while it meets the minimum.
+func (d *StateDriver) AddMinerClaim(minerID address.Address, raw, qa abi_spec.StoragePower) {
+	var spa power_spec.State
+	d.GetActorState(builtin_spec.StoragePowerActorAddr, &spa)
+	claims, err := adt_spec.AsMap(AsStore(d.State()), spa.Claims)
+	require.NoError(d.tb, err)
+	var prev power_spec.Claim
+	found, err := claims.Get(adt_spec.AddrKey(minerID), &prev)
+	require.NoError(d.tb, err)
+	require.True(d.tb, found, "miner %s has no claim", minerID)
+
+	next := power_spec.Claim{
+		RawBytePower:    big_spec.Add(prev.RawBytePower, raw),
+		QualityAdjPower: big_spec.Add(prev.QualityAdjPower, qa),
+	}
+	spa.TotalBytesCommitted = big_spec.Add(spa.TotalBytesCommitted, raw)
+	spa.TotalQABytesCommitted = big_spec.Add(spa.TotalQABytesCommitted, qa)
+
+	prevBelow := prev.QualityAdjPower.LessThan(power_spec.ConsensusMinerMinPower)
+	nextBelow := next.QualityAdjPower.LessThan(power_spec.ConsensusMinerMinPower)
+	switch {
+	case prevBelow && !nextBelow:
+		spa.MinerAboveMinPowerCount++
+		spa.TotalRawBytePower = big_spec.Add(spa.TotalRawBytePower, next.RawBytePower)
+		spa.TotalQualityAdjPower = big_spec.Add(spa.TotalQualityAdjPower, next.QualityAdjPower)
+	case !prevBelow && nextBelow:
+		spa.MinerAboveMinPowerCount--
+		spa.TotalRawBytePower = big_spec.Sub(spa.TotalRawBytePower, prev.RawBytePower)
+		spa.TotalQualityAdjPower = big_spec.Sub(spa.TotalQualityAdjPower, prev.QualityAdjPower)
+	case !prevBelow && !nextBelow:
+		spa.TotalRawBytePower = big_spec.Add(spa.TotalRawBytePower, raw)
+		spa.TotalQualityAdjPower = big_spec.Add(spa.TotalQualityAdjPower, qa)
+	}
+
+	require.NoError(d.tb, claims.Put(adt_spec.AddrKey(minerID), &next))
+	spa.Claims, err = claims.Root()
+	require.NoError(d.tb, err)
+	powerAct, err := d.State().Actor(builtin_spec.StoragePowerActorAddr)
+	require.NoError(d.tb, err)
+	_, err = d.State().SetActorState(builtin_spec.StoragePowerActorAddr, powerAct.Balance(), &spa)
+	require.NoError(d.tb, err)
+}
+
+// enrollProvingDeadlineCron enrolls the cron event with which the miner constructor starts a miner's proving
+// periods, at `epoch`, the epoch before its first period starts.
+func (d *StateDriver) enrollProvingDeadlineCron(minerID address.Address, epoch abi_spec.ChainEpoch) {
+	payload, err := chain.Serialize(&miner_spec.CronEventPayload{EventType: miner_spec.CronEventProvingDeadline})
+	require.NoError(d.tb, err)
+
+	var spa power_spec.State
+	d.GetActorState(builtin_spec.StoragePowerActorAddr, &spa)
+	queue, err := adt_spec.AsMultimap(AsStore(d.State()), spa.CronEventQueue)
+	require.NoError(d.tb, err)
+	require.NoError(d.tb, queue.Add(adt_spec.IntKey(int64(epoch)), &power_spec.CronEvent{
+		MinerAddr:       minerID,
+		CallbackPayload: payload,
+	}))
+	spa.CronEventQueue, err = queue.Root()
+	require.NoError(d.tb, err)
+	powerAct, err := d.State().Actor(builtin_spec.StoragePowerActorAddr)
+	require.NoError(d.tb, err)
+	_, err = d.State().SetActorState(builtin_spec.StoragePowerActorAddr, powerAct.Balance(), &spa)
+	require.NoError(d.tb, err)
+}
+
+// create miner without sending a message. modify the init actor manually; the power actor is left to registerMinerClaim
+func (d *StateDriver) newMinerAccountActor(sealProofType abi_spec.RegisteredSealProof, ownerBalance abi_spec.TokenAmount, peerID abi_spec.PeerID, multiaddrs []abi_spec.Multiaddrs, periodBoundary abi_spec.ChainEpoch) (address.Address, *MinerInfo) {
 	// creat a miner, owner, and its worker
-	minerOwnerPk, minerOwnerID := d.NewAccountActor(address.SECP256K1, big_spec.NewInt(1_000_000_000))
+	minerOwnerPk, minerOwnerID := d.NewAccountActor(address.SECP256K1, ownerBalance)
 	minerWorkerPk, minerWorkerID := d.NewAccountActor(address.BLS, big_spec.Zero())
 	expectedMinerActorIDAddress := utils.NewIDAddr(d.tb, utils.IdFromAddress(minerWorkerID)+1)
 	minerActorAddrs := computeInitActorExecReturn(d.tb, minerWorkerPk, 0, 1, expectedMinerActorIDAddress)
 
-	d.minerInfo = &MinerInfo{
+	info := &MinerInfo{
 		Owner:    minerOwnerPk,
 		OwnerID:  minerOwnerID,
 		Worker:   minerWorkerPk,
 		WorkerID: minerWorkerID,
+		Robust:   minerActorAddrs.RobustAddress,
 	}
 
 	ss, err := sealProofType.SectorSize()
@@ -154,8 +304,8 @@ func (d *StateDriver) newMinerAccountActor(sealProofType abi_spec.RegisteredSeal
 		Owner:                      minerOwnerID,
 		Worker:                     minerWorkerID,
 		PendingWorkerKey:           nil,
-		PeerId:                     abi_spec.PeerID("chain-validation"),
-		Multiaddrs:                 nil,
+		PeerId:                     peerID,
+		Multiaddrs:                 multiaddrs,
 		SealProofType:              sealProofType,
 		SectorSize:                 ss,
 		WindowPoStPartitionSectors: ps,
@@ -164,41 +314,14 @@ func (d *StateDriver) newMinerAccountActor(sealProofType abi_spec.RegisteredSeal
 	require.NoError(d.tb, err)
 
 	// create the miner actor s.t. it exists in the init actors map
-	minerState, err := miner_spec.ConstructState(mc, periodBoundary, EmptyBitfieldCid, EmptyArrayCid, EmptyMapCid, EmptyDeadlinesCid, EmptyVestingFundsCid)
+	minerState, err := miner_spec.ConstructState(mc, periodBoundary, emptyRoots.Bitfield, emptyRoots.Array, emptyRoots.Map, emptyRoots.Deadlines, emptyRoots.VestingFunds)
 	require.NoError(d.tb, err)
 
 	_, minerActorIDAddr, err := d.State().CreateActor(builtin_spec.StorageMinerActorCodeID, minerActorAddrs.RobustAddress, big_spec.Zero(), minerState)
 	require.NoError(d.tb, err)
 	require.Equal(d.tb, expectedMinerActorIDAddress, minerActorIDAddr)
 
-	// a miner actor has been created, exists in the state tree, and has an entry in the init actor.
-	// next update the storage power actor to track the miner
-
-	var spa power_spec.State
-	d.GetActorState(builtin_spec.StoragePowerActorAddr, &spa)
-
-	// set the miners claim
-	hm, err := adt_spec.AsMap(AsStore(d.State()), spa.Claims)
-	require.NoError(d.tb, err)
-
-	// add claim for the miner
-	err = hm.Put(adt_spec.AddrKey(minerActorIDAddr), &power_spec.Claim{
-		RawBytePower:    abi_spec.NewStoragePower(0),
-		QualityAdjPower: abi_spec.NewTokenAmount(0),
-	})
-	require.NoError(d.tb, err)
-
-	// save the claim
-	spa.Claims, err = hm.Root()
-	require.NoError(d.tb, err)
-
-	// update miner count
-	spa.MinerCount += 1
-
-	// update storage power actor's state in the tree
-	d.PutState(&spa)
-
-	return minerActorIDAddr
+	return minerActorIDAddr, info
 }
 
 func AsStore(vmw state.VMWrapper) adt_spec.Store {
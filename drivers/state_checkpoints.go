@@ -0,0 +1,116 @@
+package drivers
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+)
+
+// ActorChangeKind is the way an actor changed between two state trees.
+type ActorChangeKind string
+
+const (
+	ActorAdded    = ActorChangeKind("added")
+	ActorRemoved  = ActorChangeKind("removed")
+	ActorModified = ActorChangeKind("modified")
+)
+
+// ActorChange is an actor whose code, head, call sequence number or balance differs between two state trees.
+type ActorChange struct {
+	Address address.Address
+	Kind    ActorChangeKind
+}
+
+func (c ActorChange) String() string {
+	return fmt.Sprintf("%s %s", c.Address, c.Kind)
+}
+
+// CheckpointState stores the current state root under `label`, replacing any root stored under it before. Unlike
+// Checkpoint, the root isn't recorded or checked against an expectation: it is only compared with later states of
+// the same test by AssertStateUnchangedSince and DiffSince.
+func (td *TestDriver) CheckpointState(label string) {
+	if td.stateCheckpoints == nil {
+		td.stateCheckpoints = make(map[string]cid.Cid)
+	}
+	td.stateCheckpoints[label] = td.State().Root()
+}
+
+// AssertStateUnchangedSince asserts the current state root is that stored under `label`, e.g. across epochs in which
+// nothing should happen. On failure, the actors that changed are listed.
+func (td *TestDriver) AssertStateUnchangedSince(label string) {
+	root := td.stateCheckpoint(label)
+	if root.Equals(td.State().Root()) {
+		return
+	}
+	var sb strings.Builder
+	for _, c := range td.DiffSince(label) {
+		fmt.Fprintf(&sb, "\n  %s", c)
+		if c.Kind != ActorRemoved {
+			if act, err := td.State().Actor(c.Address); err == nil {
+				fmt.Fprintf(&sb, " (%s)", actorCodeName(act.Code()))
+			}
+		}
+	}
+	assert.Fail(td.T, "state changed", "state root %s at checkpoint %q is now %s:%s", root, label, td.State().Root(), sb.String())
+}
+
+// DiffSince returns the actors that changed since the state root stored under `label`, sorted by address.
+func (td *TestDriver) DiffSince(label string) []ActorChange {
+	before := td.actorEntries(td.stateCheckpoint(label))
+	after := td.actorEntries(td.State().Root())
+
+	var changes []ActorChange
+	for k, b := range before {
+		a, ok := after[k]
+		if !ok {
+			changes = append(changes, ActorChange{td.actorEntryAddress(k), ActorRemoved})
+		} else if !bytes.Equal(a, b) {
+			changes = append(changes, ActorChange{td.actorEntryAddress(k), ActorModified})
+		}
+	}
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			changes = append(changes, ActorChange{td.actorEntryAddress(k), ActorAdded})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Address.String() < changes[j].Address.String() })
+	return changes
+}
+
+func (td *TestDriver) stateCheckpoint(label string) cid.Cid {
+	root, ok := td.stateCheckpoints[label]
+	if !ok {
+		td.T.Fatalf("no state checkpoint %q", label)
+	}
+	return root
+}
+
+// actorEntries returns the encoded actors of the state tree rooted at `root`, keyed by the bytes of their address.
+// The state tree is a HAMT mapping addresses to actors; the blocks of earlier state trees remain in the store.
+func (td *TestDriver) actorEntries(root cid.Cid) map[string][]byte {
+	actors, err := adt_spec.AsMap(AsStore(td.State()), root)
+	require.NoError(td.T, err)
+
+	entries := make(map[string][]byte)
+	var entry cbg.Deferred
+	err = actors.ForEach(&entry, func(k string) error {
+		entries[k] = append([]byte(nil), entry.Raw...)
+		return nil
+	})
+	require.NoError(td.T, err)
+	return entries
+}
+
+func (td *TestDriver) actorEntryAddress(key string) address.Address {
+	addr, err := address.NewFromBytes([]byte(key))
+	require.NoError(td.T, err)
+	return addr
+}
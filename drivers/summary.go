@@ -0,0 +1,228 @@
+package drivers
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	market_spec "github.com/filecoin-project/specs-actors/actors/builtin/market"
+	reward_spec "github.com/filecoin-project/specs-actors/actors/builtin/reward"
+	"github.com/stretchr/testify/assert"
+)
+
+// Summaries are flat structs of the amounts and counts of an actor's state that suites check most often. Each
+// field is a big integer or an integer, so that two summaries of the same kind may be diffed field by field.
+
+type RewardSummary struct {
+	Treasury           abi_spec.TokenAmount
+	SimpleSupply       abi_spec.TokenAmount
+	BaselineSupply     abi_spec.TokenAmount
+	NextPerEpochReward abi_spec.TokenAmount
+	NextPerBlockReward abi_spec.TokenAmount
+}
+
+func (td *TestDriver) GetRewardSummary() *RewardSummary {
+	var rst reward_spec.State
+	td.GetActorState(builtin_spec.RewardActorAddr, &rst)
+
+	return &RewardSummary{
+		Treasury:           td.GetBalance(builtin_spec.RewardActorAddr),
+		NextPerEpochReward: rst.ThisEpochReward,
+		NextPerBlockReward: big_spec.Div(rst.ThisEpochReward, big_spec.NewInt(builtin_spec.ExpectedLeadersPerEpoch)),
+	}
+}
+
+type PowerSummary struct {
+	TotalRawBytePower     abi_spec.StoragePower
+	TotalQualityAdjPower  abi_spec.StoragePower
+	TotalPledgeCollateral abi_spec.TokenAmount
+	MinerCount            int64
+}
+
+func (td *TestDriver) GetPowerSummary() *PowerSummary {
+	st := td.Power().State()
+	return &PowerSummary{
+		TotalRawBytePower:     st.TotalRawBytePower,
+		TotalQualityAdjPower:  st.TotalQualityAdjPower,
+		TotalPledgeCollateral: st.TotalPledgeCollateral,
+		MinerCount:            st.MinerCount,
+	}
+}
+
+type MarketSummary struct {
+	Balance                       abi_spec.TokenAmount
+	TotalClientLockedCollateral   abi_spec.TokenAmount
+	TotalProviderLockedCollateral abi_spec.TokenAmount
+	TotalClientStorageFee         abi_spec.TokenAmount
+	NextID                        abi_spec.DealID
+}
+
+func (td *TestDriver) GetMarketSummary() *MarketSummary {
+	var st market_spec.State
+	td.GetActorState(builtin_spec.StorageMarketActorAddr, &st)
+	return &MarketSummary{
+		Balance:                       td.GetBalance(builtin_spec.StorageMarketActorAddr),
+		TotalClientLockedCollateral:   st.TotalClientLockedCollateral,
+		TotalProviderLockedCollateral: st.TotalProviderLockedCollateral,
+		TotalClientStorageFee:         st.TotalClientStorageFee,
+		NextID:                        st.NextID,
+	}
+}
+
+// MinerSummary includes the miner's power as claimed with the power actor, which is zero if it has no claim.
+type MinerSummary struct {
+	Balance           abi_spec.TokenAmount
+	LockedFunds       abi_spec.TokenAmount
+	PreCommitDeposits abi_spec.TokenAmount
+	RawBytePower      abi_spec.StoragePower
+	QualityAdjPower   abi_spec.StoragePower
+}
+
+func (td *TestDriver) GetMinerSummary(addr address.Address) *MinerSummary {
+	st := td.Miner(addr).State()
+	summary := &MinerSummary{
+		Balance:           td.GetBalance(addr),
+		LockedFunds:       st.LockedFunds,
+		PreCommitDeposits: st.PreCommitDeposits,
+		RawBytePower:      big_spec.Zero(),
+		QualityAdjPower:   big_spec.Zero(),
+	}
+	if claim, found := td.Power().Claim(addr); found {
+		summary.RawBytePower = claim.RawBytePower
+		summary.QualityAdjPower = claim.QualityAdjPower
+	}
+	return summary
+}
+
+// StateSummary holds the reward, power and market summaries, and those of a set of miners, at one point in a test.
+type StateSummary struct {
+	Reward *RewardSummary
+	Power  *PowerSummary
+	Market *MarketSummary
+	Miners map[address.Address]*MinerSummary
+}
+
+func (td *TestDriver) getStateSummary(miners []address.Address) *StateSummary {
+	s := &StateSummary{
+		Reward: td.GetRewardSummary(),
+		Power:  td.GetPowerSummary(),
+		Market: td.GetMarketSummary(),
+		Miners: make(map[address.Address]*MinerSummary),
+	}
+	for _, m := range miners {
+		s.Miners[m] = td.GetMinerSummary(m)
+	}
+	return s
+}
+
+// SummaryDiff maps the names of summary fields to the amount they changed by, e.g. "Power.MinerCount" or
+// "Miner(t0101).Balance". Unchanged fields are absent.
+type SummaryDiff map[string]big_spec.Int
+
+// Summaries captures state summaries before and after a step of a test, so that suites can assert that only the
+// expected fields changed, and by how much:
+//
+//	s := td.CaptureSummaries(miner)
+//	td.ApplyOk(msg)
+//	s.Capture()
+//	s.AssertDiff(drivers.SummaryDiff{"Miner(t0101).Balance": amount, ...})
+type Summaries struct {
+	td     *TestDriver
+	miners []address.Address
+
+	before *StateSummary
+	after  *StateSummary
+}
+
+// CaptureSummaries captures the summaries before a step, including those of `miners`.
+func (td *TestDriver) CaptureSummaries(miners ...address.Address) *Summaries {
+	return &Summaries{td: td, miners: miners, before: td.getStateSummary(miners)}
+}
+
+// Capture captures the summaries after the step. It may be called again to extend the step.
+func (s *Summaries) Capture() *Summaries {
+	s.after = s.td.getStateSummary(s.miners)
+	return s
+}
+
+func (s *Summaries) Before() *StateSummary {
+	return s.before
+}
+
+func (s *Summaries) After() *StateSummary {
+	if s.after == nil {
+		s.td.T.Fatal("summaries after the step haven't been captured")
+	}
+	return s.after
+}
+
+// Diff returns the fields whose value changed across the step.
+func (s *Summaries) Diff() SummaryDiff {
+	after := s.After()
+	diff := make(SummaryDiff)
+	diffStructs(diff, "Reward", s.before.Reward, after.Reward)
+	diffStructs(diff, "Power", s.before.Power, after.Power)
+	diffStructs(diff, "Market", s.before.Market, after.Market)
+	for _, m := range s.miners {
+		diffStructs(diff, fmt.Sprintf("Miner(%s)", m), s.before.Miners[m], after.Miners[m])
+	}
+	return diff
+}
+
+// AssertDiff asserts that exactly the fields in `expected` changed across the step, by the amounts given.
+func (s *Summaries) AssertDiff(expected SummaryDiff) {
+	actual := s.Diff()
+	names := make(map[string]struct{})
+	for name := range expected {
+		names[name] = struct{}{}
+	}
+	for name := range actual {
+		names[name] = struct{}{}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		assert.Equal(s.td.T, orZero(expected[name]), orZero(actual[name]), "change in %s", name)
+	}
+}
+
+var bigIntType = reflect.TypeOf(big_spec.Int{})
+
+// diffStructs records in `diff` the change in each field of the summaries `before` and `after`, pointers to
+// structs of the same type.
+func diffStructs(diff SummaryDiff, prefix string, before, after interface{}) {
+	bv := reflect.ValueOf(before).Elem()
+	av := reflect.ValueOf(after).Elem()
+	for i := 0; i < bv.NumField(); i++ {
+		var b, a big_spec.Int
+		switch f := bv.Field(i); {
+		case f.Type() == bigIntType:
+			b, a = orZero(f.Interface().(big_spec.Int)), orZero(av.Field(i).Interface().(big_spec.Int))
+		case f.Kind() >= reflect.Int && f.Kind() <= reflect.Int64:
+			b, a = big_spec.NewInt(f.Int()), big_spec.NewInt(av.Field(i).Int())
+		case f.Kind() >= reflect.Uint && f.Kind() <= reflect.Uint64:
+			b, a = big_spec.NewInt(int64(f.Uint())), big_spec.NewInt(int64(av.Field(i).Uint()))
+		default:
+			panic(fmt.Sprintf("unsupported summary field %s.%s of type %s", prefix, bv.Type().Field(i).Name, f.Type()))
+		}
+		if d := big_spec.Sub(a, b); !d.IsZero() {
+			diff[prefix+"."+bv.Type().Field(i).Name] = d
+		}
+	}
+}
+
+// orZero returns `v`, or zero if it is unset.
+func orZero(v big_spec.Int) big_spec.Int {
+	if v.Int == nil {
+		return big_spec.Zero()
+	}
+	return v
+}
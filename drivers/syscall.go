@@ -2,6 +2,7 @@ package drivers
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/filecoin-project/go-address"
 	commcid "github.com/filecoin-project/go-fil-commcid"
@@ -53,8 +54,9 @@ var fakeBatchVerifySealfunc = func(inp map[address.Address][]abi.SealVerifyInfo)
 	return out, nil
 }
 
-var panicingVerifyConsensusFaultFunc = func(h1, h2, extra []byte) (*runtime.ConsensusFault, error) {
-	panic("implement me")
+// Without a mocked fault, no pair of headers is a consensus fault.
+var fakeVerifyConsensusFaultFunc = func(h1, h2, extra []byte) (*runtime.ConsensusFault, error) {
+	return nil, fmt.Errorf("no consensus fault mocked")
 }
 
 type ChainValidationSysCalls struct {
@@ -76,7 +78,7 @@ func NewChainValidationSysCalls() *ChainValidationSysCalls {
 		VerifySealFunc:               fakeVerifySealFunc,
 		VerifyPoStFunc:               fakeVerifyPoStFunc,
 
-		VerifyConsensusFaultFunc: panicingVerifyConsensusFaultFunc,
+		VerifyConsensusFaultFunc: fakeVerifyConsensusFaultFunc,
 		BatchVerifySealsFunc:     fakeBatchVerifySealfunc,
 	}
 }
@@ -108,3 +110,73 @@ func (c ChainValidationSysCalls) VerifyConsensusFault(h1, h2, extra []byte) (*ru
 func (c ChainValidationSysCalls) BatchVerifySeals(inp map[address.Address][]abi.SealVerifyInfo) (map[address.Address][]bool, error) {
 	return c.BatchVerifySealsFunc(inp)
 }
+
+//
+// Failure injection. Each affects syscalls made by messages applied after the call, until ResetFailures.
+//
+
+// FailSealVerification causes seal verification to fail with `err`, both individually and in batches.
+func (c *ChainValidationSysCalls) FailSealVerification(err error) {
+	c.VerifySealFunc = func(info abi.SealVerifyInfo) error {
+		return err
+	}
+	c.BatchVerifySealsFunc = func(inp map[address.Address][]abi.SealVerifyInfo) (map[address.Address][]bool, error) {
+		// A batch reports failed seals as invalid rather than returning an error.
+		out := make(map[address.Address][]bool)
+		for a, svis := range inp {
+			out[a] = make([]bool, len(svis))
+		}
+		return out, nil
+	}
+}
+
+// MockBatchSealOutcomes causes batch seal verification to report each seal by the outcome in `outcomes` for its
+// sector. Seals of sectors without an outcome are verified as before. Individual seal verification is unaffected.
+func (c *ChainValidationSysCalls) MockBatchSealOutcomes(outcomes map[abi.SectorID]bool) {
+	verify := c.BatchVerifySealsFunc
+	c.BatchVerifySealsFunc = func(inp map[address.Address][]abi.SealVerifyInfo) (map[address.Address][]bool, error) {
+		out, err := verify(inp)
+		if err != nil {
+			return nil, err
+		}
+		for a, svis := range inp {
+			for i, svi := range svis {
+				if valid, ok := outcomes[svi.SectorID]; ok {
+					out[a][i] = valid
+				}
+			}
+		}
+		return out, nil
+	}
+}
+
+// FailPoStVerification causes window PoSt verification to fail with `err`.
+func (c *ChainValidationSysCalls) FailPoStVerification(err error) {
+	c.VerifyPoStFunc = func(info abi.WindowPoStVerifyInfo) error {
+		return err
+	}
+}
+
+// FailSignatureVerification causes verification of signatures by `signer` to fail. Signatures by other addresses are
+// verified as before.
+func (c *ChainValidationSysCalls) FailSignatureVerification(signer address.Address) {
+	verify := c.VerifySigFunc
+	c.VerifySigFunc = func(signature crypto.Signature, s address.Address, plaintext []byte) error {
+		if s == signer {
+			return fmt.Errorf("injected signature verification failure for %s", signer)
+		}
+		return verify(signature, s, plaintext)
+	}
+}
+
+// MockConsensusFault causes consensus fault verification to return `fault` and `err`, whatever the block headers.
+func (c *ChainValidationSysCalls) MockConsensusFault(fault *runtime.ConsensusFault, err error) {
+	c.VerifyConsensusFaultFunc = func(h1, h2, extra []byte) (*runtime.ConsensusFault, error) {
+		return fault, err
+	}
+}
+
+// ResetFailures restores the default behavior of all syscalls.
+func (c *ChainValidationSysCalls) ResetFailures() {
+	*c = *NewChainValidationSysCalls()
+}
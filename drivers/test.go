@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/filecoin-project/go-bitfield"
 
@@ -29,18 +33,38 @@ import (
 	datastore "github.com/ipfs/go-datastore"
 	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/multiformats/go-multihash"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/chain-validation/chain"
 	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/report"
 	"github.com/filecoin-project/chain-validation/state"
 	"github.com/filecoin-project/chain-validation/tracker"
 )
 
-var (
+// AdtRoots holds the CIDs of the empty collections and objects referenced by the initial states of actors. They are
+// the same in every store.
+type AdtRoots struct {
+	Array        cid.Cid
+	Deadlines    cid.Cid
+	VestingFunds cid.Cid
+	Map          cid.Cid
+	MultiMap     cid.Cid
+	Bitfield     cid.Cid
+}
+
+// emptyRoots is computed once, by init, and never modified, so drivers may be built concurrently.
+var emptyRoots AdtRoots
 
-	// initialized by calling initializeStoreWithAdtRoots
+// EmptyRoots returns the CIDs of the empty collections and objects referenced by the initial states of actors.
+func EmptyRoots() AdtRoots {
+	return emptyRoots
+}
+
+// Deprecated: use EmptyRoots. These are set once, by init, to the fields of EmptyRoots.
+var (
 	EmptyArrayCid        cid.Cid
 	EmptyDeadlinesCid    cid.Cid
 	EmptyVestingFundsCid cid.Cid
@@ -65,16 +89,23 @@ const (
 )
 
 func init() {
-	ms := newMockStore()
-	if err := initializeStoreWithAdtRoots(ms); err != nil {
+	var err error
+	emptyRoots, err = PutEmptyRoots(newMockStore())
+	if err != nil {
 		panic(err)
 	}
+	EmptyArrayCid = emptyRoots.Array
+	EmptyDeadlinesCid = emptyRoots.Deadlines
+	EmptyVestingFundsCid = emptyRoots.VestingFunds
+	EmptyMapCid = emptyRoots.Map
+	EmptyMultiMapCid = emptyRoots.MultiMap
+	EmptyBitfieldCid = emptyRoots.Bitfield
 
 	DefaultInitActorState = ActorState{
 		Addr:    builtin_spec.InitActorAddr,
 		Balance: big_spec.Zero(),
 		Code:    builtin_spec.InitActorCodeID,
-		State:   init_spec.ConstructState(EmptyMapCid, "chain-validation"),
+		State:   init_spec.ConstructState(emptyRoots.Map, "chain-validation"),
 	}
 
 	firstRewardState := reward_spec.ConstructState(big_spec.Zero())
@@ -98,7 +129,7 @@ func init() {
 		Addr:    builtin_spec.StoragePowerActorAddr,
 		Balance: big_spec.Zero(),
 		Code:    builtin_spec.StoragePowerActorCodeID,
-		State:   power_spec.ConstructState(EmptyMapCid, EmptyMultiMapCid),
+		State:   power_spec.ConstructState(emptyRoots.Map, emptyRoots.MultiMap),
 	}
 
 	DefaultStorageMarketActorState = ActorState{
@@ -106,13 +137,13 @@ func init() {
 		Balance: big_spec.Zero(),
 		Code:    builtin_spec.StorageMarketActorCodeID,
 		State: &market_spec.State{
-			Proposals:        EmptyArrayCid,
-			States:           EmptyArrayCid,
-			PendingProposals: EmptyMapCid,
-			EscrowTable:      EmptyMapCid,
-			LockedTable:      EmptyMapCid,
+			Proposals:        emptyRoots.Array,
+			States:           emptyRoots.Array,
+			PendingProposals: emptyRoots.Map,
+			EscrowTable:      emptyRoots.Map,
+			LockedTable:      emptyRoots.Map,
 			NextID:           abi_spec.DealID(0),
-			DealOpsByEpoch:   EmptyMultiMapCid,
+			DealOpsByEpoch:   emptyRoots.MultiMap,
 			LastCron:         0,
 		},
 	}
@@ -147,39 +178,42 @@ func init() {
 	}
 }
 
-func initializeStoreWithAdtRoots(store adt_spec.Store) error {
+// PutEmptyRoots puts the empty collections and objects referenced by the initial states of actors in `store`, and
+// returns their CIDs.
+func PutEmptyRoots(store adt_spec.Store) (AdtRoots, error) {
+	var roots AdtRoots
 	var err error
-	EmptyArrayCid, err = adt_spec.MakeEmptyArray(store).Root()
+	roots.Array, err = adt_spec.MakeEmptyArray(store).Root()
 	if err != nil {
-		return err
+		return AdtRoots{}, err
 	}
 
-	EmptyMapCid, err = adt_spec.MakeEmptyMap(store).Root()
+	roots.Map, err = adt_spec.MakeEmptyMap(store).Root()
 	if err != nil {
-		return err
+		return AdtRoots{}, err
 	}
 
-	EmptyMultiMapCid, err = adt_spec.MakeEmptyMultimap(store).Root()
+	roots.MultiMap, err = adt_spec.MakeEmptyMultimap(store).Root()
 	if err != nil {
-		return err
+		return AdtRoots{}, err
 	}
 
-	EmptyDeadlinesCid, err = store.Put(context.TODO(), miner.ConstructDeadline(EmptyArrayCid))
+	roots.Deadlines, err = store.Put(context.TODO(), miner.ConstructDeadline(roots.Array))
 	if err != nil {
-		return err
+		return AdtRoots{}, err
 	}
 
-	EmptyVestingFundsCid, err = store.Put(context.Background(), miner.ConstructVestingFunds())
+	roots.VestingFunds, err = store.Put(context.Background(), miner.ConstructVestingFunds())
 	if err != nil {
-		return err
+		return AdtRoots{}, err
 	}
 
-	EmptyBitfieldCid, err = store.Put(context.TODO(), bitfield.New())
+	roots.Bitfield, err = store.Put(context.TODO(), bitfield.New())
 	if err != nil {
-		return err
+		return AdtRoots{}, err
 	}
 
-	return nil
+	return roots, nil
 }
 
 type mockStore struct {
@@ -205,16 +239,33 @@ type TestDriverBuilder struct {
 	factory state.Factories
 
 	actorStates []ActorState
+	genesis     *GenesisBuilder
+	genesisCAR  string
+	// genesis constructed once and cached, see WithCachedGenesis
+	cachedGenesis *GenesisBuilder
 
 	defaultGasFeeCap  abi_spec.TokenAmount
 	defaultGasPremium abi_spec.TokenAmount
 	defaultGasLimit   int64
+
+	baseFee    abi_spec.TokenAmount
+	circSupply func(abi_spec.ChainEpoch) abi_spec.TokenAmount
+
+	provingPeriodStart *abi_spec.ChainEpoch
+
+	trackNonces    bool
+	reconcileBurns bool
+
+	networkVersion NetworkVersion
 }
 
 func NewBuilder(ctx context.Context, factory state.Factories) *TestDriverBuilder {
 	return &TestDriverBuilder{
 		factory: factory,
 		ctx:     ctx,
+		baseFee: abi_spec.NewTokenAmount(DefaultBaseFee),
+
+		networkVersion: NetworkVersionOf(factory),
 	}
 }
 
@@ -230,6 +281,22 @@ func (b *TestDriverBuilder) WithActorState(acts ...ActorState) *TestDriverBuilde
 	return b
 }
 
+// WithGenesis constructs the builtin actors and the state described by `g`, replacing
+// WithActorState(DefaultBuiltinActorsState...). With WithGenesisCAR the builtin actors are taken from the snapshot
+// instead. The created actors are available from TestDriver.Genesis.
+func (b *TestDriverBuilder) WithGenesis(g *GenesisBuilder) *TestDriverBuilder {
+	b.genesis = g
+	return b
+}
+
+// WithGenesisCAR starts from the state tree in the CAR file at `path`, which must have the state root as its single
+// root. The actors of WithActorState and WithGenesis are created on top of it, so the snapshot's builtin actors
+// shouldn't be added again.
+func (b *TestDriverBuilder) WithGenesisCAR(path string) *TestDriverBuilder {
+	b.genesisCAR = path
+	return b
+}
+
 func (b *TestDriverBuilder) WithDefaultGasLimit(limit int64) *TestDriverBuilder {
 	b.defaultGasLimit = limit
 	return b
@@ -245,39 +312,155 @@ func (b *TestDriverBuilder) WithDefaultGasPremium(premium int64) *TestDriverBuil
 	return b
 }
 
+// WithBaseFee sets the base fee messages are applied at, defaults to DefaultBaseFee.
+func (b *TestDriverBuilder) WithBaseFee(baseFee int64) *TestDriverBuilder {
+	b.baseFee = abi_spec.NewTokenAmount(baseFee)
+	return b
+}
+
+// WithCirculatingSupply sets the circulating supply actors observe when messages are applied at each epoch, e.g.
+// in computing a sector's initial pledge, in place of that computed by the implementation from its state.
+func (b *TestDriverBuilder) WithCirculatingSupply(fn func(epoch abi_spec.ChainEpoch) abi_spec.TokenAmount) *TestDriverBuilder {
+	b.circSupply = fn
+	return b
+}
+
+// WithProvingPeriodStart sets the epoch at which the first proving period of each miner the driver creates starts,
+// including the builtin miner and genesis miners, in place of epoch 0. It also enrolls the cron event that starts
+// the miner's proving periods, at the epoch before, as the miner constructor does, so that the miner's deadlines are
+// processed by cron. Deadline i of the first period opens at `start` + i*miner.WPoStChallengeWindow, so suites may
+// reach the deadline of their choice within a few epochs. `start` must be positive.
+func (b *TestDriverBuilder) WithProvingPeriodStart(start abi_spec.ChainEpoch) *TestDriverBuilder {
+	b.provingPeriodStart = &start
+	return b
+}
+
+// WithNonceTracking makes the driver's message producer track sender nonces, so that suites need only pass
+// chain.Nonce to override them. See MessageProducer.EnableNonceTracking.
+func (b *TestDriverBuilder) WithNonceTracking() *TestDriverBuilder {
+	b.trackNonces = true
+	return b
+}
+
+// WithNetworkVersion sets the network version the driver's suite is run at, which determines the code CIDs returned
+// by TestDriver.ActorCode. It defaults to that of the factories, see AtNetworkVersion.
+func (b *TestDriverBuilder) WithNetworkVersion(nv NetworkVersion) *TestDriverBuilder {
+	b.networkVersion = nv
+	return b
+}
+
+// WithBurnReconciliation makes the driver reconcile the funds burnt during the test with those expected when it
+// completes. See BurnLedger.
+func (b *TestDriverBuilder) WithBurnReconciliation() *TestDriverBuilder {
+	b.reconcileBurns = true
+	return b
+}
+
 func (b *TestDriverBuilder) Build(t testing.TB) *TestDriver {
+	if err := b.ctx.Err(); err != nil {
+		t.Fatalf("test aborted before building driver: %v", err)
+	}
+	cfg := b.factory.NewValidationConfig()
+	if reason, ok := knownFailureReason(t, cfg); ok {
+		t = &knownFailureTB{TB: t, reason: reason}
+	}
+
 	syscalls := NewChainValidationSysCalls()
 	stateWrapper, applier := b.factory.NewStateAndApplier(syscalls)
 	sd := NewStateDriver(t, stateWrapper, b.factory.NewKeyManager())
 	stateWrapper.NewVM()
 
-	err := initializeStoreWithAdtRoots(AsStore(sd.st))
+	roots, err := PutEmptyRoots(AsStore(sd.st))
 	require.NoError(t, err)
+	require.Equal(t, emptyRoots, roots, "empty roots differ between stores")
 
-	for _, acts := range b.actorStates {
+	genesisCAR := b.genesisCAR
+	var fix *fixture
+	if b.cachedGenesis != nil {
+		fix, err = loadFixture(t, b.factory, b.cachedGenesis)
+		require.NoError(t, err)
+		genesisCAR = fix.CAR
+	}
+	if genesisCAR != "" {
+		car, err := ioutil.ReadFile(genesisCAR)
+		require.NoError(t, err)
+		root, err := readCARRoot(car)
+		require.NoError(t, err, "genesis CAR %s", genesisCAR)
+		require.NoError(t, stateWrapper.ImportStateTree(root, bytes.NewReader(car)))
+		require.Equal(t, root, stateWrapper.Root(), "imported state root")
+	}
+	if fix != nil {
+		fix.restore(sd)
+	}
+
+	actorStates := b.actorStates
+	if b.genesis != nil && b.genesisCAR == "" {
+		actorStates = append(append([]ActorState{}, DefaultBuiltinActorsState...), actorStates...)
+	}
+	for _, acts := range actorStates {
 		_, _, err := sd.State().CreateActor(acts.Code, acts.Addr, acts.Balance, acts.State)
 		require.NoError(t, err)
 	}
+	genesisRoot := stateWrapper.Root()
 
-	minerActorIDAddr := sd.newMinerAccountActor(TestSealProofType, abi_spec.ChainEpoch(0))
+	if b.provingPeriodStart != nil {
+		require.True(t, *b.provingPeriodStart > 0, "proving period start %d must be positive", *b.provingPeriodStart)
+	}
+	sd.provingPeriodStart = b.provingPeriodStart
+	minerActorIDAddr, minerInfo := sd.NewDefaultMinerActor()
+	sd.minerInfo = minerInfo
+
+	var genesis *Genesis
+	if b.genesis != nil {
+		genesis = b.genesis.build(sd)
+	}
+	if fix != nil {
+		genesis = fix.Genesis
+	}
 
-	exeCtx := types.NewExecutionContext(1, minerActorIDAddr)
+	exeCtx := types.NewExecutionContext(1, minerActorIDAddr, b.baseFee)
 	producer := chain.NewMessageProducer(b.defaultGasFeeCap, b.defaultGasPremium, b.defaultGasLimit)
+	if b.trackNonces {
+		producer.EnableNonceTracking()
+	}
 	validator := chain.NewValidator(applier)
 
-	return &TestDriver{
+	td := &TestDriver{
 		T:               t,
 		StateDriver:     sd,
 		MessageProducer: producer,
 		validator:       validator,
 		ExeCtx:          exeCtx,
+		Genesis:         genesis,
 
-		Config: b.factory.NewValidationConfig(),
+		Config: cfg,
 
 		StateTracker: tracker.NewStateTracker(t),
 
 		SysCalls: syscalls,
+
+		circSupply: b.circSupply,
+
+		networkVersion: b.networkVersion,
+		genesisRoot:    genesisRoot,
+
+		ctx:     b.ctx,
+		started: time.Now(),
+	}
+	if b.reconcileBurns {
+		td.burns = newBurnLedger(td)
+	}
+	for f := b.factory; f != nil; {
+		if sv, ok := f.(*senderVariantFactories); ok {
+			td.applySenderVariant(sv.variant)
+		}
+		wf, ok := f.(wrappedFactories)
+		if !ok {
+			break
+		}
+		f = wf.unwrap()
 	}
+	return td
 }
 
 type TestDriver struct {
@@ -288,20 +471,127 @@ type TestDriver struct {
 	TipSetMessageBuilder *TipSetMessageBuilder
 	validator            *chain.Validator
 	ExeCtx               *types.ExecutionContext
+	// Genesis holds the actors created by the builder's GenesisBuilder, nil if there is none.
+	Genesis *Genesis
 
 	Config state.ValidationConfig
 
 	StateTracker *tracker.StateTracker
 
 	SysCalls *ChainValidationSysCalls
+
+	burns *BurnLedger
+
+	// state roots stored by CheckpointState, by label
+	stateCheckpoints map[string]cid.Cid
+
+	// when the driver was built, for profiling
+	started time.Time
+
+	networkVersion NetworkVersion
+
+	// state root once the builder's actors are created, before the default miner
+	genesisRoot cid.Cid
+
+	circSupply func(abi_spec.ChainEpoch) abi_spec.TokenAmount
+
+	// number of messages applied, counting each message applied in a tipset
+	applied int
+
+	// whether the collections of the state were diagnosed after a state root differed from that expected
+	stateRootDiagnosed bool
+
+	ctx     context.Context
+	aborted bool
+}
+
+// Validator returns the validator used to apply messages, bypassing the driver's result and state checks.
+func (td *TestDriver) Validator() *chain.Validator {
+	return td.validator
+}
+
+// CurrentEpoch returns the epoch at which messages are currently applied.
+func (td *TestDriver) CurrentEpoch() abi_spec.ChainEpoch {
+	return td.ExeCtx.Epoch
+}
+
+// AdvanceEpoch moves the epoch at which subsequent messages are applied forward by `n` epochs.
+func (td *TestDriver) AdvanceEpoch(n abi_spec.ChainEpoch) {
+	td.checkContext()
+	require.True(td.T, n >= 0, "cannot advance epoch by negative amount %d", n)
+	td.ExeCtx.Epoch += n
+}
+
+// SetEpoch sets the epoch at which subsequent messages are applied. The epoch may not move backwards.
+func (td *TestDriver) SetEpoch(epoch abi_spec.ChainEpoch) {
+	td.checkContext()
+	require.True(td.T, epoch >= td.ExeCtx.Epoch, "cannot move epoch backwards from %d to %d", td.ExeCtx.Epoch, epoch)
+	td.ExeCtx.Epoch = epoch
+}
+
+// Context returns the context the driver was built with. Suites looping over many epochs or messages should stop
+// once it is done; message application and epoch changes abort the test by themselves.
+func (td *TestDriver) Context() context.Context {
+	return td.ctx
+}
+
+// executionContext returns the context to apply messages in at the current epoch.
+func (td *TestDriver) executionContext() types.ExecutionContext {
+	exeCtx := *td.ExeCtx
+	if td.circSupply != nil {
+		circSupply := td.circSupply(exeCtx.Epoch)
+		exeCtx.CircSupply = &circSupply
+	}
+	return exeCtx
+}
+
+// checkContext aborts the test if the driver's context is done, e.g. on a CI timeout or user interrupt.
+func (td *TestDriver) checkContext() {
+	if err := td.ctx.Err(); err != nil {
+		td.aborted = true
+		td.T.Fatalf("test aborted: %v", err)
+	}
 }
 
 func (td *TestDriver) Complete() {
+	if k, ok := td.T.(*knownFailureTB); ok {
+		defer k.complete()
+	}
+	defer td.recordOutcome()
+	// The final state is exported even for aborted tests, as it may help diagnose them.
+	td.exportState()
+	if tracker.ProfilingEnabled() {
+		tracker.TrackTest(td.T, time.Since(td.started))
+	}
+
+	// The results of an aborted test are partial and must not replace its expectations.
+	if td.aborted {
+		td.T.Logf("not recording results of aborted test")
+		return
+	}
+	if td.burns != nil {
+		td.burns.assertReconciled()
+	}
 	//
 	// Gas expectation recording.
-	// Uncomment the following line to persist the actual gas values used to file as the new set
+	// Set CHAIN_VALIDATION_RECORD to persist the actual gas values used to file as the new set
 	// of expectations.
-	//td.StateTracker.Record()
+	if tracker.RecordingEnabled() {
+		td.StateTracker.Record()
+	}
+}
+
+// recordOutcome records the outcome of the test in the report, once the driver's checks are complete.
+func (td *TestDriver) recordOutcome() {
+	status := report.Passed
+	if k, ok := td.T.(*knownFailureTB); ok && k.failed {
+		status = report.KnownFailure
+	} else if td.T.Skipped() {
+		status = report.Skipped
+	} else if td.T.Failed() {
+		status = report.Failed
+	}
+	report.RecordTest(td.T, status)
 }
 
 //
@@ -315,35 +605,54 @@ func (td *TestDriver) ApplyMessage(msg *types.Message) types.ApplyMessageResult
 }
 
 func (td *TestDriver) ApplyOk(msg *types.Message) types.ApplyMessageResult {
-	return td.ApplyExpect(msg, EmptyReturnValue)
+	return td.ApplyMatching(msg, ExpectReceipt())
 }
 
 func (td *TestDriver) ApplyExpect(msg *types.Message, retval []byte) types.ApplyMessageResult {
-	return td.applyMessageExpectCodeAndReturn(msg, exitcode.Ok, retval)
+	return td.ApplyMatching(msg, ExpectReceipt().WithReturnBytes(retval))
 }
 
 func (td *TestDriver) ApplyFailure(msg *types.Message, code exitcode.ExitCode) types.ApplyMessageResult {
-	return td.applyMessageExpectCodeAndReturn(msg, code, EmptyReturnValue)
+	return td.ApplyMatching(msg, ExpectReceipt().WithExitCode(code))
+}
+
+// ApplyFailureClass applies `msg`, expecting it to fail with an exit code of class `class`, for failures whose exact
+// exit code isn't normative.
+func (td *TestDriver) ApplyFailureClass(msg *types.Message, class ExitCodeClass) types.ApplyMessageResult {
+	return td.ApplyMatching(msg, ExpectReceipt().WithExitCodeClass(class))
 }
 
-func (td *TestDriver) applyMessageExpectCodeAndReturn(msg *types.Message, code exitcode.ExitCode, retval []byte) types.ApplyMessageResult {
+// ApplyMatching applies `msg`, expecting its receipt to match `expected`.
+func (td *TestDriver) ApplyMatching(msg *types.Message, expected *ReceiptMatcher) types.ApplyMessageResult {
 	result := td.applyMessage(msg)
-	td.validateResult(result, code, retval)
+	td.validateReceipt(result, expected)
 	td.validateState(msg, result)
 	return result
 }
 
 func (td *TestDriver) applyMessage(msg *types.Message) (result types.ApplyMessageResult) {
+	td.checkContext()
 	defer func() {
 		if r := recover(); r != nil {
 			td.T.Fatalf("message application panicked: %v", r)
 		}
 	}()
 
-	result, err := td.validator.ApplyMessage(td.ExeCtx.Epoch, msg)
+	gasKey := td.gasKey(msg)
+	start := time.Now()
+	result, err := td.validator.ApplyMessage(td.executionContext(), msg)
+	elapsed := time.Since(start)
 	require.NoError(td.T, err)
 
+	result.ReceiverCode = td.receiverCode(result.Msg.To)
 	td.StateTracker.TrackResult(result)
+	td.trackGas(gasKey, result.Receipt.GasUsed)
+	td.recordApplied(result.Msg)
+	td.logImplMetrics(result)
+	td.trackApplication(fmt.Sprintf("message %d from %s", result.Msg.CallSeqNum, result.Msg.From), 1, result.Receipt.GasUsed, elapsed)
+	if td.burns != nil {
+		td.burns.trackMessage(result)
+	}
 	return result
 }
 
@@ -358,75 +667,285 @@ func (td *TestDriver) ApplySigned(msg *types.Message) types.ApplyMessageResult {
 }
 
 func (td *TestDriver) ApplySignedOk(msg *types.Message) types.ApplyMessageResult {
-	return td.ApplySignedExpect(msg, EmptyReturnValue)
+	return td.ApplySignedMatching(msg, ExpectReceipt())
 }
 
 func (td *TestDriver) ApplySignedExpect(msg *types.Message, retval []byte) types.ApplyMessageResult {
-	return td.applyMessageSignedExpectCodeAndReturn(msg, exitcode.Ok, retval)
+	return td.ApplySignedMatching(msg, ExpectReceipt().WithReturnBytes(retval))
 }
 
 func (td *TestDriver) ApplySignedFailure(msg *types.Message, code exitcode.ExitCode) types.ApplyMessageResult {
-	return td.applyMessageExpectCodeAndReturn(msg, code, EmptyReturnValue)
+	return td.ApplySignedMatching(msg, ExpectReceipt().WithExitCode(code))
 }
 
-func (td *TestDriver) applyMessageSignedExpectCodeAndReturn(msg *types.Message, code exitcode.ExitCode, retval []byte) types.ApplyMessageResult {
+// ApplySignedMatching signs and applies `msg`, expecting its receipt to match `expected`.
+func (td *TestDriver) ApplySignedMatching(msg *types.Message, expected *ReceiptMatcher) types.ApplyMessageResult {
 	result := td.applyMessageSigned(msg)
-	td.validateResult(result, code, retval)
+	td.validateReceipt(result, expected)
 	td.validateState(msg, result)
 	return result
 }
+
+// ApplySignedExpectRejection applies `smsg`, expecting the implementation to reject it, e.g. for an invalid signature,
+// with an error and without changing the state. The test is skipped if the implementation doesn't declare the
+// SignatureVerification capability.
+func (td *TestDriver) ApplySignedExpectRejection(smsg *types.SignedMessage) {
+	td.checkContext()
+	if !td.Config.Capabilities().SignatureVerification {
+		td.T.Skip("implementation doesn't verify signatures")
+	}
+	prevRoot := td.State().Root()
+	_, err := td.validator.ApplySignedMessage(td.executionContext(), smsg)
+	assert.Error(td.T, err, "expected signed message to be rejected")
+	assert.Equal(td.T, prevRoot, td.State().Root(), "rejected message changed the state")
+}
+
+// SignMessage signs `msg` with the key of `signer`, which need not be its sender, and may be the ID address of an
+// account created by the driver. Messages are signed over the bytes of their CID.
+func (td *TestDriver) SignMessage(signer address.Address, msg *types.Message) *types.SignedMessage {
+	if signer.Protocol() == address.ID {
+		signer = td.ActorPubKey(signer)
+	}
+	sig, err := td.Wallet().Sign(signer, msg.Cid().Bytes())
+	require.NoError(td.T, err)
+	return &types.SignedMessage{Message: *msg, Signature: sig}
+}
+
 func (td *TestDriver) applyMessageSigned(msg *types.Message) (result types.ApplyMessageResult) {
+	td.checkContext()
 	defer func() {
 		if r := recover(); r != nil {
 			td.T.Fatalf("message application panicked: %v", r)
 		}
 	}()
-	serMsg, err := msg.Serialize()
+	smsgs := td.SignMessage(msg.From, msg)
+	gasKey := td.gasKey(msg)
+	start := time.Now()
+	result, err = td.validator.ApplySignedMessage(td.executionContext(), smsgs)
+	elapsed := time.Since(start)
 	require.NoError(td.T, err)
 
-	msgSig, err := td.Wallet().Sign(msg.From, serMsg)
-	require.NoError(td.T, err)
-
-	smsgs := &types.SignedMessage{
-		Message:   *msg,
-		Signature: msgSig,
+	result.ReceiverCode = td.receiverCode(result.Msg.To)
+	td.StateTracker.TrackResult(result)
+	td.trackGas(gasKey, result.Receipt.GasUsed)
+	td.recordApplied(result.Msg)
+	td.logImplMetrics(result)
+	td.trackApplication(fmt.Sprintf("message %d from %s", result.Msg.CallSeqNum, result.Msg.From), 1, result.Receipt.GasUsed, elapsed)
+	if td.burns != nil {
+		td.burns.trackMessage(result)
 	}
-	result, err = td.validator.ApplySignedMessage(td.ExeCtx.Epoch, smsgs)
-	require.NoError(td.T, err)
+	return result
+}
 
-	td.StateTracker.TrackResult(result)
+//
+// Read-only Calls
+//
+
+// Call executes `msg` at the current epoch without committing its effects, e.g. to probe a return value without
+// perturbing the scenario. The call's result isn't checked against recorded expectations.
+func (td *TestDriver) Call(msg *types.Message) types.ApplyMessageResult {
+	td.checkContext()
+	defer func() {
+		if r := recover(); r != nil {
+			td.T.Fatalf("message call panicked: %v", r)
+		}
+	}()
+
+	before := td.State().Root()
+	result, err := td.validator.CallMessage(td.executionContext(), msg)
+	require.NoError(td.T, err)
+	require.Equal(td.T, before, td.State().Root(), "read-only call modified state")
+	td.logImplMetrics(result)
 	return result
 }
 
-func (td *TestDriver) validateResult(result types.ApplyMessageResult, code exitcode.ExitCode, retval []byte) {
-	if td.Config.ValidateExitCode() {
-		assert.Equal(td.T, code, result.Receipt.ExitCode, "Expected ExitCode: %s Actual ExitCode: %s", code.Error(), result.Receipt.ExitCode.Error())
+// CallOk calls `msg`, asserting that it succeeds, and returns its return value.
+func (td *TestDriver) CallOk(msg *types.Message) []byte {
+	result := td.Call(msg)
+	require.Equal(td.T, exitcode.Ok, result.Receipt.ExitCode, "call of message failed")
+	return result.Receipt.ReturnValue
+}
+
+// CallInto calls `msg`, asserting that it succeeds, and decodes its return value into `out`.
+func (td *TestDriver) CallInto(msg *types.Message, out runtime_spec.CBORUnmarshaler) {
+	ret := td.CallOk(msg)
+	require.NoError(td.T, out.UnmarshalCBOR(bytes.NewReader(ret)))
+}
+
+// logImplMetrics reports any implementation specific metrics in the test log, sorted by name.
+func (td *TestDriver) logImplMetrics(result types.ApplyMessageResult) {
+	if len(result.ImplMetrics) == 0 {
+		return
+	}
+	names := make([]string, 0, len(result.ImplMetrics))
+	for name := range result.ImplMetrics {
+		names = append(names, name)
 	}
-	if td.Config.ValidateReturnValue() {
-		assert.Equal(td.T, retval, result.Receipt.ReturnValue, "Expected ReturnValue: %v Actual ReturnValue: %v", retval, result.Receipt.ReturnValue)
+	sort.Strings(names)
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, " %s=%d", name, result.ImplMetrics[name])
 	}
+	td.T.Logf("impl metrics for message %d from %s:%s", result.Msg.CallSeqNum, result.Msg.From, sb.String())
 }
 
 func (td *TestDriver) validateState(msg *types.Message, result types.ApplyMessageResult) {
 	if td.Config.ValidateGas() {
 		expectedGasUsed, ok := td.StateTracker.NextExpectedGas()
 		if ok {
-			assert.Equal(td.T, expectedGasUsed, result.Receipt.GasUsed, "Expected GasUsed: %d Actual GasUsed: %d", expectedGasUsed, result.Receipt.GasUsed)
+			ok := assert.Equal(td.T, expectedGasUsed, result.Receipt.GasUsed, "Expected GasUsed: %d Actual GasUsed: %d", expectedGasUsed, result.Receipt.GasUsed)
+			td.reportFailure(ok, report.GasUsed, td.applied-1, "", expectedGasUsed, result.Receipt.GasUsed)
+			if expected, found := td.StateTracker.ExpectedGasBreakdown(); found && td.Config.Capabilities().GasBreakdown {
+				td.validateGasBreakdown(expected, result.GasBreakdown)
+			}
 		} else {
-			td.T.Logf("WARNING (not a test failure): failed to find expected gas cost for message: %+v", msg)
+			tracker.ReportSoftFailure(td.T, tracker.MissingGasExpectation, fmt.Sprintf("failed to find expected gas cost for message: %+v", msg))
 		}
 	}
 	if td.Config.ValidateStateRoot() {
 		expectedRoot, found := td.StateTracker.NextExpectedStateRoot()
 		actualRoot := td.State().Root()
 		if found {
-			assert.Equal(td.T, expectedRoot, actualRoot, "Expected StateRoot: %s Actual StateRoot: %s", expectedRoot, actualRoot)
+			ok := assert.Equal(td.T, expectedRoot, actualRoot, "Expected StateRoot: %s Actual StateRoot: %s", expectedRoot, actualRoot)
+			td.reportFailure(ok, report.StateRoot, td.applied-1, "", expectedRoot, actualRoot)
+			if !ok {
+				td.diagnoseStateRoot()
+			}
 		} else {
-			td.T.Log("WARNING: failed to find expected state  root for message number")
+			tracker.ReportSoftFailure(td.T, tracker.MissingStateRootExpectation, fmt.Sprintf("failed to find expected state root for message: %+v", msg))
 		}
 	}
 }
 
+// validateGasBreakdown compares the gas used by the last message applied in each category of charge with that
+// expected, so that a difference in the total is localized to the charges responsible. Failures are reported under
+// the name of the category.
+func (td *TestDriver) validateGasBreakdown(expected, actual map[string]types.GasUnits) {
+	categories := make(map[string]struct{})
+	for c := range expected {
+		categories[c] = struct{}{}
+	}
+	for c := range actual {
+		categories[c] = struct{}{}
+	}
+	sorted := make([]string, 0, len(categories))
+	for c := range categories {
+		sorted = append(sorted, c)
+	}
+	sort.Strings(sorted)
+
+	for _, c := range sorted {
+		ok := assert.Equal(td.T, expected[c], actual[c], "Gas charge %q Expected GasUsed: %d Actual GasUsed: %d", c, expected[c], actual[c])
+		td.reportFailure(ok, report.GasUsed, td.applied-1, c, expected[c], actual[c])
+	}
+}
+
+// Checkpoint checks the current state root against that recorded for the checkpoint `name`, or records it. Unlike
+// the state roots checked after each message or tipset, a checkpoint's expectation is found by name, so it survives
+// changes to the messages applied before it, such as added setup.
+func (td *TestDriver) Checkpoint(name string) {
+	td.checkpointRoot(name, td.State().Root())
+}
+
+// AssertGenesisRoot checks the state root once the builder's actors were created, before the driver created its
+// default miner, against the canonical root of DefaultBuiltinActorsState, see CanonicalStateRoot, so the builder must
+// have created those actors alone. It is the genesis root every implementation must construct byte for byte, and is
+// checked before any message is applied.
+func (td *TestDriver) AssertGenesisRoot() {
+	if !td.Config.ValidateStateRoot() {
+		return
+	}
+	expectedRoot, err := CanonicalStateRoot(DefaultBuiltinActorsState)
+	require.NoError(td.T, err)
+	ok := assert.Equal(td.T, expectedRoot, td.genesisRoot, "Genesis Expected StateRoot: %s Actual StateRoot: %s", expectedRoot, td.genesisRoot)
+	td.reportFailure(ok, report.Checkpoint, report.NoMessage, "genesis", expectedRoot, td.genesisRoot)
+}
+
+func (td *TestDriver) checkpointRoot(name string, actualRoot cid.Cid) {
+	td.StateTracker.TrackCheckpoint(name, actualRoot)
+	if !td.Config.ValidateStateRoot() || tracker.RecordingEnabled() {
+		return
+	}
+	expectedRoot, found := td.StateTracker.ExpectedCheckpoint(name)
+	if found {
+		ok := assert.Equal(td.T, expectedRoot, actualRoot, "Checkpoint %q Expected StateRoot: %s Actual StateRoot: %s", name, expectedRoot, actualRoot)
+		td.reportFailure(ok, report.Checkpoint, report.NoMessage, name, expectedRoot, actualRoot)
+		if !ok && actualRoot.Equals(td.State().Root()) {
+			td.diagnoseStateRoot()
+		}
+	} else {
+		tracker.ReportSoftFailure(td.T, tracker.MissingCheckpointExpectation, fmt.Sprintf("failed to find expected state root for checkpoint %q", name))
+	}
+}
+
+// gasKey returns the key under which the gas used by `msg` is aggregated for the gas baseline: the code of its
+// receiver, as it is before the message is applied, and its method, e.g. "fil/1/multisig.2".
+func (td *TestDriver) gasKey(msg *types.Message) string {
+	if !tracker.GasBaselineEnabled() {
+		return ""
+	}
+	code := "<no actor>"
+	if actr, err := td.State().Actor(msg.To); err == nil {
+		code = actorCodeName(actr.Code())
+	}
+	return fmt.Sprintf("%s.%d", code, msg.Method)
+}
+
+// trackApplication adds the application of `messages` messages, together using `gasUsed` and taking `elapsed`, to the
+// profile of the test's suite, if profiling is enabled.
+func (td *TestDriver) trackApplication(desc string, messages int, gasUsed types.GasUnits, elapsed time.Duration) {
+	if tracker.ProfilingEnabled() {
+		tracker.TrackApplication(td.T, desc, messages, gasUsed, elapsed)
+	}
+}
+
+func (td *TestDriver) trackGas(key string, gasUsed types.GasUnits) {
+	if tracker.GasBaselineEnabled() {
+		tracker.TrackGas(key, gasUsed)
+	}
+}
+
+// actorCodeName returns the name embedded in a builtin actor code CID, e.g. "fil/1/account", or the CID itself for
+// other codes.
+func actorCodeName(code cid.Cid) string {
+	mh, err := multihash.Decode(code.Hash())
+	if err != nil || mh.Code != multihash.IDENTITY {
+		return code.String()
+	}
+	return string(mh.Digest)
+}
+
+// receiverCode returns the name of the code of the actor at `addr`, or "" if there is none, e.g. because the transfer
+// which would have created it failed.
+func (td *TestDriver) receiverCode(addr address.Address) string {
+	act, err := td.State().Actor(addr)
+	if err != nil || act == nil {
+		return ""
+	}
+	return actorCodeName(act.Code())
+}
+
+// recordApplied counts the application of `msg` and records it for the run's report.
+func (td *TestDriver) recordApplied(msg types.Message) {
+	report.RecordMessage(td.T, td.applied, fmt.Sprintf("method %d of %s from %s nonce %d", msg.Method, msg.To, msg.From, msg.CallSeqNum))
+	td.applied++
+}
+
+// reportFailure records the failure of a check for the run's report, unless the check was `ok`. The check concerned
+// the message at `index`, or the checkpoint or burn category `name`.
+func (td *TestDriver) reportFailure(ok bool, kind report.Kind, index int, name string, expected, actual interface{}) {
+	if ok {
+		return
+	}
+	report.Record(td.T, report.Failure{
+		Kind:         kind,
+		MessageIndex: index,
+		Name:         name,
+		Expected:     fmt.Sprint(expected),
+		Actual:       fmt.Sprint(actual),
+	})
+}
+
 func (td *TestDriver) AssertNoActor(addr address.Address) {
 	_, err := td.State().Actor(addr)
 	assert.Error(td.T, err, "expected no such actor %s", addr)
@@ -438,6 +957,13 @@ func (td *TestDriver) GetBalance(addr address.Address) abi_spec.TokenAmount {
 	return actr.Balance()
 }
 
+// GetCallSeqNum returns the call sequence number (nonce) of the actor at `addr`.
+func (td *TestDriver) GetCallSeqNum(addr address.Address) uint64 {
+	actr, err := td.State().Actor(addr)
+	require.NoError(td.T, err)
+	return actr.CallSeqNum()
+}
+
 func (td *TestDriver) GetHead(addr address.Address) cid.Cid {
 	actr, err := td.State().Actor(addr)
 	require.NoError(td.T, err)
@@ -451,6 +977,12 @@ func (td *TestDriver) AssertBalance(addr address.Address, expected abi_spec.Toke
 	assert.Equal(td.T, expected, actr.Balance(), fmt.Sprintf("expected actor %s balance: %s, actual balance: %s", addr, expected, actr.Balance()))
 }
 
+// AssertCallSeqNum checks an actor has an expected call sequence number, regardless of its balance.
+func (td *TestDriver) AssertCallSeqNum(addr address.Address, expected uint64) {
+	actual := td.GetCallSeqNum(addr)
+	assert.Equal(td.T, expected, actual, "expected actor %s callSeqNum: %d, actual: %d", addr, expected, actual)
+}
+
 // Checks that after executing a message, the sender actor's balance is as expected, given
 // - the actor's previous balance
 // - the gas limit of the executed message
@@ -466,11 +998,35 @@ func (td *TestDriver) AssertActorChange(addr address.Address, prevBalance abi_sp
 	assert.Equal(td.T, callSeqNum, actr.CallSeqNum(), fmt.Sprintf("expected actor %s callSeqNum: %d, actual : %d", addr, callSeqNum, actr.CallSeqNum()))
 }
 
+// AssertEvent asserts that applying the message of `result` emitted `expected`. Nothing is checked if the
+// implementation doesn't report events.
+func (td *TestDriver) AssertEvent(result types.ApplyMessageResult, expected types.Event) {
+	if !td.Config.Capabilities().ExecTraces {
+		td.T.Logf("implementation doesn't report events, not checking event %q emitted by %s", expected.Type, expected.Emitter)
+		return
+	}
+	for _, e := range result.Events {
+		if e.Emitter == expected.Emitter && e.Type == expected.Type && bytes.Equal(e.Data, expected.Data) {
+			return
+		}
+	}
+	assert.Fail(td.T, "expected event not emitted", "expected %q emitted by %s with data %x, actual events: %+v", expected.Type, expected.Emitter, expected.Data, result.Events)
+}
+
 func (td *TestDriver) AssertHead(addr address.Address, expected cid.Cid) {
 	head := td.GetHead(addr)
 	assert.Equal(td.T, expected, head, "expected actor %s head %s, actual %s", addr, expected, head)
 }
 
+// AssertHeadUnchanged runs `fn`, typically a message application, and asserts the head of the actor at `addr`
+// is identical afterward. Use it to check read-only methods leave their receiver's state untouched.
+func (td *TestDriver) AssertHeadUnchanged(addr address.Address, fn func()) {
+	td.T.Helper()
+	before := td.GetHead(addr)
+	fn()
+	td.AssertHead(addr, before)
+}
+
 func (td *TestDriver) AssertBalanceCallback(addr address.Address, thing func(actorBalance abi_spec.TokenAmount) bool) {
 	actr, err := td.State().Actor(addr)
 	require.NoError(td.T, err)
@@ -549,9 +1105,9 @@ func computeInitActorExecReturn(t testing.TB, from address.Address, originatorCa
 
 func (td *TestDriver) MustCreateAndVerifyMultisigActor(nonce uint64, value abi_spec.TokenAmount, multisigAddr address.Address, from address.Address, params *multisig_spec.ConstructorParams, code exitcode.ExitCode, retval []byte) {
 	/* Create the Multisig actor*/
-	td.applyMessageExpectCodeAndReturn(
+	td.ApplyMatching(
 		td.MessageProducer.CreateMultisigActor(from, params.Signers, params.UnlockDuration, params.NumApprovalsThreshold, chain.Nonce(nonce), chain.Value(value)),
-		code, retval)
+		ExpectReceipt().WithExitCode(code).WithReturnBytes(retval))
 	/* Assert the actor state was setup as expected */
 	pendingTxMapRoot, err := adt_spec.MakeEmptyMap(newMockStore()).Root()
 	require.NoError(td.T, err)
@@ -574,22 +1130,3 @@ func (td *TestDriver) MustCreateAndVerifyMultisigActor(nonce uint64, value abi_s
 	})
 	td.AssertBalance(multisigAddr, value)
 }
-
-type RewardSummary struct {
-	Treasury           abi_spec.TokenAmount
-	SimpleSupply       abi_spec.TokenAmount
-	BaselineSupply     abi_spec.TokenAmount
-	NextPerEpochReward abi_spec.TokenAmount
-	NextPerBlockReward abi_spec.TokenAmount
-}
-
-func (td *TestDriver) GetRewardSummary() *RewardSummary {
-	var rst reward_spec.State
-	td.GetActorState(builtin_spec.RewardActorAddr, &rst)
-
-	return &RewardSummary{
-		Treasury:           td.GetBalance(builtin_spec.RewardActorAddr),
-		NextPerEpochReward: rst.ThisEpochReward,
-		NextPerBlockReward: big_spec.Div(rst.ThisEpochReward, big_spec.NewInt(builtin_spec.ExpectedLeadersPerEpoch)),
-	}
-}
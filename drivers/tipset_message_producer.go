@@ -1,12 +1,21 @@
 package drivers
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
 	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/ipfs/go-cid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/chain/wallet"
+	"github.com/filecoin-project/chain-validation/report"
+	"github.com/filecoin-project/chain-validation/tracker"
 )
 
 type TipSetMessageBuilder struct {
@@ -45,22 +54,63 @@ func (t *TipSetMessageBuilder) ApplyAndValidate() types.ApplyTipSetResult {
 	return result
 }
 
+// ApplyExpectRejection applies the tipset expecting the implementation to reject it outright, as it must for a block
+// carrying an invalid signature. The state must be left unchanged. The test is skipped if the implementation doesn't
+// declare the BlockValidation capability, as its applier then needn't validate blocks.
+func (t *TipSetMessageBuilder) ApplyExpectRejection() {
+	t.driver.checkContext()
+	if !t.driver.Config.Capabilities().BlockValidation {
+		t.driver.T.Skip("implementation doesn't validate blocks")
+	}
+	var blks []types.BlockMessagesInfo
+	for _, b := range t.bbs {
+		blks = append(blks, b.build())
+	}
+	prevRoot := t.driver.State().Root()
+	_, err := t.driver.validator.ApplyTipSetMessages(t.driver.executionContext(), blks, t.driver.Randomness())
+	assert.Error(t.driver.T, err, "expected tipset to be rejected")
+	assert.Equal(t.driver.T, prevRoot, t.driver.State().Root(), "rejected tipset changed the state")
+
+	t.Clear()
+}
+
 func (t *TipSetMessageBuilder) apply() types.ApplyTipSetResult {
+	t.driver.checkContext()
 	var blks []types.BlockMessagesInfo
 	for _, b := range t.bbs {
 		blks = append(blks, b.build())
 	}
-	result, err := t.driver.validator.ApplyTipSetMessages(t.driver.ExeCtx.Epoch, blks, t.driver.Randomness())
+	start := time.Now()
+	result, err := t.driver.validator.ApplyTipSetMessages(t.driver.executionContext(), blks, t.driver.Randomness())
+	elapsed := time.Since(start)
 	require.NoError(t.driver.T, err)
 
 	t.driver.StateTracker.TrackResult(result)
+	var gasUsed types.GasUnits
+	for _, rct := range result.Receipts {
+		gasUsed += rct.GasUsed
+	}
+	t.driver.trackApplication(fmt.Sprintf("tipset at epoch %d", t.driver.ExeCtx.Epoch), len(result.Receipts), gasUsed, elapsed)
+	for i := range result.Receipts {
+		// Receipts don't identify their messages, so the gas of messages in tipsets is aggregated together.
+		t.driver.trackGas("tipset", result.Receipts[i].GasUsed)
+		report.RecordMessage(t.driver.T, t.driver.applied, fmt.Sprintf("receipt %d of tipset at epoch %d", i, t.driver.ExeCtx.Epoch))
+		t.driver.applied++
+	}
+	if t.driver.burns != nil {
+		t.driver.burns.trackTipSet()
+	}
 	return result
 }
 
+// validateResult validates each receipt against the expectation bound to its message, and fails the test once with
+// the differences of every message from its expectation.
 func (t *TipSetMessageBuilder) validateResult(result types.ApplyTipSetResult) {
-	expected := []ExpectedResult{}
+	var expected []expectedReceipt
 	for _, b := range t.bbs {
-		expected = append(expected, b.expectedResults...)
+		// Each block's BLS messages are applied before its SECP messages.
+		expected = append(expected, b.blsExpected...)
+		expected = append(expected, b.secpExpected...)
 	}
 
 	if len(result.Receipts) > len(expected) {
@@ -68,38 +118,81 @@ func (t *TipSetMessageBuilder) validateResult(result types.ApplyTipSetResult) {
 		return
 	}
 
-	for i := range result.Receipts {
-		if t.driver.Config.ValidateExitCode() {
-			assert.Equal(t.driver.T, expected[i].ExitCode, result.Receipts[i].ExitCode, "Message Number: %d Expected ExitCode: %s Actual ExitCode: %s", i, expected[i].ExitCode.Error(), result.Receipts[i].ExitCode.Error())
+	// Index among the messages applied by the test of the first message of the tipset.
+	first := t.driver.applied - len(result.Receipts)
+	var diff strings.Builder
+	for i, e := range expected {
+		if i >= len(result.Receipts) {
+			fmt.Fprintf(&diff, "\n  message %d (%s nonce %d): no receipt", i, e.msg.From, e.msg.CallSeqNum)
+			continue
 		}
-		if t.driver.Config.ValidateReturnValue() {
-			assert.Equal(t.driver.T, expected[i].ReturnVal, result.Receipts[i].ReturnValue, "Message Number: %d Expected ReturnValue: %v Actual ReturnValue: %v", i, expected[i].ReturnVal, result.Receipts[i].ReturnValue)
+		for _, mm := range e.matcher.mismatches(t.driver.Config, result.Receipts[i]) {
+			fmt.Fprintf(&diff, "\n  message %d (%s nonce %d): %s", i, e.msg.From, e.msg.CallSeqNum, mm)
+			t.driver.reportFailure(false, mm.kind, first+i, mm.name, mm.expected, mm.actual)
 		}
 	}
+	if diff.Len() > 0 {
+		assert.Fail(t.driver.T, "receipts don't match expectations", "tipset at epoch %d:%s", t.driver.ExeCtx.Epoch, diff.String())
+	}
 }
 
 func (t *TipSetMessageBuilder) validateState(result types.ApplyTipSetResult) {
 	if t.driver.Config.ValidateGas() {
+		first := t.driver.applied - len(result.Receipts)
 		for i := range result.Receipts {
 			expectedGas, found := t.driver.StateTracker.NextExpectedGas()
 			if found {
-				assert.Equal(t.driver.T, expectedGas, result.Receipts[i].GasUsed, "Message Number: %d Expected GasUsed: %d Actual GasUsed: %d", i, expectedGas, result.Receipts[i].GasUsed)
+				ok := assert.Equal(t.driver.T, expectedGas, result.Receipts[i].GasUsed, "Message Number: %d Expected GasUsed: %d Actual GasUsed: %d", i, expectedGas, result.Receipts[i].GasUsed)
+				t.driver.reportFailure(ok, report.GasUsed, first+i, "", expectedGas, result.Receipts[i].GasUsed)
 			} else {
-				t.driver.T.Logf("WARNING: failed to find expected gas cost for message number: %d", i)
+				tracker.ReportSoftFailure(t.driver.T, tracker.MissingGasExpectation, fmt.Sprintf("failed to find expected gas cost for message number: %d", i))
 			}
 		}
 	}
+	if t.driver.Config.Capabilities().ReceiptsRoot {
+		t.validateReceiptsRoot(result)
+	}
 	if t.driver.Config.ValidateStateRoot() {
 		expectedRoot, found := t.driver.StateTracker.NextExpectedStateRoot()
 		actualRoot := t.driver.State().Root()
 		if found {
-			assert.Equal(t.driver.T, expectedRoot, actualRoot, "Expected StateRoot: %s Actual StateRoot: %s", expectedRoot, actualRoot)
+			ok := assert.Equal(t.driver.T, expectedRoot, actualRoot, "Expected StateRoot: %s Actual StateRoot: %s", expectedRoot, actualRoot)
+			t.driver.reportFailure(ok, report.StateRoot, report.NoMessage, "", expectedRoot, actualRoot)
+			if !ok {
+				t.driver.diagnoseStateRoot()
+			}
 		} else {
-			t.driver.T.Log("WARNING: failed to find expected state  root for message number")
+			tracker.ReportSoftFailure(t.driver.T, tracker.MissingStateRootExpectation, "failed to find expected state root for tipset")
 		}
 	}
 }
 
+// validateReceiptsRoot checks the receipts root reported by the implementation against the root of the AMT of the
+// receipts it returned, and against the recorded root, if any. Either may differ while each receipt matches its
+// expectation, e.g. if the implementation serializes receipts differently.
+func (t *TipSetMessageBuilder) validateReceiptsRoot(result types.ApplyTipSetResult) {
+	expectedRoot, found := t.driver.StateTracker.NextExpectedReceiptsRoot()
+	if !assert.NotEmpty(t.driver.T, result.ReceiptsRoot, "implementation reported no receipts root for tipset") {
+		return
+	}
+	actualRoot, err := cid.Decode(result.ReceiptsRoot)
+	if !assert.NoError(t.driver.T, err, "invalid receipts root %q", result.ReceiptsRoot) {
+		return
+	}
+
+	builtRoot, err := ReceiptsRoot(result.Receipts)
+	require.NoError(t.driver.T, err)
+	ok := assert.Equal(t.driver.T, builtRoot, actualRoot, "receipts root doesn't match the AMT of the receipts returned")
+	t.driver.reportFailure(ok, report.ReceiptsRoot, report.NoMessage, "built", builtRoot, actualRoot)
+
+	if found {
+		ok := assert.Equal(t.driver.T, expectedRoot, actualRoot, "Expected ReceiptsRoot: %s Actual ReceiptsRoot: %s", expectedRoot, actualRoot)
+		t.driver.reportFailure(ok, report.ReceiptsRoot, report.NoMessage, "", expectedRoot, actualRoot)
+	} else {
+		tracker.ReportSoftFailure(t.driver.T, tracker.MissingReceiptsRootExpectation, "failed to find expected receipts root for tipset")
+	}
+}
+
 func (t *TipSetMessageBuilder) Clear() {
 	t.bbs = nil
 }
@@ -107,42 +200,45 @@ func (t *TipSetMessageBuilder) Clear() {
 type BlockBuilder struct {
 	TD *TestDriver
 
-	miner       address.Address
-	ticketCount int64
+	miner        address.Address
+	ticketCount  int64
+	ticket       []byte
+	timestamp    uint64
+	aggregateBLS bool
 
 	secpMsgs []*types.SignedMessage
 	blsMsgs  []*types.Message
 
-	expectedResults []ExpectedResult
+	// Expected receipts of the messages that aren't expected to be dropped, in the order of their inclusion.
+	blsExpected  []expectedReceipt
+	secpExpected []expectedReceipt
 }
 
-type ExpectedResult struct {
-	ExitCode  exitcode.ExitCode
-	ReturnVal []byte
+// expectedReceipt binds the expected receipt of a message to the message, for reporting.
+type expectedReceipt struct {
+	msg     *types.Message
+	matcher *ReceiptMatcher
 }
 
 func NewBlockBuilder(td *TestDriver, miner address.Address) *BlockBuilder {
 	return &BlockBuilder{
-		TD:              td,
-		miner:           miner,
-		ticketCount:     1,
-		secpMsgs:        nil,
-		blsMsgs:         nil,
-		expectedResults: nil,
+		TD:          td,
+		miner:       miner,
+		ticketCount: 1,
+		secpMsgs:    nil,
+		blsMsgs:     nil,
 	}
 }
 
-func (bb *BlockBuilder) addResult(code exitcode.ExitCode, retval []byte) {
-	bb.expectedResults = append(bb.expectedResults, ExpectedResult{
-		ExitCode:  code,
-		ReturnVal: retval,
-	})
+// WithBLSMessageMatching includes a BLS message, expecting its receipt to match `expected`.
+func (bb *BlockBuilder) WithBLSMessageMatching(bm *types.Message, expected *ReceiptMatcher) *BlockBuilder {
+	bb.blsMsgs = append(bb.blsMsgs, bm)
+	bb.blsExpected = append(bb.blsExpected, expectedReceipt{bm, expected})
+	return bb
 }
 
 func (bb *BlockBuilder) WithBLSMessageOk(blsMsg *types.Message) *BlockBuilder {
-	bb.blsMsgs = append(bb.blsMsgs, blsMsg)
-	bb.addResult(exitcode.Ok, EmptyReturnValue)
-	return bb
+	return bb.WithBLSMessageMatching(blsMsg, ExpectReceipt())
 }
 
 func (bb *BlockBuilder) WithBLSMessageDropped(blsMsg *types.Message) *BlockBuilder {
@@ -151,36 +247,28 @@ func (bb *BlockBuilder) WithBLSMessageDropped(blsMsg *types.Message) *BlockBuild
 }
 
 func (bb *BlockBuilder) WithBLSMessageAndCode(bm *types.Message, code exitcode.ExitCode) *BlockBuilder {
-	bb.blsMsgs = append(bb.blsMsgs, bm)
-	bb.addResult(code, EmptyReturnValue)
-	return bb
+	return bb.WithBLSMessageMatching(bm, ExpectReceipt().WithExitCode(code))
 }
 
 func (bb *BlockBuilder) WithBLSMessageAndRet(bm *types.Message, retval []byte) *BlockBuilder {
-	bb.blsMsgs = append(bb.blsMsgs, bm)
-	bb.addResult(exitcode.Ok, retval)
-	return bb
+	return bb.WithBLSMessageMatching(bm, ExpectReceipt().WithReturnBytes(retval))
+}
+
+// WithSECPMessageMatching includes a message signed by its sender, expecting its receipt to match `expected`.
+func (bb *BlockBuilder) WithSECPMessageMatching(bm *types.Message, expected *ReceiptMatcher) *BlockBuilder {
+	return bb.WithSignedSECPMessageMatching(bb.toSignedMessage(bm), expected)
 }
 
 func (bb *BlockBuilder) WithSECPMessageAndCode(bm *types.Message, code exitcode.ExitCode) *BlockBuilder {
-	secpMsg := bb.toSignedMessage(bm)
-	bb.secpMsgs = append(bb.secpMsgs, secpMsg)
-	bb.addResult(code, EmptyReturnValue)
-	return bb
+	return bb.WithSECPMessageMatching(bm, ExpectReceipt().WithExitCode(code))
 }
 
 func (bb *BlockBuilder) WithSECPMessageAndRet(bm *types.Message, retval []byte) *BlockBuilder {
-	secpMsg := bb.toSignedMessage(bm)
-	bb.secpMsgs = append(bb.secpMsgs, secpMsg)
-	bb.addResult(exitcode.Ok, retval)
-	return bb
+	return bb.WithSECPMessageMatching(bm, ExpectReceipt().WithReturnBytes(retval))
 }
 
 func (bb *BlockBuilder) WithSECPMessageOk(bm *types.Message) *BlockBuilder {
-	secpMsg := bb.toSignedMessage(bm)
-	bb.secpMsgs = append(bb.secpMsgs, secpMsg)
-	bb.addResult(exitcode.Ok, EmptyReturnValue)
-	return bb
+	return bb.WithSECPMessageMatching(bm, ExpectReceipt())
 }
 
 func (bb *BlockBuilder) WithSECPMessageDropped(bm *types.Message) *BlockBuilder {
@@ -189,11 +277,71 @@ func (bb *BlockBuilder) WithSECPMessageDropped(bm *types.Message) *BlockBuilder
 	return bb
 }
 
+// WithSignedSECPMessageMatching includes a message with a signature of the caller's making, which may be invalid,
+// expecting its receipt to match `expected`.
+func (bb *BlockBuilder) WithSignedSECPMessageMatching(sm *types.SignedMessage, expected *ReceiptMatcher) *BlockBuilder {
+	bb.secpMsgs = append(bb.secpMsgs, sm)
+	bb.secpExpected = append(bb.secpExpected, expectedReceipt{&sm.Message, expected})
+	return bb
+}
+
+// WithSignedSECPMessageAndCode includes a message with a signature of the caller's making, which may be invalid.
+func (bb *BlockBuilder) WithSignedSECPMessageAndCode(sm *types.SignedMessage, code exitcode.ExitCode) *BlockBuilder {
+	return bb.WithSignedSECPMessageMatching(sm, ExpectReceipt().WithExitCode(code))
+}
+
+// WithSignedSECPMessageDropped includes a message with a signature of the caller's making, expecting no receipt.
+func (bb *BlockBuilder) WithSignedSECPMessageDropped(sm *types.SignedMessage) *BlockBuilder {
+	bb.secpMsgs = append(bb.secpMsgs, sm)
+	return bb
+}
+
 func (bb *BlockBuilder) WithTicketCount(count int64) *BlockBuilder {
 	bb.ticketCount = count
 	return bb
 }
 
+// WithTicket sets the VRF proof of the block's ticket.
+func (bb *BlockBuilder) WithTicket(ticket []byte) *BlockBuilder {
+	bb.ticket = ticket
+	return bb
+}
+
+// WithTimestamp sets the timestamp of the block's header.
+func (bb *BlockBuilder) WithTimestamp(timestamp uint64) *BlockBuilder {
+	bb.timestamp = timestamp
+	return bb
+}
+
+// WithBLSAggregate makes the block carry a real aggregate of its BLS messages' signatures, signed with the driver's
+// key manager, so that implementations verify it rather than bypass verification. Every BLS message sender must
+// then be a BLS account known to the key manager.
+func (bb *BlockBuilder) WithBLSAggregate() *BlockBuilder {
+	bb.aggregateBLS = true
+	return bb
+}
+
+// blsAggregate signs each BLS message's CID with its sender's key and aggregates the signatures.
+func (bb *BlockBuilder) blsAggregate() *crypto.Signature {
+	sigs := make([]crypto.Signature, len(bb.blsMsgs))
+	for i, m := range bb.blsMsgs {
+		from := m.From
+		if from.Protocol() == address.ID {
+			from = bb.TD.ActorPubKey(from)
+		}
+		if from.Protocol() != address.BLS {
+			bb.TD.T.Fatalf("Invalid address for BLS signature, address protocol: %v", from.Protocol())
+		}
+		sig, err := bb.TD.Wallet().Sign(from, m.Cid().Bytes())
+		require.NoError(bb.TD.T, err)
+		sigs[i] = sig
+	}
+
+	agg, err := wallet.AggregateBLSSignatures(sigs)
+	require.NoError(bb.TD.T, err)
+	return agg
+}
+
 func (bb *BlockBuilder) toSignedMessage(m *types.Message) *types.SignedMessage {
 	from := m.From
 	if from.Protocol() == address.ID {
@@ -202,23 +350,21 @@ func (bb *BlockBuilder) toSignedMessage(m *types.Message) *types.SignedMessage {
 	if from.Protocol() != address.SECP256K1 {
 		bb.TD.T.Fatalf("Invalid address for SECP signature, address protocol: %v", from.Protocol())
 	}
-	raw, err := m.Serialize()
-	require.NoError(bb.TD.T, err)
-
-	sig, err := bb.TD.Wallet().Sign(from, raw)
-	require.NoError(bb.TD.T, err)
-
-	return &types.SignedMessage{
-		Message:   *m,
-		Signature: sig,
-	}
+	return bb.TD.SignMessage(from, m)
 }
 
 func (bb *BlockBuilder) build() types.BlockMessagesInfo {
+	var agg *crypto.Signature
+	if bb.aggregateBLS {
+		agg = bb.blsAggregate()
+	}
 	return types.BlockMessagesInfo{
 		BLSMessages:  bb.blsMsgs,
 		SECPMessages: bb.secpMsgs,
 		Miner:        bb.miner,
 		TicketCount:  bb.ticketCount,
+		Ticket:       bb.ticket,
+		Timestamp:    bb.timestamp,
+		BLSAggregate: agg,
 	}
 }
@@ -0,0 +1,124 @@
+package drivers
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/stretchr/testify/require"
+)
+
+// Identity is a named actor known to a test, with each of its address forms.
+type Identity struct {
+	Name string
+	// ID address of the actor.
+	ID address.Address
+	// Robust address of the actor: an account's public key address, or the address assigned by the init actor.
+	Robust address.Address
+}
+
+// IsAccount returns whether the identity is an account, whose robust address is a public key that can sign.
+func (i *Identity) IsAccount() bool {
+	p := i.Robust.Protocol()
+	return p == address.SECP256K1 || p == address.BLS
+}
+
+// Matches returns whether `addr` is either of the identity's addresses.
+func (i *Identity) Matches(addr address.Address) bool {
+	return addr == i.ID || (addr != address.Undef && addr == i.Robust)
+}
+
+// Wallet is an address book of the named identities of a test, such as "alice" or "miner1-worker". Names are
+// hierarchical: an actor owning others, such as a miner, has them named by its own name followed by a dash and
+// their role. Suites look identities up by name or by either address, rather than keeping parallel variables for
+// each address form.
+type Wallet struct {
+	d      *StateDriver
+	byName map[string]*Identity
+}
+
+// Identities returns the driver's address book, which is empty until identities are added.
+func (d *StateDriver) Identities() *Wallet {
+	if d.identities == nil {
+		d.identities = &Wallet{d: d, byName: make(map[string]*Identity)}
+	}
+	return d.identities
+}
+
+// NewAccount creates an account actor named `name`. Its key is derived from the name, so the account has the same
+// addresses in every run and implementation.
+func (w *Wallet) NewAccount(name string, addrType address.Protocol, balance abi_spec.TokenAmount) *Identity {
+	pk, id := w.d.NewAccountActorFromSeed(addrType, []byte(name), balance)
+	return w.Add(name, id, pk)
+}
+
+// NewMiner creates a miner actor named `name`, like StateDriver.NewDefaultMinerActor, and adds its owner and worker as
+// "<name>-owner" and "<name>-worker".
+func (w *Wallet) NewMiner(name string) *Identity {
+	id, info := w.d.NewDefaultMinerActor()
+	w.Add(name+"-owner", info.OwnerID, info.Owner)
+	w.Add(name+"-worker", info.WorkerID, info.Worker)
+	return w.Add(name, id, info.Robust)
+}
+
+// Add names an existing actor with ID address `id` and robust address `robust`, which may be address.Undef if it
+// isn't known.
+func (w *Wallet) Add(name string, id, robust address.Address) *Identity {
+	require.Equal(w.d.tb, address.ID, id.Protocol(), "identity %q must have an ID address, got %s", name, id)
+	_, exists := w.byName[name]
+	require.False(w.d.tb, exists, "duplicate identity %q", name)
+
+	ident := &Identity{Name: name, ID: id, Robust: robust}
+	w.byName[name] = ident
+	return ident
+}
+
+// Get returns the identity named `name`, failing the test if there is none.
+func (w *Wallet) Get(name string) *Identity {
+	ident, ok := w.byName[name]
+	require.True(w.d.tb, ok, "no identity named %q", name)
+	return ident
+}
+
+// ID returns the ID address of the identity named `name`.
+func (w *Wallet) ID(name string) address.Address {
+	return w.Get(name).ID
+}
+
+// Robust returns the robust address of the identity named `name`.
+func (w *Wallet) Robust(name string) address.Address {
+	return w.Get(name).Robust
+}
+
+// Lookup returns the identity with either address `addr`, if there is one.
+func (w *Wallet) Lookup(addr address.Address) (*Identity, bool) {
+	for _, ident := range w.byName {
+		if ident.Matches(addr) {
+			return ident, true
+		}
+	}
+	return nil, false
+}
+
+// Children returns the identities named under `name`, such as a miner's owner and worker, sorted by name.
+func (w *Wallet) Children(name string) []*Identity {
+	var children []*Identity
+	for n, ident := range w.byName {
+		if strings.HasPrefix(n, name+"-") {
+			children = append(children, ident)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children
+}
+
+// Names returns the names of all identities, sorted.
+func (w *Wallet) Names() []string {
+	names := make([]string, 0, len(w.byName))
+	for n := range w.byName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -32,6 +32,7 @@ require (
 	golang.org/x/sys v0.0.0-20200427175716-29b57079015a // indirect
 	golang.org/x/tools v0.0.0-20200318150045-ba25ddc85566 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1
+	google.golang.org/grpc v1.20.1
 	honnef.co/go/tools v0.0.1-2020.1.3 // indirect
 )
 
@@ -0,0 +1,136 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Detail  string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, with a test suite per suite and a test case per message applied by each
+// test, failed if any check of the message failed. Failures of checks not concerning a single message, such as of
+// checkpoints, are reported by a test case for the test as a whole.
+func WriteJUnit(w io.Writer) error {
+	type caseKey struct {
+		test  string
+		index int
+	}
+	bySuite := make(map[string]*junitTestSuite)
+	var suiteOrder []string
+	cases := make(map[caseKey]*junitTestCase)
+	var caseOrder []caseKey
+	suiteOf := make(map[caseKey]string)
+	// kinds of the failed checks of each case
+	kinds := make(map[caseKey][]Kind)
+
+	addCase := func(suite, test string, index int, desc string) caseKey {
+		key := caseKey{test, index}
+		if _, ok := cases[key]; ok {
+			return key
+		}
+		if _, ok := bySuite[suite]; !ok {
+			bySuite[suite] = &junitTestSuite{Name: suite}
+			suiteOrder = append(suiteOrder, suite)
+		}
+		name := test
+		if index != NoMessage {
+			name = fmt.Sprintf("%s#%d %s", test, index, desc)
+		}
+		cases[key] = &junitTestCase{Name: name, ClassName: test}
+		caseOrder = append(caseOrder, key)
+		suiteOf[key] = suite
+		return key
+	}
+
+	for _, m := range Messages() {
+		addCase(m.Suite, m.Test, m.Index, m.Desc)
+	}
+	for _, f := range Failures() {
+		key := caseKey{f.Test, f.MessageIndex}
+		if _, ok := cases[key]; !ok {
+			key = addCase(f.Suite, f.Test, f.MessageIndex, "")
+		}
+		c := cases[key]
+		if c.Failure == nil {
+			c.Failure = &junitFailure{}
+		}
+		if !containsKind(kinds[key], f.Kind) {
+			kinds[key] = append(kinds[key], f.Kind)
+		}
+		c.Failure.Type = joinKinds(kinds[key])
+		c.Failure.Message = c.Failure.Type
+		c.Failure.Detail += formatFailure(f) + "\n"
+	}
+
+	var out junitTestSuites
+	for _, key := range caseOrder {
+		suite := bySuite[suiteOf[key]]
+		suite.Cases = append(suite.Cases, *cases[key])
+		suite.Tests++
+		if cases[key].Failure != nil {
+			suite.Failures++
+		}
+	}
+	for _, name := range suiteOrder {
+		out.Suites = append(out.Suites, *bySuite[name])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func formatFailure(f Failure) string {
+	if f.Name != "" {
+		return fmt.Sprintf("%s %s: expected %s, actual %s", f.Kind, f.Name, f.Expected, f.Actual)
+	}
+	return fmt.Sprintf("%s: expected %s, actual %s", f.Kind, f.Expected, f.Actual)
+}
+
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func joinKinds(kinds []Kind) string {
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,207 @@
+// Package report collects the failed checks of test drivers across a run of the suites into a machine-readable
+// report, so that implementations can triage many failures without scraping test output.
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// FileEnvVar names the file WriteFile writes the report to. No report is written if it is unset.
+const FileEnvVar = "CHAIN_VALIDATION_REPORT"
+
+// JUnitFileEnvVar names the file WriteFile writes the report to as JUnit XML. No XML is written if it is unset.
+const JUnitFileEnvVar = "CHAIN_VALIDATION_JUNIT"
+
+// Kind classifies a failed check.
+type Kind string
+
+const (
+	ExitCode            Kind = "exit-code"
+	ReturnValue         Kind = "return-value"
+	ReturnValueEncoding Kind = "return-value-encoding"
+	GasUsed             Kind = "gas-used"
+	StateRoot           Kind = "state-root"
+	ReceiptsRoot        Kind = "receipts-root"
+	Checkpoint          Kind = "checkpoint"
+	BurntFunds          Kind = "burnt-funds"
+)
+
+// NoMessage is the message index of failures that don't concern a single message, such as that of the state root
+// after a tipset.
+const NoMessage = -1
+
+// Failure is a failed check of a test.
+type Failure struct {
+	Suite string `json:"suite"`
+	Test  string `json:"test"`
+	Kind  Kind   `json:"kind"`
+	// MessageIndex is the index of the message checked among those applied by the test, counting each message
+	// applied in a tipset, or NoMessage.
+	MessageIndex int `json:"messageIndex"`
+	// Name of the checkpoint or burn category checked, if any.
+	Name     string `json:"name,omitempty"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Message is a message applied by a test.
+type Message struct {
+	Suite string `json:"suite"`
+	Test  string `json:"test"`
+	// Index of the message among those applied by the test, counting each message applied in a tipset.
+	Index int    `json:"index"`
+	Desc  string `json:"desc"`
+}
+
+// Status is the outcome of a test.
+type Status string
+
+const (
+	Passed  Status = "pass"
+	Failed  Status = "fail"
+	Skipped Status = "skip"
+	// KnownFailure is the status of a test failing as listed in the implementation's known failures.
+	KnownFailure Status = "known-failure"
+)
+
+// Test is the outcome of a test, recorded by its driver on completion. A test building several drivers is recorded
+// once per driver.
+type Test struct {
+	Suite  string `json:"suite"`
+	Test   string `json:"test"`
+	Status Status `json:"status"`
+}
+
+// Report is the report of a run.
+type Report struct {
+	Tests    []Test    `json:"tests"`
+	Messages []Message `json:"messages"`
+	Failures []Failure `json:"failures"`
+}
+
+var failures struct {
+	sync.Mutex
+	list []Failure
+}
+
+var messages struct {
+	sync.Mutex
+	list []Message
+}
+
+var tests struct {
+	sync.Mutex
+	list []Test
+}
+
+// RecordTest records the outcome of the test `t`.
+func RecordTest(t testing.TB, status Status) {
+	tests.Lock()
+	defer tests.Unlock()
+	tests.list = append(tests.list, Test{
+		Suite:  suiteFromTest(t),
+		Test:   t.Name(),
+		Status: status,
+	})
+}
+
+// Tests returns the test outcomes recorded so far, in the order they were recorded.
+func Tests() []Test {
+	tests.Lock()
+	defer tests.Unlock()
+	return append([]Test(nil), tests.list...)
+}
+
+// RecordMessage records that the test `t` applied the message at `index`, described by `desc`, so that the report
+// lists the messages that passed their checks as well as those that failed them.
+func RecordMessage(t testing.TB, index int, desc string) {
+	messages.Lock()
+	defer messages.Unlock()
+	messages.list = append(messages.list, Message{
+		Suite: suiteFromTest(t),
+		Test:  t.Name(),
+		Index: index,
+		Desc:  desc,
+	})
+}
+
+// Messages returns the messages recorded so far, in the order they were recorded.
+func Messages() []Message {
+	messages.Lock()
+	defer messages.Unlock()
+	return append([]Message(nil), messages.list...)
+}
+
+// Record records a failure of the test `t`, which is expected to have failed it already.
+func Record(t testing.TB, f Failure) {
+	f.Suite = suiteFromTest(t)
+	f.Test = t.Name()
+
+	failures.Lock()
+	defer failures.Unlock()
+	failures.list = append(failures.list, f)
+}
+
+// Failures returns the failures recorded so far, in the order they were recorded.
+func Failures() []Failure {
+	failures.Lock()
+	defer failures.Unlock()
+	return append([]Failure(nil), failures.list...)
+}
+
+// WriteJSON writes the report of the failures recorded so far as JSON.
+func WriteJSON(w io.Writer) error {
+	r := Report{Tests: Tests(), Messages: Messages(), Failures: Failures()}
+	if r.Tests == nil {
+		r.Tests = []Test{}
+	}
+	if r.Messages == nil {
+		r.Messages = []Message{}
+	}
+	if r.Failures == nil {
+		r.Failures = []Failure{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteFile writes the report as JSON to the file named by FileEnvVar, and as JUnit XML to that named by
+// JUnitFileEnvVar, if they are set. Implementations should call it once all suites have run, e.g. from TestMain
+// after m.Run.
+func WriteFile() error {
+	if err := writeFile(os.Getenv(FileEnvVar), WriteJSON); err != nil {
+		return err
+	}
+	return writeFile(os.Getenv(JUnitFileEnvVar), WriteJUnit)
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// suiteFromTest returns the name of the suite a test belongs to, the first component of its name below the
+// implementation's top-level test.
+func suiteFromTest(t testing.TB) string {
+	tokens := strings.Split(t.Name(), "/")
+	if len(tokens) > 1 {
+		return tokens[1]
+	}
+	return tokens[0]
+}
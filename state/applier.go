@@ -9,11 +9,38 @@ import (
 	"github.com/filecoin-project/chain-validation/chain/types"
 )
 
-// Applier applies abstract messages to states.
+// Applier applies abstract messages to states. The execution context carries the epoch and base fee messages are
+// applied at, and the circulating supply if the test controls it, which the implementation must then report to actors
+// instead of computing its own. Messages in tipsets are applied at the epoch and base fee of the execution context, and
+// in the context of their block, whose miner, win count, ticket and timestamp are given by its BlockMessagesInfo.
 type Applier interface {
-	ApplyMessage(epoch abi.ChainEpoch, msg *types.Message) (types.ApplyMessageResult, error)
-	ApplySignedMessage(epoch abi.ChainEpoch, msg *types.SignedMessage) (types.ApplyMessageResult, error)
-	ApplyTipSetMessages(epoch abi.ChainEpoch, blocks []types.BlockMessagesInfo, rnd RandomnessSource) (types.ApplyTipSetResult, error)
+	ApplyMessage(exeCtx types.ExecutionContext, msg *types.Message) (types.ApplyMessageResult, error)
+	// ApplySignedMessage applies a message signed by its sender over the bytes of its CID. If the implementation
+	// declares the SignatureVerification capability, it must return an error and leave the state unchanged for a
+	// message whose signature isn't its sender's over those bytes. Applying a message otherwise doesn't validate it.
+	ApplySignedMessage(exeCtx types.ExecutionContext, msg *types.SignedMessage) (types.ApplyMessageResult, error)
+	// ApplyTipSetMessages applies the messages of a tipset's blocks. If the implementation declares the
+	// BlockValidation capability, it must return an error and leave the state unchanged for a tipset with a block
+	// consensus rejects, one beyond drivers.BlockMessageLimit messages or drivers.BlockGasLimit gas, or carrying a
+	// message with an invalid signature.
+	ApplyTipSetMessages(exeCtx types.ExecutionContext, blocks []types.BlockMessagesInfo, rnd RandomnessSource) (types.ApplyTipSetResult, error)
+	// CallMessage executes a message against the state without committing any of its effects, as a read-only call.
+	// The receipt, including gas used, is that the message would have if applied. The sender's nonce isn't checked,
+	// and neither its nonce nor any balance changes. The result's root is that of the unchanged state.
+	CallMessage(exeCtx types.ExecutionContext, msg *types.Message) (types.ApplyMessageResult, error)
+}
+
+// MessageValidatingApplier is implemented by Appliers able to check whether a message may be included in a block, as
+// their consensus does, apart from applying it. Suites of message inclusion rules are skipped for other Appliers.
+type MessageValidatingApplier interface {
+	Applier
+
+	// ValidateMessageForBlock returns an error if `msg` may not be included in a block applied in `exeCtx` to the
+	// current state, after the messages `prior` already included in the block, e.g. because its nonce doesn't follow
+	// that of its sender's last message or its version isn't zero. Only the syntax and nonces of messages are
+	// checked, as in block validation, not message pool policies such as the sender's balance covering the maximum
+	// cost. The state is unchanged.
+	ValidateMessageForBlock(exeCtx types.ExecutionContext, prior []*types.SignedMessage, msg *types.SignedMessage) error
 }
 
 // RandomnessSource provides randomness to actors.
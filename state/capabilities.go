@@ -0,0 +1,44 @@
+package state
+
+// Capabilities describes the optional features an implementation supports. Suites needing a feature it lacks are
+// skipped, and checks of optional results it doesn't provide are skipped rather than failed.
+type Capabilities struct {
+	// TipSetApplication is whether the applier implements ApplyTipSetMessages. Tipset suites are skipped without it.
+	TipSetApplication bool
+	// ExecTraces is whether message results list the events emitted while applying them.
+	ExecTraces bool
+	// GasBreakdown is whether message results break down their gas used by category of charge.
+	GasBreakdown bool
+	// StateMigration is whether the state wrapper implements MigratingVMWrapper. State migration suites are skipped
+	// without it.
+	StateMigration bool
+	// ReceiptsRoot is whether tipset results carry the root of the AMT of their receipts.
+	ReceiptsRoot bool
+	// BlockValidation is whether ApplyTipSetMessages validates the blocks of a tipset as consensus does, rejecting a
+	// tipset with a block beyond the limits on its messages or carrying an invalid signature. Suites of invalid blocks
+	// are skipped without it.
+	BlockValidation bool
+	// SignatureVerification is whether ApplySignedMessage verifies the signatures of messages, rejecting those signed
+	// by another key or over other bytes. Tests of invalid signatures are skipped without it.
+	SignatureVerification bool
+	// MessageValidation is whether the applier implements MessageValidatingApplier. Suites of message inclusion rules
+	// are skipped without it.
+	MessageValidation bool
+	// NetworkVersions lists the network versions the implementation supports, or is empty if it supports all of them.
+	// Suites applying to none of them are skipped.
+	NetworkVersions []uint
+}
+
+// FullCapabilities returns the capabilities of an implementation supporting every feature and network version.
+func FullCapabilities() Capabilities {
+	return Capabilities{
+		TipSetApplication:     true,
+		ExecTraces:            true,
+		GasBreakdown:          true,
+		StateMigration:        true,
+		ReceiptsRoot:          true,
+		BlockValidation:       true,
+		MessageValidation:     true,
+		SignatureVerification: true,
+	}
+}
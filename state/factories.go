@@ -10,3 +10,21 @@ type Factories interface {
 
 	NewValidationConfig() ValidationConfig
 }
+
+// ParallelFactories is implemented by Factories whose states, appliers and key managers are independent of each
+// other, so that suites may build and use drivers concurrently.
+type ParallelFactories interface {
+	Factories
+
+	// SupportsParallel returns whether suites may run in parallel.
+	SupportsParallel() bool
+}
+
+// NamedFactories is implemented by Factories that identify the implementation they integrate, e.g. "lotus", so that
+// data cached from its states, such as genesis fixtures, isn't shared with other implementations.
+type NamedFactories interface {
+	Factories
+
+	// ImplementationName returns the name of the implementation, unique among those sharing a cache.
+	ImplementationName() string
+}
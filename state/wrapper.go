@@ -1,6 +1,8 @@
 package state
 
 import (
+	"io"
+
 	cid "github.com/ipfs/go-cid"
 
 	address "github.com/filecoin-project/go-address"
@@ -34,6 +36,29 @@ type VMWrapper interface {
 
 	// Installs a new actor in the state tree, going through the init actor when appropriate and returning the ID address of the actor.
 	CreateActor(code cid.Cid, addr address.Address, balance abi.TokenAmount, state runtime.CBORMarshaler) (Actor, address.Address, error)
+
+	// Imports the blocks of a CAR (v1) file into the vm store and replaces the state tree with the one rooted at `root`.
+	ImportStateTree(root cid.Cid, car io.Reader) error
+}
+
+// MigratingVMWrapper is implemented by VMWrappers able to migrate their state tree across a network upgrade. Suites
+// of state migration are skipped for other VMWrappers.
+type MigratingVMWrapper interface {
+	VMWrapper
+
+	// Migrates the state tree to network version `to`, as the implementation does at the upgrade to it, replacing
+	// the code and states of builtin actors with those of its actors version, and returns the new root.
+	Migrate(to uint) (cid.Cid, error)
+}
+
+// CustomActorsVMWrapper is implemented by VMWrappers able to run actors other than the builtin ones, such as the
+// purpose-built test actors of the actors package. Suites using them are skipped for other VMWrappers.
+type CustomActorsVMWrapper interface {
+	VMWrapper
+
+	// Registers the actor code `code`, whose methods are `exports`, indexed by method number as returned by the
+	// Exports method of a specs-actors runtime.Invokee. Actors created with `code` run those methods.
+	RegisterActor(code cid.Cid, exports []interface{}) error
 }
 
 // TODO this needs to be implemented by chain validation. Providing these methods over RPC doesn't add a lot of value.
@@ -44,6 +69,13 @@ type KeyManager interface {
 	// Creates a new BLS private key and returns the associated address.
 	NewBLSAccountAddress() address.Address
 
+	// Creates the secp private key derived from `seed` by wallet.PrivateKeyFromSeed and returns the associated
+	// address. The same seed yields the same address in every run and implementation.
+	NewSECP256k1AccountKeyFromSeed(seed []byte) address.Address
+
+	// Creates the BLS private key derived from `seed` by wallet.PrivateKeyFromSeed and returns the associated address.
+	NewBLSAccountKeyFromSeed(seed []byte) address.Address
+
 	// Sign data with addr's key.
 	Sign(addr address.Address, data []byte) (crypto.Signature, error)
 }
@@ -61,4 +93,16 @@ type ValidationConfig interface {
 	ValidateExitCode() bool
 	ValidateReturnValue() bool
 	ValidateStateRoot() bool
+	// ValidateReturnValueEncoding enables checking that every receipt's return value is either empty or a single
+	// canonically encoded CBOR item, whether or not a test inspects the value itself.
+	ValidateReturnValueEncoding() bool
+	// ValidateExitCodeClassOnly relaxes the checking of exit codes to their class, i.e. Ok, a system error or an actor
+	// error, for implementations that legitimately differ in the exact codes of some failures.
+	ValidateExitCodeClassOnly() bool
+	// KnownFailures maps the IDs of tests the implementation is known to fail, such as "MessageTest_Paych" or
+	// "MessageTest_Paych/happy path", to the reason why. Known failures are run, but their failures are logged and
+	// the tests skipped, while known failures that pass are reported for removal from the list.
+	KnownFailures() map[string]string
+	// Capabilities describes the optional features the implementation supports.
+	Capabilities() Capabilities
 }
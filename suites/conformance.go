@@ -0,0 +1,215 @@
+package suites
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/account"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/wallet"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// FactoryConformance checks that the state, applier and key manager made by `factory` obey the contracts of their
+// interfaces, on which every suite relies. Run it before the suites, so that a broken integration is reported as
+// such, rather than as confusing failures of actor semantics.
+func FactoryConformance(t *testing.T, factory state.Factories) {
+	accountCode := drivers.ActorCode(drivers.AccountActor, drivers.ActorsVersionOf(drivers.NetworkVersionOf(factory)))
+
+	// newState returns a new state holding the default builtin actors, as built for each test driver.
+	newState := func(t *testing.T) state.VMWrapper {
+		st, _ := factory.NewStateAndApplier(drivers.NewChainValidationSysCalls())
+		st.NewVM()
+		_, err := drivers.PutEmptyRoots(drivers.AsStore(st))
+		require.NoError(t, err)
+		for _, acts := range drivers.DefaultBuiltinActorsState {
+			_, _, err := st.CreateActor(acts.Code, acts.Addr, acts.Balance, acts.State)
+			require.NoError(t, err, "creating actor at %s", acts.Addr)
+		}
+		return st
+	}
+
+	// assertActor asserts that the actor at `addr` exists with the given code, balance and head.
+	assertActor := func(t *testing.T, st state.VMWrapper, addr address.Address, code cid.Cid, balance abi.TokenAmount, head cid.Cid) {
+		actor, err := st.Actor(addr)
+		require.NoError(t, err, "actor at %s", addr)
+		require.NotNil(t, actor, "actor at %s", addr)
+		assert.Equal(t, code, actor.Code(), "code of actor at %s", addr)
+		assert.Equal(t, balance, actor.Balance(), "balance of actor at %s", addr)
+		assert.Equal(t, head, actor.Head(), "head of actor at %s", addr)
+	}
+
+	t.Run("store round trip", func(t *testing.T) {
+		st := newState(t)
+		value := &account.State{Address: utils.NewIDAddr(t, 100)}
+		c, err := st.StorePut(value)
+		require.NoError(t, err)
+
+		var out account.State
+		require.NoError(t, st.StoreGet(c, &out))
+		assert.Equal(t, *value, out)
+
+		again, err := st.StorePut(value)
+		require.NoError(t, err)
+		assert.Equal(t, c, again, "CID of a value put twice")
+	})
+
+	t.Run("builtin actors persist", func(t *testing.T) {
+		st := newState(t)
+		for _, acts := range drivers.DefaultBuiltinActorsState {
+			head, err := st.StorePut(acts.State)
+			require.NoError(t, err)
+			assertActor(t, st, acts.Addr, acts.Code, acts.Balance, head)
+		}
+	})
+
+	t.Run("created actor resolves by any address", func(t *testing.T) {
+		st := newState(t)
+		km := factory.NewKeyManager()
+		for _, pubkey := range []address.Address{km.NewSECP256k1AccountAddress(), km.NewBLSAccountAddress()} {
+			balance := abi.NewTokenAmount(1_000)
+			actState := &account.State{Address: pubkey}
+			created, id, err := st.CreateActor(accountCode, pubkey, balance, actState)
+			require.NoError(t, err)
+			require.Equal(t, address.ID, id.Protocol(), "protocol of address returned for %s", pubkey)
+
+			head, err := st.StorePut(actState)
+			require.NoError(t, err)
+			assert.Equal(t, head, created.Head(), "head of created actor")
+			assert.Equal(t, uint64(0), created.CallSeqNum(), "call sequence number of created actor")
+			assertActor(t, st, id, accountCode, balance, head)
+			assertActor(t, st, pubkey, accountCode, balance, head)
+		}
+	})
+
+	t.Run("unknown actors are errors", func(t *testing.T) {
+		st := newState(t)
+		km := factory.NewKeyManager()
+		for _, addr := range []address.Address{
+			utils.NewIDAddr(t, 1_000_000),
+			km.NewSECP256k1AccountAddress(),
+			km.NewBLSAccountAddress(),
+			utils.NewActorAddr(t, "unknown actor"),
+		} {
+			_, err := st.Actor(addr)
+			assert.Error(t, err, "actor at unknown address %s", addr)
+		}
+	})
+
+	t.Run("root is stable", func(t *testing.T) {
+		st := newState(t)
+		root := st.Root()
+		assert.Equal(t, root, newState(t).Root(), "root of identically built states")
+
+		_, err := st.Actor(builtin.InitActorAddr)
+		require.NoError(t, err)
+		assert.Equal(t, root, st.Root(), "root after reading an actor")
+
+		// Setting an actor's state changes the root, and setting it back restores it.
+		var prev account.State
+		pubkey := factory.NewKeyManager().NewSECP256k1AccountAddress()
+		_, id, err := st.CreateActor(accountCode, pubkey, big.Zero(), &account.State{Address: pubkey})
+		require.NoError(t, err)
+		created := st.Root()
+		assert.NotEqual(t, root, created, "root after creating an actor")
+
+		actor, err := st.Actor(id)
+		require.NoError(t, err)
+		require.NoError(t, st.StoreGet(actor.Head(), &prev))
+
+		balance := abi.NewTokenAmount(1_000)
+		next := &account.State{Address: utils.NewIDAddr(t, 100)}
+		_, err = st.SetActorState(id, balance, next)
+		require.NoError(t, err)
+		assert.NotEqual(t, created, st.Root(), "root after setting an actor's state")
+		head, err := st.StorePut(next)
+		require.NoError(t, err)
+		assertActor(t, st, id, accountCode, balance, head)
+		assertActor(t, st, pubkey, accountCode, balance, head)
+
+		_, err = st.SetActorState(id, big.Zero(), &prev)
+		require.NoError(t, err)
+		assert.Equal(t, created, st.Root(), "root after restoring an actor's state")
+	})
+
+	t.Run("signatures verify", func(t *testing.T) {
+		km := factory.NewKeyManager()
+		data := []byte("chain-validation conformance")
+		for _, tc := range []struct {
+			protocol address.Protocol
+			sigType  crypto.SigType
+			newAddr  func() address.Address
+		}{
+			{address.SECP256K1, crypto.SigTypeSecp256k1, km.NewSECP256k1AccountAddress},
+			{address.BLS, crypto.SigTypeBLS, km.NewBLSAccountAddress},
+		} {
+			signer := tc.newAddr()
+			require.Equal(t, tc.protocol, signer.Protocol(), "protocol of new address")
+
+			sig, err := km.Sign(signer, data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.sigType, sig.Type, "signature type of %s", signer)
+			assert.NoError(t, wallet.Verify(sig, signer, data), "signature by %s", signer)
+			assert.Error(t, wallet.Verify(sig, signer, []byte("other data")), "signature by %s of other data", signer)
+			assert.Error(t, wallet.Verify(sig, tc.newAddr(), data), "signature by %s checked against another key", signer)
+		}
+
+		_, err := km.Sign(utils.NewIDAddr(t, 100), data)
+		assert.Error(t, err, "signing with an unknown address")
+	})
+
+	t.Run("seeded keys are deterministic", func(t *testing.T) {
+		seed := []byte("chain-validation conformance")
+		for _, tc := range []struct {
+			sigType crypto.SigType
+			newAddr func(km state.KeyManager) address.Address
+		}{
+			{crypto.SigTypeSecp256k1, func(km state.KeyManager) address.Address { return km.NewSECP256k1AccountKeyFromSeed(seed) }},
+			{crypto.SigTypeBLS, func(km state.KeyManager) address.Address { return km.NewBLSAccountKeyFromSeed(seed) }},
+		} {
+			prv, err := wallet.PrivateKeyFromSeed(tc.sigType, seed)
+			require.NoError(t, err)
+			key, err := wallet.NewKey(wallet.KeyInfo{Type: tc.sigType, PrivateKey: prv})
+			require.NoError(t, err)
+
+			km := factory.NewKeyManager()
+			addr := tc.newAddr(km)
+			assert.Equal(t, key.Address, addr, "address from seed")
+			assert.Equal(t, addr, tc.newAddr(factory.NewKeyManager()), "address from seed in another key manager")
+
+			sig, err := km.Sign(addr, seed)
+			require.NoError(t, err)
+			assert.NoError(t, wallet.Verify(sig, addr, seed), "signature by seeded key %s", addr)
+		}
+	})
+
+	t.Run("applier transfers", func(t *testing.T) {
+		td := drivers.NewBuilder(context.Background(), factory).
+			WithDefaultGasLimit(1_000_000_000).
+			WithDefaultGasFeeCap(200).
+			WithDefaultGasPremium(1).
+			WithActorState(drivers.DefaultBuiltinActorsState...).
+			Build(t)
+		defer td.Complete()
+
+		value := abi.NewTokenAmount(10_000)
+		sender, senderID := td.NewAccountActor(drivers.SECP, abi.NewTokenAmount(1_000_000_000_000_000))
+		receiver, receiverID := td.NewAccountActor(drivers.BLS, big.Zero())
+		prevSenderBal := td.GetBalance(senderID)
+
+		result := td.ApplyOk(td.MessageProducer.Transfer(sender, receiver, chain.Value(value), chain.Nonce(0)))
+		td.AssertActorChange(senderID, prevSenderBal, result.Msg.GasLimit, result.Msg.GasPremium, value, result.Receipt, 1)
+		td.AssertBalance(receiverID, value)
+	})
+}
@@ -0,0 +1,153 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// Tests transfers to a receiver of each address protocol, whether or not an actor exists at the address. Transfers to
+// an existing actor reach it by any of its addresses. An account is implicitly created only for an unknown public key
+// address; unknown ID and actor addresses are invalid receivers.
+func MessageTest_AddressResolution(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var senderBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	var transferAmnt = abi_spec.NewTokenAmount(10_000)
+
+	testCases := []struct {
+		desc string
+		// receiver returns the address to send to, the ID address of the actor existing there or address.Undef,
+		// and the next nonce of the sender.
+		receiver func(td *drivers.TestDriver, sender address.Address) (address.Address, address.Address, uint64)
+		// Whether an account is created at the receiver.
+		creates     bool
+		expExitCode exitcode.ExitCode
+	}{
+		{
+			"existing ID address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				_, id := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+				return id, id, 0
+			},
+			false, exitcode.Ok,
+		},
+		{
+			"unknown ID address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				return utils.NewIDAddr(td.T, 1_000_000), address.Undef, 0
+			},
+			false, exitcode.SysErrInvalidReceiver,
+		},
+		{
+			"existing SECP256K1 address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				pk, id := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+				return pk, id, 0
+			},
+			false, exitcode.Ok,
+		},
+		{
+			"unknown SECP256K1 address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				return td.Wallet().NewSECP256k1AccountAddress(), address.Undef, 0
+			},
+			true, exitcode.Ok,
+		},
+		{
+			"existing BLS address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				pk, id := td.NewAccountActor(drivers.BLS, big_spec.Zero())
+				return pk, id, 0
+			},
+			false, exitcode.Ok,
+		},
+		{
+			"unknown BLS address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				return td.Wallet().NewBLSAccountAddress(), address.Undef, 0
+			},
+			true, exitcode.Ok,
+		},
+		{
+			"existing actor address",
+			func(td *drivers.TestDriver, sender address.Address) (address.Address, address.Address, uint64) {
+				paychReceiver, paychReceiverID := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+				paychAddr := utils.NewIDAddr(td.T, utils.IdFromAddress(paychReceiverID)+1)
+				createRet := td.ComputeInitActorExecReturn(sender, 0, 0, paychAddr)
+				td.ApplyExpect(
+					td.MessageProducer.CreatePaymentChannelActor(sender, paychReceiver, chain.Nonce(0)),
+					chain.MustSerialize(&createRet))
+				return createRet.RobustAddress, createRet.IDAddress, 1
+			},
+			false, exitcode.Ok,
+		},
+		{
+			"unknown actor address",
+			func(td *drivers.TestDriver, _ address.Address) (address.Address, address.Address, uint64) {
+				return utils.NewActorAddr(td.T, "unknown actor"), address.Undef, 0
+			},
+			false, exitcode.SysErrInvalidReceiver,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, senderID := td.NewAccountActor(drivers.SECP, senderBal)
+			receiver, receiverID, nonce := tc.receiver(td, sender)
+
+			var prevReceiverBal abi_spec.TokenAmount
+			if receiverID != address.Undef {
+				prevReceiverBal = td.GetBalance(receiverID)
+			}
+			var prevInit init_spec.State
+			td.GetActorState(builtin_spec.InitActorAddr, &prevInit)
+			prevSenderBal := td.GetBalance(senderID)
+
+			result := td.ApplyFailure(td.MessageProducer.Transfer(sender, receiver, chain.Value(transferAmnt), chain.Nonce(nonce)), tc.expExitCode)
+
+			transferred := big_spec.Zero()
+			if tc.expExitCode.IsSuccess() {
+				transferred = transferAmnt
+			}
+			td.AssertActorChange(senderID, prevSenderBal, result.Msg.GasLimit, result.Msg.GasPremium, transferred, result.Receipt, nonce+1)
+
+			// An account is created only by assigning the next ID address to the receiver.
+			var initSt init_spec.State
+			td.GetActorState(builtin_spec.InitActorAddr, &initSt)
+			if tc.creates {
+				createdID := utils.NewIDAddr(t, uint64(prevInit.NextID))
+				assert.Equal(t, prevInit.NextID+1, initSt.NextID, "next ID address")
+				td.AssertBalance(receiver, transferAmnt)
+				td.AssertBalance(createdID, transferAmnt)
+				td.AssertHead(createdID, td.GetHead(receiver))
+			} else {
+				assert.Equal(t, prevInit.NextID, initSt.NextID, "next ID address")
+				if receiverID != address.Undef {
+					td.AssertBalance(receiverID, big_spec.Add(prevReceiverBal, transferred))
+				} else {
+					td.AssertNoActor(receiver)
+				}
+			}
+		})
+	}
+}
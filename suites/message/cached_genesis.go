@@ -0,0 +1,71 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/commitments"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test that a genesis cached by WithCachedGenesis is imported as it was constructed. The first driver constructs and
+// caches the state, unless an earlier run did, and the second imports it from the cache. Both must find the same
+// genesis actors, and the genesis accounts and miner owners must be able to sign messages with their restored keys.
+func MessageTest_CachedGenesis(t *testing.T, factory state.Factories) {
+	balance := abi_spec.NewTokenAmount(1_000_000_000_000_000)
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithCachedGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(2, drivers.SECP, balance).
+			WithMiners(1, drivers.GenesisMinerSpec{Sectors: 2, Expiration: 10_000}).
+			WithDeal(drivers.GenesisDealSpec{
+				Client:               0,
+				Provider:             0,
+				Piece:                commitments.PieceOfSize(commitments.MaxPieceSize),
+				StartEpoch:           1_000,
+				EndEpoch:             2_000,
+				StoragePricePerEpoch: abi_spec.NewTokenAmount(1),
+				ProviderCollateral:   abi_spec.NewTokenAmount(1),
+				ClientCollateral:     abi_spec.NewTokenAmount(1),
+			}))
+
+	// The genesis and the heads of the genesis miner and the market, as found by each driver.
+	var genesis []*drivers.Genesis
+	var minerHeads, marketHeads []cid.Cid
+	for _, desc := range []string{"constructed or imported", "imported"} {
+		t.Run(desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			gen := td.Genesis
+			if !assert.NotNil(t, gen, "driver has no genesis") {
+				return
+			}
+			genesis = append(genesis, gen)
+			minerHeads = append(minerHeads, td.GetHead(gen.Miners[0].ID))
+			marketHeads = append(marketHeads, td.GetHead(builtin_spec.StorageMarketActorAddr))
+
+			value := abi_spec.NewTokenAmount(100)
+			td.ApplyOk(td.MessageProducer.Transfer(gen.Accounts[0].PubKey, gen.Accounts[1].ID, chain.Value(value), chain.Nonce(0)))
+			// Miner owners hold too little to cover the default gas limit.
+			td.ApplyOk(td.MessageProducer.Transfer(gen.Miners[0].Info.Owner, gen.Accounts[1].ID, chain.Value(value), chain.Nonce(0), chain.GasLimit(1_000_000)))
+			td.AssertBalance(gen.Accounts[1].ID, big_spec.Add(balance, big_spec.Mul(value, big_spec.NewInt(2))))
+		})
+	}
+
+	if len(genesis) == 2 {
+		assert.Equal(t, genesis[0], genesis[1], "genesis of constructed and imported fixture")
+		assert.Equal(t, minerHeads[0], minerHeads[1], "genesis miner head of constructed and imported fixture")
+		assert.Equal(t, marketHeads[0], marketHeads[1], "market head of constructed and imported fixture")
+	}
+}
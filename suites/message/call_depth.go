@@ -0,0 +1,128 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// The maximum depth of an internal send below its top-level message, which is at depth zero.
+const maxCallDepth = 4096
+
+// Tests the limit on the depth of internal sends, with a chain of multisigs each of whose pending transaction
+// approves that of the next, so that one top-level approval cascades down the chain. The send exceeding the limit
+// fails with SysErrForbidden, which is returned to the multisig making it, and passed back up the chain as the
+// result of each approval, so the top-level message itself succeeds.
+//
+// The chain is built directly in the state, each multisig's transaction already approved by a common co-signer, so
+// that each level costs a constant amount of gas.
+func MessageTest_CallDepthLimit(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(10_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000_000)
+
+	testCases := []struct {
+		desc string
+		// Number of multisigs in the chain, and so the depth of the send from the last to the receiver.
+		depth   int
+		expCode exitcode_spec.ExitCode
+	}{
+		{"at limit", maxCallDepth, exitcode_spec.Ok},
+		{"beyond limit", maxCallDepth + 1, exitcode_spec.SysErrForbidden},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(fmt.Sprintf("send depth %s", tc.desc), func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, senderID := td.NewAccountActor(drivers.SECP, initialBal)
+			_, cosignerID := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+			_, receiverID := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+			// Build the chain from its bottom, whose transaction sends to the receiver. Each multisig's transaction
+			// approves that of the one below, whose hash it carries.
+			txn := multisig_spec.Transaction{
+				To:       receiverID,
+				Value:    big_spec.Zero(),
+				Method:   builtin_spec.MethodSend,
+				Params:   nil,
+				Approved: []address.Address{cosignerID},
+			}
+			multisigs := make([]address.Address, tc.depth)
+			var txnHash []byte
+			for i := range multisigs {
+				txns := adt_spec.MakeEmptyMap(drivers.AsStore(td.State()))
+				require.NoError(t, txns.Put(multisig_spec.TxnID(0), &txn))
+				pending, err := txns.Root()
+				require.NoError(t, err)
+				txnHash = makeProposalHash(t, &txn)
+
+				_, id, err := td.State().CreateActor(td.ActorCode(drivers.MultisigActor), utils.NewActorAddr(t, fmt.Sprintf("multisig %d", i)), big_spec.Zero(), &multisig_spec.State{
+					// The approver is the multisig above, which is created next, or the sender at the top.
+					Signers:               []address.Address{cosignerID, utils.NewIDAddr(t, utils.IdFromAddress(receiverID)+uint64(i)+2)},
+					NumApprovalsThreshold: 2,
+					NextTxnID:             1,
+					InitialBalance:        big_spec.Zero(),
+					StartEpoch:            0,
+					UnlockDuration:        0,
+					PendingTxns:           pending,
+				})
+				require.NoError(t, err)
+				require.Equal(t, utils.IdFromAddress(receiverID)+uint64(i)+1, utils.IdFromAddress(id), "ID of multisig %d", i)
+				multisigs[i] = id
+
+				txn = multisig_spec.Transaction{
+					To:       id,
+					Value:    big_spec.Zero(),
+					Method:   builtin_spec.MethodsMultisig.Approve,
+					Params:   chain.MustSerialize(&multisig_spec.TxnIDParams{ID: 0, ProposalHash: txnHash}),
+					Approved: []address.Address{cosignerID},
+				}
+			}
+			// The top multisig's approver is the sender, not a multisig.
+			top := multisigs[len(multisigs)-1]
+			var topSt multisig_spec.State
+			td.GetActorState(top, &topSt)
+			topSt.Signers[1] = senderID
+			topAct, err := td.State().Actor(top)
+			require.NoError(t, err)
+			_, err = td.State().SetActorState(top, topAct.Balance(), &topSt)
+			require.NoError(t, err)
+
+			// Each approval returns the result of the transaction it applies, i.e. the approval below it.
+			var ret []byte
+			code := tc.expCode
+			for range multisigs {
+				ret = chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: code, Ret: ret})
+				code = exitcode_spec.Ok
+			}
+			td.ApplyExpect(
+				td.MessageProducer.MultisigApprove(sender, top, &multisig_spec.TxnIDParams{ID: 0, ProposalHash: txnHash}, chain.Nonce(0)),
+				ret)
+
+			// Every transaction was applied, whether or not the send at the bottom of the chain succeeded.
+			for _, m := range multisigs {
+				td.AssertMultisigContainsTransaction(m, 0, false)
+			}
+		})
+	}
+}
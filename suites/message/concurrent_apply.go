@@ -0,0 +1,71 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// concurrentDrivers is the number of drivers MessageTest_ConcurrentApply applies messages through at once.
+const concurrentDrivers = 8
+
+// concurrentMessages is the number of messages each driver of MessageTest_ConcurrentApply applies.
+const concurrentMessages = 50
+
+// Test that independent states and appliers made by the same factory may be used concurrently, as when a node
+// validates several tipsets in parallel. Each of concurrentDrivers drivers, run as a parallel subtest, applies its own
+// sequence of transfers, some creating accounts, and checks every result, balance and nonce, so that state shared
+// between instances, such as a cache of actors or a current state root, shows as wrong results. Run it with the race
+// detector, `go test -race`, to also find unsynchronized access that happens not to change them. It is skipped for
+// factories that don't declare themselves safe for parallel use with state.ParallelFactories.
+func MessageTest_ConcurrentApply(t *testing.T, factory state.Factories) {
+	if pf, ok := factory.(state.ParallelFactories); !ok || !pf.SupportsParallel() {
+		t.Skip("implementation doesn't support concurrent drivers")
+	}
+
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	aliceBal := abi_spec.NewTokenAmount(1_000_000_000_000_000)
+
+	for i := 0; i < concurrentDrivers; i++ {
+		i := i
+		t.Run(fmt.Sprintf("driver %d", i), func(t *testing.T) {
+			t.Parallel()
+			td := builder.Build(t)
+			defer td.Complete()
+
+			alice, aliceId := td.NewAccountActor(drivers.SECP, aliceBal)
+			_, bobId := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+			// Each driver transfers its own amounts, so that no two states are alike.
+			value := abi_spec.NewTokenAmount(int64(i + 1))
+			bobBal := big_spec.Zero()
+			for n := 0; n < concurrentMessages; n++ {
+				nonce := uint64(n)
+				// Every fifth transfer creates an account for its receiver.
+				if n%5 == 0 {
+					receiver := utils.NewSECP256K1Addr(t, fmt.Sprintf("concurrent %d %d", i, n))
+					td.ApplyOk(td.MessageProducer.Transfer(alice, receiver, chain.Value(value), chain.Nonce(nonce)))
+					td.AssertBalance(receiver, value)
+				} else {
+					td.ApplyOk(td.MessageProducer.Transfer(alice, bobId, chain.Value(value), chain.Nonce(nonce)))
+					bobBal = big_spec.Add(bobBal, value)
+					td.AssertBalance(bobId, bobBal)
+				}
+				td.AssertCallSeqNum(aliceId, nonce+1)
+			}
+		})
+	}
+}
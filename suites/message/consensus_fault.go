@@ -0,0 +1,115 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	runtime_spec "github.com/filecoin-project/specs-actors/actors/runtime"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test reporting of consensus faults, verified by a mocked syscall. A verified fault rewards the reporter from the
+// miner's balance, burns the rest, removes the miner's power and the miner itself.
+func MessageTest_ConsensusFault(t *testing.T, factory state.Factories) {
+	const sectors = 4
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(1, drivers.SECP, abi_spec.NewTokenAmount(1_000_000_000_000)).
+			WithMiners(1, drivers.GenesisMinerSpec{Sectors: sectors, Expiration: 100_000}))
+
+	var minerFunds = abi_spec.NewTokenAmount(1_000_000)
+	const faultAge = abi_spec.ChainEpoch(10)
+
+	faultParams := &miner_spec.ReportConsensusFaultParams{
+		BlockHeader1: []byte("block header one"),
+		BlockHeader2: []byte("block header two"),
+	}
+
+	t.Run("slash miner and reward reporter", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		reporter, reporterID := td.Genesis.Accounts[0].PubKey, td.Genesis.Accounts[0].ID
+		miner := td.Genesis.Miners[0].ID
+		td.ApplyOk(td.MessageProducer.Transfer(reporter, miner, chain.Value(minerFunds), chain.Nonce(0)))
+
+		faultEpoch := td.CurrentEpoch()
+		td.AdvanceEpoch(faultAge)
+		td.SysCalls.MockConsensusFault(&runtime_spec.ConsensusFault{
+			Target: miner,
+			Epoch:  faultEpoch,
+			Type:   runtime_spec.ConsensusFaultDoubleForkMining,
+		}, nil)
+
+		prevReporterBal := td.GetBalance(reporterID)
+		prevBurnt := td.GetBalance(builtin_spec.BurntFundsActorAddr)
+		prevMinerCount := td.Power().State().MinerCount
+
+		msg := td.MessageProducer.MinerReportConsensusFault(reporter, miner, faultParams, chain.Nonce(1))
+		result := td.ApplyOk(msg)
+
+		slasherReward := miner_spec.RewardForConsensusSlashReport(faultAge, minerFunds)
+		td.AssertBalance(reporterID, big_spec.Add(
+			big_spec.Sub(prevReporterBal, td.CalcMessageCost(msg.GasLimit, msg.GasPremium, big_spec.Zero(), result.Receipt)),
+			slasherReward))
+		// Whatever isn't paid to the reporter is burnt, along with the message's gas.
+		gasBurn := drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, result.Receipt.GasUsed).Burn()
+		td.AssertBalance(builtin_spec.BurntFundsActorAddr, big_spec.Sum(prevBurnt, big_spec.Sub(minerFunds, slasherReward), gasBurn))
+		td.AssertNoActor(miner)
+
+		td.Power().
+			AssertNoClaim(miner).
+			AssertMinerCount(prevMinerCount - 1).
+			AssertTotalRawPower(big_spec.Zero()).
+			AssertTotalQualityAdjPower(big_spec.Zero())
+	})
+
+	rejections := []struct {
+		desc  string
+		fault func(td *drivers.TestDriver) (*runtime_spec.ConsensusFault, error)
+	}{
+		{"reject unverified fault", func(td *drivers.TestDriver) (*runtime_spec.ConsensusFault, error) {
+			return nil, fmt.Errorf("headers are not a consensus fault")
+		}},
+		{"reject fault at current epoch", func(td *drivers.TestDriver) (*runtime_spec.ConsensusFault, error) {
+			return &runtime_spec.ConsensusFault{
+				Target: td.Genesis.Miners[0].ID,
+				Epoch:  td.CurrentEpoch(),
+				Type:   runtime_spec.ConsensusFaultDoubleForkMining,
+			}, nil
+		}},
+	}
+	for _, tc := range rejections {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			reporter := td.Genesis.Accounts[0].PubKey
+			miner := td.Genesis.Miners[0].ID
+			td.ApplyOk(td.MessageProducer.Transfer(reporter, miner, chain.Value(minerFunds), chain.Nonce(0)))
+			td.AdvanceEpoch(faultAge)
+			td.SysCalls.MockConsensusFault(tc.fault(td))
+
+			summaries := td.CaptureSummaries(miner)
+			msg := td.MessageProducer.MinerReportConsensusFault(reporter, miner, faultParams, chain.Nonce(1))
+			result := td.ApplyFailure(msg, exitcode_spec.ErrIllegalArgument)
+
+			// The miner keeps its funds and power, the reward actor receiving only the message's gas tip.
+			tip := drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, result.Receipt.GasUsed).MinerTip()
+			summaries.Capture().AssertDiff(drivers.SummaryDiff{"Reward.Treasury": tip})
+		})
+	}
+}
@@ -5,11 +5,22 @@ import (
 	"testing"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
 
 	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	paych_spec "github.com/filecoin-project/specs-actors/actors/builtin/paych"
 	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/commitments"
+	"github.com/filecoin-project/chain-validation/chain/types"
 	"github.com/filecoin-project/chain-validation/drivers"
 	"github.com/filecoin-project/chain-validation/state"
 	"github.com/filecoin-project/chain-validation/suites/utils"
@@ -123,3 +134,205 @@ func MessageTest_InitActorSequentialIDAddressCreate(t *testing.T, factory state.
 		chain.MustSerialize(&secondInitRet),
 	)
 }
+
+// Tests that an account may not exec an actor of any code but that of the actors the init actor constructs for
+// anyone, i.e. payment channels and multisigs. Singleton actors, miners (constructed only for the power actor), and
+// code unknown to the VM are forbidden, leaving the init actor unchanged, creating no actor, and returning any value
+// sent.
+func MessageTest_InitExecForbiddenCode(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	var toSend = abi_spec.NewTokenAmount(10_000)
+
+	// unknownBuiltin is a CID of the same form as those of builtin actors, naming no actor.
+	unknownBuiltin, err := cid.Prefix{
+		Version:  1,
+		Codec:    cid.Raw,
+		MhType:   multihash.IDENTITY,
+		MhLength: -1,
+	}.Sum([]byte("fil/1/unknown"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		desc string
+		// The kind of builtin actor to exec, or none to exec `code`.
+		kind drivers.ActorKind
+		code cid.Cid
+	}{
+		{"system", drivers.SystemActor, cid.Undef},
+		{"init", drivers.InitActor, cid.Undef},
+		{"cron", drivers.CronActor, cid.Undef},
+		{"account", drivers.AccountActor, cid.Undef},
+		{"power", drivers.PowerActor, cid.Undef},
+		{"miner", drivers.MinerActor, cid.Undef},
+		{"market", drivers.MarketActor, cid.Undef},
+		{"reward", drivers.RewardActor, cid.Undef},
+		{"verified registry", drivers.VerifregActor, cid.Undef},
+		{"unknown builtin", "", unknownBuiltin},
+		{"unknown CID", "", commitments.UnsealedCID(0)},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run("exec "+tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			code := tc.code
+			if tc.kind != "" {
+				code = td.ActorCode(tc.kind)
+			}
+			sender, senderID := td.NewAccountActor(drivers.SECP, initialBal)
+			var prevInit init_spec.State
+			td.GetActorState(builtin_spec.InitActorAddr, &prevInit)
+			expectedAddr := utils.NewIDAddr(t, uint64(prevInit.NextID))
+
+			var result types.ApplyMessageResult
+			td.AssertHeadUnchanged(builtin_spec.InitActorAddr, func() {
+				result = td.ApplyFailure(
+					td.MessageProducer.InitExec(sender, builtin_spec.InitActorAddr, &init_spec.ExecParams{
+						CodeCID:           code,
+						ConstructorParams: nil,
+					}, chain.Value(toSend), chain.Nonce(0)),
+					exitcode_spec.ErrForbidden)
+			})
+			td.AssertActorChange(senderID, initialBal, result.Msg.GasLimit, result.Msg.GasPremium, big_spec.Zero(), result.Receipt, 1)
+			td.AssertBalance(builtin_spec.InitActorAddr, big_spec.Zero())
+			td.AssertNoActor(expectedAddr)
+		})
+	}
+}
+
+// Tests actors created by init.Exec within the execution of a multisig, rather than called for by an account. The new
+// actor's robust address derives from the top-level message, i.e. the address and nonce of its sender and the count of
+// actors it has created so far, not from the multisig which called init. Actor creation happens only in init.Exec, and
+// no builtin actor calls it more than once in a message, so the count is always zero.
+func MessageTest_NestedActorCreation(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	var multisigBal = abi_spec.NewTokenAmount(1_000_000)
+	var toSend = abi_spec.NewTokenAmount(10_000)
+
+	// execProposal proposes init.Exec of an actor of `code`, constructed with `params`, sending it toSend.
+	execProposal := func(code cid.Cid, params []byte) *multisig_spec.ProposeParams {
+		return &multisig_spec.ProposeParams{
+			To:     builtin_spec.InitActorAddr,
+			Value:  toSend,
+			Method: builtin_spec.MethodsInit.Exec,
+			Params: chain.MustSerialize(&init_spec.ExecParams{CodeCID: code, ConstructorParams: params}),
+		}
+	}
+
+	// assertCreated asserts that an actor of `code` exists at both addresses of `ret`, holding toSend.
+	assertCreated := func(td *drivers.TestDriver, ret init_spec.ExecReturn, code cid.Cid) {
+		for _, addr := range []address.Address{ret.IDAddress, ret.RobustAddress} {
+			actor, err := td.State().Actor(addr)
+			require.NoError(t, err)
+			assert.Equal(t, code, actor.Code(), "code of actor at %s", addr)
+		}
+		td.AssertBalance(ret.IDAddress, toSend)
+	}
+
+	t.Run("multisig creates payment channel", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, initialBal)
+		_, bobID := td.NewAccountActor(drivers.SECP, initialBal)
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(bobID))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, multisigBal, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{Signers: []address.Address{aliceID}, NumApprovalsThreshold: 1},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		// The payment channel is between accounts, though created by the multisig.
+		paychRet := td.ComputeInitActorExecReturn(alice, 1, 0, utils.NewIDAddr(t, 1+utils.IdFromAddress(multisigAddr)))
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, execProposal(td.ActorCode(drivers.PaychActor),
+				chain.MustSerialize(&paych_spec.ConstructorParams{From: aliceID, To: bobID})), chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 0, Applied: true, Code: exitcode_spec.Ok, Ret: chain.MustSerialize(&paychRet)}))
+
+		assertCreated(td, paychRet, td.ActorCode(drivers.PaychActor))
+		var paychSt paych_spec.State
+		td.GetActorState(paychRet.IDAddress, &paychSt)
+		assert.Equal(t, aliceID, paychSt.From)
+		assert.Equal(t, bobID, paychSt.To)
+		td.AssertBalance(multisigAddr, big_spec.Sub(multisigBal, toSend))
+	})
+
+	t.Run("multisig creates multisig", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, initialBal)
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(aliceID))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, multisigBal, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{Signers: []address.Address{aliceID}, NumApprovalsThreshold: 1},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		// The new multisig's only signer is the one creating it.
+		childRet := td.ComputeInitActorExecReturn(alice, 1, 0, utils.NewIDAddr(t, 1+utils.IdFromAddress(multisigAddr)))
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, execProposal(td.ActorCode(drivers.MultisigActor),
+				chain.MustSerialize(&multisig_spec.ConstructorParams{Signers: []address.Address{multisigAddr}, NumApprovalsThreshold: 1})), chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 0, Applied: true, Code: exitcode_spec.Ok, Ret: chain.MustSerialize(&childRet)}))
+
+		assertCreated(td, childRet, td.ActorCode(drivers.MultisigActor))
+		td.AssertMultisigState(childRet.IDAddress, multisig_spec.State{
+			Signers:               []address.Address{multisigAddr},
+			NumApprovalsThreshold: 1,
+			NextTxnID:             0,
+			InitialBalance:        big_spec.Zero(),
+			StartEpoch:            0,
+			UnlockDuration:        0,
+		})
+		td.AssertBalance(multisigAddr, big_spec.Sub(multisigBal, toSend))
+	})
+
+	t.Run("approval creates payment channel", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, initialBal)
+		bob, bobID := td.NewAccountActor(drivers.SECP, initialBal)
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(bobID))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, multisigBal, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{Signers: []address.Address{aliceID, bobID}, NumApprovalsThreshold: 2},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		proposal := execProposal(td.ActorCode(drivers.PaychActor),
+			chain.MustSerialize(&paych_spec.ConstructorParams{From: aliceID, To: bobID}))
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, proposal, chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 0, Applied: false, Code: exitcode_spec.Ok, Ret: nil}))
+
+		// The channel is created by bob's approval, so its address derives from bob's message.
+		paychRet := td.ComputeInitActorExecReturn(bob, 0, 0, utils.NewIDAddr(t, 1+utils.IdFromAddress(multisigAddr)))
+		ph := makeProposalHash(t, &multisig_spec.Transaction{
+			To:       proposal.To,
+			Value:    proposal.Value,
+			Method:   proposal.Method,
+			Params:   proposal.Params,
+			Approved: []address.Address{aliceID},
+		})
+		td.ApplyExpect(
+			td.MessageProducer.MultisigApprove(bob, multisigAddr, &multisig_spec.TxnIDParams{ID: 0, ProposalHash: ph}, chain.Nonce(0)),
+			chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: exitcode_spec.Ok, Ret: chain.MustSerialize(&paychRet)}))
+
+		assertCreated(td, paychRet, td.ActorCode(drivers.PaychActor))
+		td.AssertBalance(multisigAddr, big_spec.Sub(multisigBal, toSend))
+	})
+}
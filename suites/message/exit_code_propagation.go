@@ -0,0 +1,81 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test which exit code reaches the top-level receipt when an actor aborts at the bottom of a chain of nested sends,
+// using the probe's Relay method. A code propagated by every caller is the receipt's exit code, unchanged, while a
+// code wrapped by a caller in its return value leaves the receipt Ok, with the code as its return value.
+func MessageTest_ExitCodePropagation(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var aliceBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+
+	abortCodes := []exitcode_spec.ExitCode{
+		exitcode_spec.ErrIllegalArgument,
+		exitcode_spec.ErrNotFound,
+		exitcode_spec.ErrInsufficientFunds,
+		exitcode_spec.FirstActorSpecificExitCode,
+		exitcode_spec.FirstActorSpecificExitCode + 100,
+	}
+	noWrap := int64(-1)
+	testCases := []struct {
+		depth     int64
+		wrapDepth int64
+	}{
+		{0, noWrap},
+		{1, noWrap},
+		{1, 1},
+		{4, noWrap},
+		{4, 1},
+		{4, 2},
+		{4, 4},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		desc := fmt.Sprintf("abort at depth %d propagated", tc.depth)
+		if tc.wrapDepth != noWrap {
+			desc = fmt.Sprintf("abort at depth %d wrapped at depth %d", tc.depth, tc.wrapDepth)
+		}
+		t.Run(desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+			probeAddr := td.NewProbeActor(big_spec.Zero())
+
+			for i, code := range abortCodes {
+				msg := td.MessageProducer.ProbeRelay(alice, probeAddr, &probe.RelayParams{
+					Depth:     tc.depth,
+					AbortCode: int64(code),
+					WrapDepth: tc.wrapDepth,
+				}, chain.Nonce(uint64(i)))
+
+				if tc.wrapDepth == noWrap {
+					td.ApplyFailure(msg, code)
+					continue
+				}
+				wrapped := cbg.CborInt(code)
+				td.ApplyExpect(msg, chain.MustSerialize(&wrapped))
+			}
+		})
+	}
+}
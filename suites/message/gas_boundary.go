@@ -0,0 +1,63 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Tests the gas limit at which each of a range of messages goes from running out of gas to succeeding. Out of gas
+// at the last charge of a message, e.g. that for its return value, must abort it having used the whole limit.
+func MessageTest_GasLimitBoundaries(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var senderBal = abi_spec.NewTokenAmount(1_000_000_000_000_000)
+	var value = abi_spec.NewTokenAmount(10_000)
+
+	testCases := []struct {
+		desc string
+		msg  func(td *drivers.TestDriver, sender, senderID address.Address) *types.Message
+	}{
+		{"transfer to existing account", func(td *drivers.TestDriver, sender, _ address.Address) *types.Message {
+			receiver, _ := td.NewAccountActor(drivers.SECP, abi_spec.NewTokenAmount(0))
+			return td.MessageProducer.Transfer(sender, receiver, chain.Value(value), chain.Nonce(0))
+		}},
+		{"transfer creating SECP256K1 account", func(td *drivers.TestDriver, sender, _ address.Address) *types.Message {
+			return td.MessageProducer.Transfer(sender, td.Wallet().NewSECP256k1AccountAddress(), chain.Value(value), chain.Nonce(0))
+		}},
+		{"transfer creating BLS account", func(td *drivers.TestDriver, sender, _ address.Address) *types.Message {
+			return td.MessageProducer.Transfer(sender, td.Wallet().NewBLSAccountAddress(), chain.Value(value), chain.Nonce(0))
+		}},
+		{"miner control addresses", func(td *drivers.TestDriver, sender, _ address.Address) *types.Message {
+			return td.MessageProducer.MinerControlAddresses(sender, td.ExeCtx.Miner, nil, chain.Nonce(0))
+		}},
+		{"create payment channel", func(td *drivers.TestDriver, sender, _ address.Address) *types.Message {
+			receiver, _ := td.NewAccountActor(drivers.SECP, abi_spec.NewTokenAmount(0))
+			return td.MessageProducer.CreatePaymentChannelActor(sender, receiver, chain.Value(value), chain.Nonce(0))
+		}},
+		{"create multisig", func(td *drivers.TestDriver, sender, senderID address.Address) *types.Message {
+			return td.MessageProducer.CreateMultisigActor(sender, []address.Address{senderID}, 0, 1, chain.Value(value), chain.Nonce(0))
+		}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, senderID := td.NewAccountActor(drivers.SECP, senderBal)
+			td.AssertGasBoundary(tc.msg(td, sender, senderID))
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// MessageTest_GenesisRoot checks the state root of the default builtin actors against their canonical root, so that
+// implementations verify their genesis construction before any message is applied, and that each HAMT and AMT of the
+// state is built with the parameters of the specs. The default builtin actors are those of actors v0 at every network
+// version, so it is checked once.
+func MessageTest_GenesisRoot(t *testing.T, factory state.Factories) {
+	td := drivers.NewBuilder(context.Background(), factory).
+		WithActorState(drivers.DefaultBuiltinActorsState...).
+		Build(t)
+	defer td.Complete()
+
+	td.AssertGenesisRoot()
+	td.AssertCollectionsCanonical()
+}
@@ -0,0 +1,160 @@
+package message
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	account_spec "github.com/filecoin-project/specs-actors/actors/builtin/account"
+	cron_spec "github.com/filecoin-project/specs-actors/actors/builtin/cron"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	market_spec "github.com/filecoin-project/specs-actors/actors/builtin/market"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	paych_spec "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	power_spec "github.com/filecoin-project/specs-actors/actors/builtin/power"
+	reward_spec "github.com/filecoin-project/specs-actors/actors/builtin/reward"
+	system_spec "github.com/filecoin-project/specs-actors/actors/builtin/system"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// An actor to which messages with invalid method numbers are sent.
+type invalidMethodTarget struct {
+	desc    string
+	exports []interface{}
+	// setup installs the actor if needed, returning its address and the next nonce of the sender.
+	setup func(td *drivers.TestDriver, sender, senderID address.Address) (address.Address, uint64)
+}
+
+func singletonTarget(addr address.Address) func(*drivers.TestDriver, address.Address, address.Address) (address.Address, uint64) {
+	return func(_ *drivers.TestDriver, _, _ address.Address) (address.Address, uint64) {
+		return addr, 0
+	}
+}
+
+func builtinInvalidMethodTargets() []invalidMethodTarget {
+	return []invalidMethodTarget{
+		{"account", account_spec.Actor{}.Exports(), func(_ *drivers.TestDriver, _, senderID address.Address) (address.Address, uint64) {
+			return senderID, 0
+		}},
+		{"init", init_spec.Actor{}.Exports(), singletonTarget(builtin_spec.InitActorAddr)},
+		{"reward", reward_spec.Actor{}.Exports(), singletonTarget(builtin_spec.RewardActorAddr)},
+		{"cron", cron_spec.Actor{}.Exports(), singletonTarget(builtin_spec.CronActorAddr)},
+		{"power", power_spec.Actor{}.Exports(), singletonTarget(builtin_spec.StoragePowerActorAddr)},
+		{"market", market_spec.Actor{}.Exports(), singletonTarget(builtin_spec.StorageMarketActorAddr)},
+		{"system", system_spec.Actor{}.Exports(), singletonTarget(builtin_spec.SystemActorAddr)},
+		{"miner", miner_spec.Actor{}.Exports(), func(td *drivers.TestDriver, _, _ address.Address) (address.Address, uint64) {
+			return td.ExeCtx.Miner, 0
+		}},
+		{"multisig", multisig_spec.Actor{}.Exports(), func(td *drivers.TestDriver, sender, senderID address.Address) (address.Address, uint64) {
+			msAddr := utils.NewIDAddr(td.T, utils.IdFromAddress(senderID)+1)
+			createRet := td.ComputeInitActorExecReturn(sender, 0, 0, msAddr)
+			td.ApplyExpect(
+				td.MessageProducer.CreateMultisigActor(sender, []address.Address{senderID}, 0, 1, chain.Nonce(0)),
+				chain.MustSerialize(&createRet))
+			return msAddr, 1
+		}},
+		{"paych", paych_spec.Actor{}.Exports(), func(td *drivers.TestDriver, sender, senderID address.Address) (address.Address, uint64) {
+			receiver, receiverID := td.NewAccountActor(drivers.SECP, abi_spec.NewTokenAmount(0))
+			paychAddr := utils.NewIDAddr(td.T, utils.IdFromAddress(receiverID)+1)
+			createRet := td.ComputeInitActorExecReturn(sender, 0, 0, paychAddr)
+			td.ApplyExpect(
+				td.MessageProducer.CreatePaymentChannelActor(sender, receiver, chain.Nonce(0)),
+				chain.MustSerialize(&createRet))
+			return paychAddr, 1
+		}},
+	}
+}
+
+// Tests sending method numbers outside each builtin actor's exports, from the one past the last method exported to the
+// largest, with and without value, and sending method 0. The params are well-formed, so that any failure is due to the
+// method number alone. An unknown method aborts with SysErrInvalidMethod and transfers no value, while method 0 is a
+// plain transfer that succeeds for any recipient, without invoking it.
+func MessageTest_InvalidMethodNumbers(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var senderBal = abi_spec.NewTokenAmount(1_000_000_000_000_000)
+
+	for _, target := range builtinInvalidMethodTargets() {
+		target := target
+		t.Run("invalid methods "+target.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, senderID := td.NewAccountActor(drivers.SECP, senderBal)
+			to, nonce := target.setup(td, sender, senderID)
+			params := chain.MustSerialize(&senderID)
+
+			// apply sends `method` with `value`, asserting its exit code and that value moves only if it succeeds.
+			apply := func(method abi_spec.MethodNum, value abi_spec.TokenAmount, code exitcode.ExitCode) {
+				prevSenderBal := td.GetBalance(senderID)
+				prevToBal := td.GetBalance(to)
+
+				msg := td.MessageProducer.Build(sender, to, method, params, chain.Value(value), chain.Nonce(nonce))
+				nonce++
+				var result types.ApplyMessageResult
+				if code.IsSuccess() {
+					result = td.ApplyOk(msg)
+				} else {
+					result = td.ApplyFailure(msg, code)
+				}
+
+				expectedSenderBal := big_spec.Sub(prevSenderBal, td.CalcMessageCost(msg.GasLimit, msg.GasPremium, value, result.Receipt))
+				expectedToBal := prevToBal
+				if code.IsSuccess() {
+					expectedToBal = big_spec.Add(expectedToBal, value)
+				}
+				if to == builtin_spec.RewardActorAddr {
+					// The reward actor also receives the message's gas tip.
+					expectedToBal = big_spec.Add(expectedToBal, drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, result.Receipt.GasUsed).MinerTip())
+				}
+				if to == senderID {
+					// The account target is the sender itself, to which any value transferred returns.
+					if code.IsSuccess() {
+						expectedSenderBal = big_spec.Add(expectedSenderBal, value)
+					}
+				} else {
+					td.AssertBalance(to, expectedToBal)
+				}
+				td.AssertBalance(senderID, expectedSenderBal)
+			}
+
+			// Exports are indexed by method number, so its length is the first unexported number.
+			unknown := []abi_spec.MethodNum{
+				abi_spec.MethodNum(len(target.exports)),
+				abi_spec.MethodNum(len(target.exports) + 1),
+				1 << 20,
+				// The largest method numbers, which are negative if read as signed.
+				1 << 63,
+				math.MaxUint64,
+			}
+			for _, value := range []abi_spec.TokenAmount{big_spec.Zero(), abi_spec.NewTokenAmount(1)} {
+				td.AssertHeadUnchanged(to, func() {
+					for _, method := range unknown {
+						apply(method, value, exitcode.SysErrInvalidMethod)
+					}
+				})
+			}
+
+			// Method 0 transfers value without invoking the recipient, so its state is unchanged and params ignored.
+			td.AssertHeadUnchanged(to, func() {
+				apply(builtin_spec.MethodSend, big_spec.Zero(), exitcode.Ok)
+				apply(builtin_spec.MethodSend, abi_spec.NewTokenAmount(1), exitcode.Ok)
+			})
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	adt_spec "github.com/filecoin-project/specs-actors/actors/util/adt"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Params of a method that must fail to decode as its params type.
+type invalidParams struct {
+	desc   string
+	params []byte
+}
+
+// invalidParamsFor returns structurally invalid params for a method taking params of `paramsType`, a pointer type.
+// Valid params followed by trailing bytes aren't among them, as implementations needn't reject bytes left over after
+// decoding params.
+func invalidParamsFor(t testing.TB, paramsType reflect.Type) []invalidParams {
+	var cases []invalidParams
+
+	// Params of every builtin method are encoded as a CBOR array, byte string or integer, never as text.
+	var wrongType bytes.Buffer
+	if err := cbg.CborWriteHeader(&wrongType, cbg.MajTextString, 7); err != nil {
+		t.Fatal(err)
+	}
+	wrongType.WriteString("garbage")
+	cases = append(cases, invalidParams{"wrong type", wrongType.Bytes()})
+
+	// Structs are encoded as tuples, which must have exactly as many elements as the struct has fields.
+	if paramsType.Elem().Kind() == reflect.Struct {
+		fields := paramsType.Elem().NumField()
+		var wrongCount bytes.Buffer
+		if err := cbg.CborWriteHeader(&wrongCount, cbg.MajArray, uint64(fields+1)); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i <= fields; i++ {
+			if err := cbg.CborWriteHeader(&wrongCount, cbg.MajUnsignedInt, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+		cases = append(cases, invalidParams{"wrong field count", wrongCount.Bytes()})
+	}
+
+	return cases
+}
+
+// Tests sending structurally invalid params to every exported method of each builtin actor: params of the wrong CBOR
+// type and tuples of the wrong length. Each must fail to deserialize before the method is invoked, leaving the actor
+// unchanged.
+func MessageTest_InvalidParams(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var senderBal = drivers.FIL(1_000)
+	// Methods without params needn't read them, so ignore any they're sent.
+	emptyParamsType := reflect.TypeOf(&adt_spec.EmptyValue{})
+
+	for _, target := range builtinInvalidMethodTargets() {
+		target := target
+		t.Run("invalid params "+target.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, senderID := td.NewAccountActor(drivers.SECP, senderBal)
+			to, nonce := target.setup(td, sender, senderID)
+
+			td.AssertHeadUnchanged(to, func() {
+				for num, export := range target.exports {
+					if export == nil {
+						continue
+					}
+					// Exported methods take the runtime and a pointer to their params.
+					paramsType := reflect.TypeOf(export).In(1)
+					if paramsType == emptyParamsType {
+						continue
+					}
+					for _, p := range invalidParamsFor(t, paramsType) {
+						t.Logf("method %d with %s", num, p.desc)
+						td.ApplyFailure(
+							td.MessageProducer.Build(sender, to, abi_spec.MethodNum(num), p.params, chain.Nonce(nonce)),
+							exitcode.ErrSerialization)
+						nonce++
+					}
+				}
+			})
+		})
+	}
+}
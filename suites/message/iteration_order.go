@@ -0,0 +1,156 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	address "github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// Test that collections built up by a sequence of messages have a single encoding, whatever order their entries
+// were added or removed in. Implementations that iterate or rebuild HAMTs and arrays in a different order produce
+// different state roots and return values, which otherwise only show up as unexplained receipt mismatches.
+func MessageTest_DeterministicIterationOrder(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	var msValue = abi_spec.NewTokenAmount(1_000_000)
+
+	t.Run("pending transactions independent of removal order", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, initialBal)
+		_, bobId := td.NewAccountActor(drivers.SECP, initialBal)
+
+		// Two multisigs with the same signers, which can't apply transactions without bob's approval.
+		params := &multisig_spec.ConstructorParams{
+			Signers:               []address.Address{aliceId, bobId},
+			NumApprovalsThreshold: 2,
+		}
+		multisigs := []address.Address{
+			utils.NewIDAddr(t, 1+utils.IdFromAddress(bobId)),
+			utils.NewIDAddr(t, 2+utils.IdFromAddress(bobId)),
+		}
+		for i, multisigAddr := range multisigs {
+			createRet := td.ComputeInitActorExecReturn(alice, uint64(i), 0, multisigAddr)
+			td.MustCreateAndVerifyMultisigActor(uint64(i), msValue, multisigAddr, alice, params, exitcode_spec.Ok, chain.MustSerialize(&createRet))
+		}
+		nonce := uint64(len(multisigs))
+
+		// alice proposes the same four transactions to each, then cancels the first and third in opposite orders.
+		const numTxns = 4
+		txns := make([]multisig_spec.Transaction, numTxns)
+		for i := range txns {
+			txns[i] = multisig_spec.Transaction{
+				To:       bobId,
+				Value:    abi_spec.NewTokenAmount(int64(i + 1)),
+				Method:   builtin_spec.MethodSend,
+				Approved: []address.Address{aliceId},
+			}
+		}
+		for _, multisigAddr := range multisigs {
+			for i, txn := range txns {
+				expected := multisig_spec.ProposeReturn{TxnID: multisig_spec.TxnID(i)}
+				td.ApplyExpect(
+					td.MessageProducer.MultisigPropose(alice, multisigAddr, &multisig_spec.ProposeParams{
+						To:     txn.To,
+						Value:  txn.Value,
+						Method: txn.Method,
+					}, chain.Nonce(nonce)),
+					chain.MustSerialize(&expected))
+				nonce++
+			}
+		}
+
+		cancelOrders := [][]multisig_spec.TxnID{{0, 2}, {2, 0}}
+		for i, multisigAddr := range multisigs {
+			for _, id := range cancelOrders[i] {
+				td.ApplyOk(td.MessageProducer.MultisigCancel(alice, multisigAddr, &multisig_spec.TxnIDParams{
+					ID:           id,
+					ProposalHash: makeProposalHash(t, &txns[id]),
+				}, chain.Nonce(nonce)))
+				nonce++
+			}
+		}
+
+		var first, second multisig_spec.State
+		td.GetActorState(multisigs[0], &first)
+		td.GetActorState(multisigs[1], &second)
+		assert.Equal(t, first.PendingTxns, second.PendingTxns, "pending transactions encode differently after removal in a different order")
+		for _, multisigAddr := range multisigs {
+			td.AssertMultisigContainsTransaction(multisigAddr, 0, false)
+			td.AssertMultisigTransaction(multisigAddr, 1, txns[1])
+			td.AssertMultisigContainsTransaction(multisigAddr, 2, false)
+			td.AssertMultisigTransaction(multisigAddr, 3, txns[3])
+		}
+	})
+
+	t.Run("signers keep their order", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, initialBal)
+		_, bobId := td.NewAccountActor(drivers.SECP, initialBal)
+		_, carolId := td.NewAccountActor(drivers.SECP, initialBal)
+
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(carolId))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, msValue, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{
+				Signers:               []address.Address{carolId, aliceId, bobId},
+				NumApprovalsThreshold: 1,
+			},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		assertSigners := func(expected ...address.Address) {
+			var st multisig_spec.State
+			td.GetActorState(multisigAddr, &st)
+			assert.Equal(t, expected, st.Signers)
+		}
+		assertSigners(carolId, aliceId, bobId)
+
+		// Each change is proposed by alice and applied immediately.
+		nonce := uint64(1)
+		txnID := multisig_spec.TxnID(0)
+		propose := func(method abi_spec.MethodNum, params []byte) {
+			expected := multisig_spec.ProposeReturn{TxnID: txnID, Applied: true, Code: exitcode_spec.Ok}
+			td.ApplyExpect(
+				td.MessageProducer.MultisigPropose(alice, multisigAddr, &multisig_spec.ProposeParams{
+					To:     multisigAddr,
+					Value:  big_spec.Zero(),
+					Method: method,
+					Params: params,
+				}, chain.Nonce(nonce)),
+				chain.MustSerialize(&expected))
+			nonce++
+			txnID++
+		}
+
+		// Removing a signer preserves the order of the rest.
+		propose(builtin_spec.MethodsMultisig.RemoveSigner, chain.MustSerialize(&multisig_spec.RemoveSignerParams{Signer: carolId}))
+		assertSigners(aliceId, bobId)
+
+		// Adding a signer appends it.
+		propose(builtin_spec.MethodsMultisig.AddSigner, chain.MustSerialize(&multisig_spec.AddSignerParams{Signer: carolId}))
+		assertSigners(aliceId, bobId, carolId)
+
+		propose(builtin_spec.MethodsMultisig.RemoveSigner, chain.MustSerialize(&multisig_spec.RemoveSignerParams{Signer: bobId}))
+		assertSigners(aliceId, carolId)
+	})
+}
@@ -0,0 +1,78 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// largeStateMessages is the number of messages of each kind MessageTest_LargeState applies.
+const largeStateMessages = 100
+
+// Test message application on a state of the size of a busy network, drivers.LargeStateGenesis, for implementations
+// to measure their performance with CHAIN_VALIDATION_PROFILE set. The state is constructed and cached on the first
+// run, which takes minutes, so the suite is skipped in short mode. It applies transfers between genesis accounts
+// spread across the state tree, transfers creating accounts, and market deposits for genesis miners, whose escrow
+// table holds the balances of every party to the genesis deals, checking the results of each.
+func MessageTest_LargeState(t *testing.T, factory state.Factories) {
+	if testing.Short() {
+		t.Skip("constructing the large state takes minutes")
+	}
+
+	td := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithNonceTracking().
+		WithCachedGenesis(drivers.LargeStateGenesis()).
+		Build(t)
+	defer td.Complete()
+
+	gen := td.Genesis
+	require.Len(t, gen.Accounts, drivers.LargeStateAccounts)
+	require.Len(t, gen.Miners, drivers.LargeStateMiners)
+	require.Len(t, gen.Deals, drivers.LargeStateDeals)
+	td.Market().AssertNextDealID(abi_spec.DealID(drivers.LargeStateDeals))
+
+	// The accounts sending each kind of message are spread across the genesis accounts, and send to accounts far from
+	// them, so that their lookups touch different parts of the state tree.
+	stride := drivers.LargeStateAccounts / largeStateMessages
+	sender := func(i int) drivers.GenesisAccount {
+		return gen.Accounts[i*stride]
+	}
+	value := abi_spec.NewTokenAmount(1_000)
+
+	// Transfers between genesis accounts.
+	for i := 0; i < largeStateMessages; i++ {
+		to := gen.Accounts[(i*stride+drivers.LargeStateAccounts/2)%drivers.LargeStateAccounts].ID
+		prevBal := td.GetBalance(to)
+		td.ApplyOk(td.MessageProducer.Transfer(sender(i).PubKey, to, chain.Value(value)))
+		td.AssertBalance(to, big_spec.Add(prevBal, value))
+	}
+
+	// Transfers creating accounts, adding to the init actor's address map.
+	for i := 0; i < largeStateMessages; i++ {
+		to := utils.NewSECP256K1Addr(t, fmt.Sprintf("large state %d", i))
+		td.ApplyOk(td.MessageProducer.Transfer(sender(i).PubKey, to, chain.Value(value)))
+		td.AssertBalance(to, value)
+	}
+
+	// Market deposits for genesis miners, for which the market looks up the miner's control addresses.
+	minerStride := drivers.LargeStateMiners / largeStateMessages
+	for i := 0; i < largeStateMessages; i++ {
+		minerAddr := gen.Miners[i*minerStride].ID
+		prevEscrow := td.Market().Escrow(minerAddr)
+		td.ApplyOk(td.MessageProducer.MarketAddBalance(sender(i).PubKey, builtin_spec.StorageMarketActorAddr, &minerAddr, chain.Value(value)))
+		td.Market().AssertEscrow(minerAddr, big_spec.Add(prevEscrow, value))
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
 	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
 	paych_spec "github.com/filecoin-project/specs-actors/actors/builtin/paych"
 	crypto_spec "github.com/filecoin-project/specs-actors/actors/crypto"
 
@@ -160,6 +161,29 @@ func MessageTest_MessageApplicationEdgecases(t *testing.T, factory state.Factori
 			exitcode.SysErrInvalidMethod)
 	})
 
+	t.Run("read-only methods leave receiver state unchanged", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, aliceBal)
+		minerInfo := td.BuiltinMinerInfo()
+
+		td.AssertHeadUnchanged(td.ExeCtx.Miner, func() {
+			td.ApplyExpect(
+				td.MessageProducer.MinerControlAddresses(alice, td.ExeCtx.Miner, nil, chain.Nonce(0)),
+				chain.MustSerialize(&miner_spec.GetControlAddressesReturn{
+					Owner:  minerInfo.OwnerID,
+					Worker: minerInfo.WorkerID,
+				}))
+		})
+
+		td.AssertHeadUnchanged(aliceId, func() {
+			td.ApplyExpect(
+				td.MessageProducer.AccountPubkeyAddress(alice, aliceId, nil, chain.Nonce(1)),
+				chain.MustSerialize(&alice))
+		})
+	})
+
 	t.Run("receiver ID/Actor address does not exist", func(t *testing.T) {
 		td := builder.Build(t)
 		defer td.Complete()
@@ -0,0 +1,91 @@
+package message
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test the rules for including a message in a block, checked by consensus apart from execution: a message's nonce must
+// follow that of its sender's last message, in the state or earlier in the block, and its version must be zero. Block
+// validation doesn't check that the sender's balance covers the message's maximum cost, a message pool policy left to
+// each implementation, so neither does this suite. Suites are skipped for implementations without
+// state.MessageValidatingApplier.
+func MessageTest_MessageValidation(t *testing.T, factory state.Factories) {
+	const gasLimit = 1_000_000
+	const gasFeeCap = 200
+
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(gasFeeCap).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	value := abi_spec.NewTokenAmount(100)
+	// maxCost is the most a message sent with the default gas parameters and `value` may cost its sender.
+	maxCost := big_spec.Add(big_spec.Mul(big_spec.NewInt(gasLimit), big_spec.NewInt(gasFeeCap)), value)
+
+	t.Run("nonces", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, big_spec.Mul(maxCost, big_spec.NewInt(10)))
+		bob, _ := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+		transfer := func(nonce uint64) *types.Message {
+			return td.MessageProducer.Transfer(alice, bob, chain.Value(value), chain.Nonce(nonce))
+		}
+
+		td.AssertValidForBlock(nil, transfer(0))
+		td.AssertInvalidForBlock(nil, transfer(1), "nonce gap after the state")
+		td.AssertValidForBlock([]*types.Message{transfer(0)}, transfer(1))
+		td.AssertValidForBlock([]*types.Message{transfer(0), transfer(1)}, transfer(2))
+		td.AssertInvalidForBlock([]*types.Message{transfer(0)}, transfer(2), "nonce gap after an earlier message")
+
+		// Once a message is applied, its nonce may not be reused.
+		td.ApplyOk(transfer(0))
+		td.AssertInvalidForBlock(nil, transfer(0), "nonce already used in the state")
+		td.AssertValidForBlock(nil, transfer(1))
+	})
+
+	t.Run("duplicate nonce with different content", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, big_spec.Mul(maxCost, big_spec.NewInt(10)))
+		bob, _ := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		first := td.MessageProducer.Transfer(alice, bob, chain.Value(value), chain.Nonce(0))
+		other := td.MessageProducer.Transfer(alice, bob, chain.Value(big_spec.Add(value, big_spec.NewInt(1))), chain.Nonce(0))
+		td.AssertInvalidForBlock([]*types.Message{first}, other, "nonce of an earlier message with other content")
+		td.AssertInvalidForBlock([]*types.Message{first}, first, "nonce of an identical earlier message")
+		// Messages from other senders don't take the nonce.
+		carol, _ := td.NewAccountActor(drivers.SECP, big_spec.Mul(maxCost, big_spec.NewInt(10)))
+		td.AssertValidForBlock([]*types.Message{td.MessageProducer.Transfer(carol, bob, chain.Value(value), chain.Nonce(0))}, other)
+	})
+
+	t.Run("unsupported message version", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, big_spec.Mul(maxCost, big_spec.NewInt(10)))
+		bob, _ := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		// Messages of any version but zero may not be included in a block. The version isn't part of a message's
+		// serialization, so it is checked on the message passed to the implementation rather than its signature.
+		for _, version := range []uint64{1, 2, math.MaxUint64} {
+			msg := td.MessageProducer.Transfer(alice, bob, chain.Value(value), chain.Nonce(0), chain.Version(version))
+			td.AssertInvalidForBlock(nil, msg, fmt.Sprintf("version %d", version))
+		}
+		td.AssertValidForBlock(nil, td.MessageProducer.Transfer(alice, bob, chain.Value(value), chain.Nonce(0), chain.Version(0)))
+	})
+
+}
@@ -197,7 +197,7 @@ func MessageTest_MultiSigActor(t *testing.T, factory state.Factories) {
 			exitcode_spec.ErrForbidden)
 
 		// increment the epoch to unlock the funds
-		td.ExeCtx.Epoch += unlockDuration
+		td.AdvanceEpoch(unlockDuration)
 		balanceBefore := td.GetBalance(outsider)
 
 		// bob approves transfer of 'valueSend' FIL to outsider.
@@ -292,6 +292,165 @@ func MessageTest_MultiSigActor(t *testing.T, factory state.Factories) {
 			UnlockDuration:        0,
 		})
 	})
+
+	t.Run("approve with mismatched proposal hash", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+		var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+		const numApprovals = 2
+		var valueSend = abi_spec.NewTokenAmount(10)
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, initialBal)
+		bob, bobId := td.NewAccountActor(drivers.SECP, initialBal)
+		outsider, outsiderId := td.NewAccountActor(drivers.SECP, initialBal)
+
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(outsiderId))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, valueSend, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{
+				Signers:               []address.Address{aliceId, bobId},
+				NumApprovalsThreshold: numApprovals,
+				UnlockDuration:        0,
+			},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		pparams := multisig_spec.ProposeParams{
+			To:     outsider,
+			Value:  valueSend,
+			Method: builtin_spec.MethodSend,
+			Params: nil,
+		}
+		txID0 := multisig_spec.TxnID(0)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, &pparams, chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: txID0, Applied: false, Code: 0, Ret: nil}))
+		txn0 := multisig_spec.Transaction{
+			To:       pparams.To,
+			Value:    pparams.Value,
+			Method:   pparams.Method,
+			Params:   pparams.Params,
+			Approved: []address.Address{aliceId},
+		}
+
+		// bob approves with the hash of a transaction differing from that proposed. Each approval aborts, leaving
+		// the transaction pending with alice's approval only.
+		mismatched := []struct {
+			desc string
+			txn  multisig_spec.Transaction
+		}{
+			{"value", multisig_spec.Transaction{To: txn0.To, Value: big_spec.Add(valueSend, big_spec.NewInt(1)), Method: txn0.Method, Params: txn0.Params, Approved: txn0.Approved}},
+			{"params", multisig_spec.Transaction{To: txn0.To, Value: txn0.Value, Method: txn0.Method, Params: []byte{0}, Approved: txn0.Approved}},
+			{"method", multisig_spec.Transaction{To: txn0.To, Value: txn0.Value, Method: builtin_spec.MethodsAccount.PubkeyAddress, Params: txn0.Params, Approved: txn0.Approved}},
+			{"receiver", multisig_spec.Transaction{To: alice, Value: txn0.Value, Method: txn0.Method, Params: txn0.Params, Approved: txn0.Approved}},
+			{"proposer", multisig_spec.Transaction{To: txn0.To, Value: txn0.Value, Method: txn0.Method, Params: txn0.Params, Approved: []address.Address{bobId}}},
+		}
+		nonce := uint64(0)
+		td.AssertHeadUnchanged(multisigAddr, func() {
+			for _, m := range mismatched {
+				t.Logf("proposal hash with changed %s", m.desc)
+				td.ApplyFailure(
+					td.MessageProducer.MultisigApprove(bob, multisigAddr, &multisig_spec.TxnIDParams{ID: txID0, ProposalHash: makeProposalHash(t, &m.txn)}, chain.Nonce(nonce)),
+					exitcode_spec.ErrIllegalArgument)
+				nonce++
+			}
+		})
+		td.AssertMultisigTransaction(multisigAddr, txID0, txn0)
+
+		// With the hash of the proposed transaction, bob's approval applies it.
+		balanceBefore := td.GetBalance(outsider)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigApprove(bob, multisigAddr, &multisig_spec.TxnIDParams{ID: txID0, ProposalHash: makeProposalHash(t, &txn0)}, chain.Nonce(nonce)),
+			chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: 0, Ret: nil}))
+		td.AssertMultisigContainsTransaction(multisigAddr, txID0, false)
+		td.AssertBalance(outsider, big_spec.Add(balanceBefore, valueSend))
+	})
+
+	t.Run("approve after signer removed", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+		var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+		const numApprovals = 2
+		var valueSend = abi_spec.NewTokenAmount(10)
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, initialBal)
+		bob, bobId := td.NewAccountActor(drivers.SECP, initialBal)
+		carol, carolId := td.NewAccountActor(drivers.SECP, initialBal)
+		outsider, outsiderId := td.NewAccountActor(drivers.SECP, initialBal)
+
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(outsiderId))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, valueSend, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{
+				Signers:               []address.Address{aliceId, bobId, carolId},
+				NumApprovalsThreshold: numApprovals,
+				UnlockDuration:        0,
+			},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		// alice proposes a transfer to outsider.
+		transfer := multisig_spec.Transaction{
+			To:       outsider,
+			Value:    valueSend,
+			Method:   builtin_spec.MethodSend,
+			Params:   nil,
+			Approved: []address.Address{aliceId},
+		}
+		txID0 := multisig_spec.TxnID(0)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, &multisig_spec.ProposeParams{
+				To:     transfer.To,
+				Value:  transfer.Value,
+				Method: transfer.Method,
+				Params: transfer.Params,
+			}, chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: txID0, Applied: false, Code: 0, Ret: nil}))
+
+		// alice proposes removing carol, without decreasing the threshold, and bob's approval applies it.
+		removeParams := chain.MustSerialize(&multisig_spec.RemoveSignerParams{Signer: carolId, Decrease: false})
+		removal := multisig_spec.Transaction{
+			To:       multisigAddr,
+			Value:    big_spec.Zero(),
+			Method:   builtin_spec.MethodsMultisig.RemoveSigner,
+			Params:   removeParams,
+			Approved: []address.Address{aliceId},
+		}
+		txID1 := multisig_spec.TxnID(1)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, &multisig_spec.ProposeParams{
+				To:     removal.To,
+				Value:  removal.Value,
+				Method: removal.Method,
+				Params: removal.Params,
+			}, chain.Nonce(2)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: txID1, Applied: false, Code: 0, Ret: nil}))
+		td.ApplyExpect(
+			td.MessageProducer.MultisigApprove(bob, multisigAddr, &multisig_spec.TxnIDParams{ID: txID1, ProposalHash: makeProposalHash(t, &removal)}, chain.Nonce(0)),
+			chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: 0, Ret: nil}))
+		td.AssertMultisigState(multisigAddr, multisig_spec.State{
+			Signers:               []address.Address{aliceId, bobId},
+			NumApprovalsThreshold: numApprovals,
+			NextTxnID:             multisig_spec.TxnID(2),
+			InitialBalance:        big_spec.Zero(),
+			StartEpoch:            0,
+			UnlockDuration:        0,
+		})
+
+		// carol may no longer approve the pending transfer.
+		td.AssertHeadUnchanged(multisigAddr, func() {
+			td.ApplyFailure(
+				td.MessageProducer.MultisigApprove(carol, multisigAddr, &multisig_spec.TxnIDParams{ID: txID0, ProposalHash: makeProposalHash(t, &transfer)}, chain.Nonce(0)),
+				exitcode_spec.ErrForbidden)
+		})
+		td.AssertMultisigTransaction(multisigAddr, txID0, transfer)
+
+		// bob, still a signer, approves the transfer, applying it.
+		balanceBefore := td.GetBalance(outsider)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigApprove(bob, multisigAddr, &multisig_spec.TxnIDParams{ID: txID0, ProposalHash: makeProposalHash(t, &transfer)}, chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: 0, Ret: nil}))
+		td.AssertMultisigContainsTransaction(multisigAddr, txID0, false)
+		td.AssertBalance(outsider, big_spec.Add(balanceBefore, valueSend))
+	})
 }
 
 func makeProposalHash(t *testing.T, txn *multisig_spec.Transaction) []byte {
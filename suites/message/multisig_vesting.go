@@ -0,0 +1,256 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	address "github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+func MessageTest_MultiSigVestingAndSigners(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var initialBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+
+	t.Run("locked balance vests linearly over unlock duration", func(t *testing.T) {
+		const unlockDuration = 10
+		// Divisible by the unlock duration so that the amount unlocked each epoch is exact.
+		var msValue = abi_spec.NewTokenAmount(10_000)
+		var halfValue = big_spec.Div(msValue, big_spec.NewInt(2))
+
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, initialBal)
+		outsider, outsiderId := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(outsiderId))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, msValue, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{
+				Signers:               []address.Address{aliceId},
+				NumApprovalsThreshold: 1,
+				UnlockDuration:        unlockDuration,
+			},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+		startEpoch := td.CurrentEpoch()
+
+		sendParams := func(value abi_spec.TokenAmount) *multisig_spec.ProposeParams {
+			return &multisig_spec.ProposeParams{
+				To:     outsider,
+				Value:  value,
+				Method: builtin_spec.MethodSend,
+				Params: nil,
+			}
+		}
+
+		// Nothing has vested at the start epoch, so even the smallest send is refused.
+		td.ApplyFailure(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, sendParams(abi_spec.NewTokenAmount(1)), chain.Nonce(1)),
+			exitcode_spec.ErrInsufficientFunds)
+
+		// Half way through the unlock duration half the balance has vested.
+		td.SetEpoch(startEpoch + unlockDuration/2)
+
+		// One more than the vested amount is refused.
+		td.ApplyFailure(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, sendParams(big_spec.Add(halfValue, big_spec.NewInt(1))), chain.Nonce(2)),
+			exitcode_spec.ErrInsufficientFunds)
+
+		// Exactly the vested amount is sent. Refused proposals are aborted, so this is the first transaction.
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, sendParams(halfValue), chain.Nonce(3)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 0, Applied: true, Code: exitcode_spec.Ok, Ret: nil}))
+		td.AssertBalance(multisigAddr, halfValue)
+		td.AssertBalance(outsider, halfValue)
+
+		// One epoch before the end of the unlock duration the remainder is still partially locked.
+		td.SetEpoch(startEpoch + unlockDuration - 1)
+		td.ApplyFailure(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, sendParams(halfValue), chain.Nonce(4)),
+			exitcode_spec.ErrInsufficientFunds)
+
+		// Once the unlock duration has elapsed the whole remaining balance is available.
+		td.AdvanceEpoch(1)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, sendParams(halfValue), chain.Nonce(5)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 1, Applied: true, Code: exitcode_spec.Ok, Ret: nil}))
+		td.AssertBalance(multisigAddr, big_spec.Zero())
+		td.AssertBalance(outsider, msValue)
+
+		// Vesting does not alter the actor's vesting parameters.
+		td.AssertMultisigState(multisigAddr, multisig_spec.State{
+			Signers:               []address.Address{aliceId},
+			NumApprovalsThreshold: 1,
+			NextTxnID:             2,
+			InitialBalance:        msValue,
+			StartEpoch:            startEpoch,
+			UnlockDuration:        unlockDuration,
+		})
+	})
+
+	t.Run("pending proposal survives epoch advancement", func(t *testing.T) {
+		const numApprovals = 2
+		var msValue = abi_spec.NewTokenAmount(100)
+
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceId := td.NewAccountActor(drivers.SECP, initialBal)
+		bob, bobId := td.NewAccountActor(drivers.SECP, initialBal)
+		outsider, outsiderId := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(outsiderId))
+		createRet := td.ComputeInitActorExecReturn(alice, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, msValue, multisigAddr, alice,
+			&multisig_spec.ConstructorParams{
+				Signers:               []address.Address{aliceId, bobId},
+				NumApprovalsThreshold: numApprovals,
+				UnlockDuration:        0,
+			},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+		pparams := multisig_spec.ProposeParams{To: outsider, Value: msValue, Method: builtin_spec.MethodSend}
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice, multisigAddr, &pparams, chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 0, Applied: false, Code: exitcode_spec.Ok, Ret: nil}))
+		ph := makeProposalHash(t, &multisig_spec.Transaction{
+			To:       pparams.To,
+			Value:    pparams.Value,
+			Method:   pparams.Method,
+			Params:   pparams.Params,
+			Approved: []address.Address{aliceId},
+		})
+
+		// Multisig proposals carry no expiry: the transaction is still approvable much later.
+		td.AdvanceEpoch(1000)
+		td.AssertMultisigContainsTransaction(multisigAddr, 0, true)
+		td.ApplyExpect(
+			td.MessageProducer.MultisigApprove(bob, multisigAddr, &multisig_spec.TxnIDParams{ID: 0, ProposalHash: ph}, chain.Nonce(0)),
+			chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: exitcode_spec.Ok, Ret: nil}))
+		td.AssertMultisigContainsTransaction(multisigAddr, 0, false)
+		td.AssertBalance(outsider, msValue)
+	})
+
+	t.Run("signer and threshold changes via self-referential proposals", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		// Signers are named identities: their robust addresses send messages, and their ID addresses are those the
+		// multisig records.
+		ids := td.Identities()
+		alice := ids.NewAccount("alice", drivers.SECP, initialBal)
+		bob := ids.NewAccount("bob", drivers.SECP, initialBal)
+		carol := ids.NewAccount("carol", drivers.SECP, initialBal)
+
+		multisigAddr := utils.NewIDAddr(t, 1+utils.IdFromAddress(carol.ID))
+		createRet := td.ComputeInitActorExecReturn(alice.Robust, 0, 0, multisigAddr)
+		td.MustCreateAndVerifyMultisigActor(0, big_spec.Zero(), multisigAddr, alice.Robust,
+			&multisig_spec.ConstructorParams{
+				Signers:               []address.Address{alice.ID},
+				NumApprovalsThreshold: 1,
+				UnlockDuration:        0,
+			},
+			exitcode_spec.Ok, chain.MustSerialize(&createRet))
+		multisig := ids.Add("multisig", createRet.IDAddress, createRet.RobustAddress)
+
+		// selfProposal builds a proposal the multisig sends to itself.
+		selfProposal := func(method abi_spec.MethodNum, params cbg.CBORMarshaler) *multisig_spec.ProposeParams {
+			return &multisig_spec.ProposeParams{
+				To:     multisig.ID,
+				Value:  big_spec.Zero(),
+				Method: method,
+				Params: chain.MustSerialize(params),
+			}
+		}
+		// approveOther approves a pending proposal made by alice.
+		approveOther := func(approver *drivers.Identity, nonce uint64, txnID multisig_spec.TxnID, pparams *multisig_spec.ProposeParams) {
+			ph := makeProposalHash(t, &multisig_spec.Transaction{
+				To:       pparams.To,
+				Value:    pparams.Value,
+				Method:   pparams.Method,
+				Params:   pparams.Params,
+				Approved: []address.Address{alice.ID},
+			})
+			td.ApplyExpect(
+				td.MessageProducer.MultisigApprove(approver.Robust, multisig.ID, &multisig_spec.TxnIDParams{ID: txnID, ProposalHash: ph}, chain.Nonce(nonce)),
+				chain.MustSerialize(&multisig_spec.ApproveReturn{Applied: true, Code: exitcode_spec.Ok, Ret: nil}))
+		}
+
+		// With a threshold of one alice adds bob and raises the threshold, applied immediately.
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice.Robust, multisig.ID,
+				selfProposal(builtin_spec.MethodsMultisig.AddSigner, &multisig_spec.AddSignerParams{Signer: bob.ID, Increase: true}),
+				chain.Nonce(1)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 0, Applied: true, Code: exitcode_spec.Ok, Ret: nil}))
+		assertMultisigSigners(t, td, multisig.ID, 2, alice.ID, bob.ID)
+
+		// Swapping bob for carol now needs bob's approval as well.
+		swap := selfProposal(builtin_spec.MethodsMultisig.SwapSigner, &multisig_spec.SwapSignerParams{From: bob.ID, To: carol.ID})
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice.Robust, multisig.ID, swap, chain.Nonce(2)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 1, Applied: false, Code: exitcode_spec.Ok, Ret: nil}))
+		assertMultisigSigners(t, td, multisig.ID, 2, alice.ID, bob.ID)
+		approveOther(bob, 0, 1, swap)
+		assertMultisigSigners(t, td, multisig.ID, 2, alice.ID, carol.ID)
+
+		// Bob is no longer a signer and cannot propose.
+		td.ApplyFailure(
+			td.MessageProducer.MultisigPropose(bob.Robust, multisig.ID, swap, chain.Nonce(1)),
+			exitcode_spec.ErrForbidden)
+
+		// Lowering the threshold back to one needs carol's approval.
+		lower := selfProposal(builtin_spec.MethodsMultisig.ChangeNumApprovalsThreshold, &multisig_spec.ChangeNumApprovalsThresholdParams{NewThreshold: 1})
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice.Robust, multisig.ID, lower, chain.Nonce(3)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 2, Applied: false, Code: exitcode_spec.Ok, Ret: nil}))
+		approveOther(carol, 0, 2, lower)
+		assertMultisigSigners(t, td, multisig.ID, 1, alice.ID, carol.ID)
+
+		// With a threshold of one alice removes carol alone.
+		td.ApplyExpect(
+			td.MessageProducer.MultisigPropose(alice.Robust, multisig.ID,
+				selfProposal(builtin_spec.MethodsMultisig.RemoveSigner, &multisig_spec.RemoveSignerParams{Signer: carol.ID, Decrease: false}),
+				chain.Nonce(4)),
+			chain.MustSerialize(&multisig_spec.ProposeReturn{TxnID: 3, Applied: true, Code: exitcode_spec.Ok, Ret: nil}))
+		assertMultisigSigners(t, td, multisig.ID, 1, alice.ID)
+
+		// A threshold above the number of signers is rejected by the inner call. The proposal itself is applied and
+		// reports the inner exit code.
+		result := td.ApplyMessage(td.MessageProducer.MultisigPropose(alice.Robust, multisig.ID,
+			selfProposal(builtin_spec.MethodsMultisig.ChangeNumApprovalsThreshold, &multisig_spec.ChangeNumApprovalsThresholdParams{NewThreshold: 2}),
+			chain.Nonce(5)))
+		require.Equal(t, exitcode_spec.Ok, result.Receipt.ExitCode)
+		var ret multisig_spec.ProposeReturn
+		require.NoError(t, ret.UnmarshalCBOR(bytes.NewReader(result.Receipt.ReturnValue)))
+		assert.True(t, ret.Applied)
+		assert.Equal(t, exitcode_spec.ErrIllegalArgument, ret.Code)
+		assertMultisigSigners(t, td, multisig.ID, 1, alice.ID)
+	})
+}
+
+// assertMultisigSigners asserts the multisig has exactly the signers given, in any order, and the given threshold.
+func assertMultisigSigners(t *testing.T, td *drivers.TestDriver, multisigAddr address.Address, threshold uint64, signers ...address.Address) {
+	var msState multisig_spec.State
+	td.GetActorState(multisigAddr, &msState)
+	assert.ElementsMatch(t, signers, msState.Signers)
+	assert.Equal(t, threshold, msState.NumApprovalsThreshold)
+}
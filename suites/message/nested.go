@@ -311,7 +311,7 @@ func MessageTest_NestedSends(t *testing.T, factory state.Factories) {
 			To:   builtin.SystemActorAddr,
 		}
 		execParams := init_.ExecParams{
-			CodeCID:           builtin.PaymentChannelActorCodeID,
+			CodeCID:           td.ActorCode(drivers.PaychActor),
 			ConstructorParams: chain.MustSerialize(&ctorParams),
 		}
 
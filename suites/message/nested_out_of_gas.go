@@ -0,0 +1,101 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test how failures of nested sends after burning gas propagate, using the probe actor. Gas is limited per message,
+// not per send: gas burnt by a nested send is charged to the message even if the send aborts, and its caller may
+// continue, but a nested send running out of gas exhausts the message's gas, failing the whole message with
+// SysErrOutOfGas and rolling back the changes of every send.
+func MessageTest_NestedOutOfGas(t *testing.T, factory state.Factories) {
+	const gasLimit = 100_000_000
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var aliceBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	const burnt = 1_000_000
+	const abortCode = int64(exitcode_spec.FirstActorSpecificExitCode)
+
+	t.Run("caller continues after nested abort, paying its gas", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		// The nested sends burn 2M and 3M gas, which serialize to as many bytes, and abort. Their callers continue,
+		// returning their exit code, and commit their increment of the probe's state.
+		code := cbg.CborInt(abortCode)
+		base := td.ApplyExpect(
+			td.MessageProducer.ProbeCallBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 2 * burnt, AbortCode: abortCode}, chain.Nonce(0)),
+			chain.MustSerialize(&code))
+		more := td.ApplyExpect(
+			td.MessageProducer.ProbeCallBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 3 * burnt, AbortCode: abortCode}, chain.Nonce(1)),
+			chain.MustSerialize(&code))
+		assert.Equal(t, int64(burnt), int64(more.Receipt.GasUsed-base.Receipt.GasUsed), "gas burnt by aborted nested send")
+
+		var st probe.State
+		td.GetActorState(probeAddr, &st)
+		assert.Equal(t, probe.State(2), st)
+	})
+
+	t.Run("nested out of gas fails the message", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		td.AssertHeadUnchanged(probeAddr, func() {
+			td.ApplyMatching(
+				td.MessageProducer.ProbeCallBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 2 * gasLimit}, chain.Nonce(0)),
+				drivers.ExpectReceipt().WithExitCode(exitcode_spec.SysErrOutOfGas).WithGasBetween(gasLimit, gasLimit))
+		})
+		td.AssertCallSeqNum(aliceID, 1)
+	})
+
+	t.Run("nested out of gas with abort code fails the message out of gas", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		// The nested send runs out of gas before reaching its abort, so the abort code is never seen.
+		td.AssertHeadUnchanged(probeAddr, func() {
+			td.ApplyMatching(
+				td.MessageProducer.ProbeCallBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 2 * gasLimit, AbortCode: abortCode}, chain.Nonce(0)),
+				drivers.ExpectReceipt().WithExitCode(exitcode_spec.SysErrOutOfGas).WithGasBetween(gasLimit, gasLimit))
+		})
+	})
+
+	t.Run("top-level out of gas", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		td.AssertHeadUnchanged(probeAddr, func() {
+			td.ApplyMatching(
+				td.MessageProducer.ProbeBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 2 * gasLimit}, chain.Nonce(0)),
+				drivers.ExpectReceipt().WithExitCode(exitcode_spec.SysErrOutOfGas).WithGasBetween(gasLimit, gasLimit))
+		})
+	})
+}
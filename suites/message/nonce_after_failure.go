@@ -0,0 +1,166 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	address "github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// Test whether the sender's nonce advances after a message fails, by the class of its failure. A message failing the
+// checks made before it is executed, of its gas limit covering its on-chain size, of its sender being an account,
+// of its nonce and of its sender's balance covering its gas, is not applied, and leaves the nonce unchanged. Every
+// failure once it is executed, of its value transfer, its receiver, its method or its gas, advances the nonce as a
+// success does. A message valid for the sender's nonce after each failure is then applied, confirming the nonce.
+func MessageTest_NonceAfterFailure(t *testing.T, factory state.Factories) {
+	const gasLimit = 1_000_000_000
+	const gasFeeCap = 200
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(gasFeeCap).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	aliceBal := abi_spec.NewTokenAmount(1_000_000_000_000)
+	transferAmnt := abi_spec.NewTokenAmount(10)
+
+	testCases := []struct {
+		desc string
+		// msg returns the failing message from `alice` at her nonce `nonce`, to `bob`.
+		msg      func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message
+		code     exitcode_spec.ExitCode
+		advances bool
+	}{
+		{
+			desc: "gas limit below on-chain cost",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.Transfer(alice, bob, chain.Value(transferAmnt), chain.Nonce(nonce), chain.GasLimit(1))
+			},
+			code:     exitcode_spec.SysErrOutOfGas,
+			advances: false,
+		},
+		{
+			desc: "nonce too high",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.Transfer(alice, bob, chain.Value(transferAmnt), chain.Nonce(nonce+1))
+			},
+			code:     exitcode_spec.SysErrSenderStateInvalid,
+			advances: false,
+		},
+		{
+			desc: "nonce too low",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				// Apply a message first, so that there is a lower nonce to reuse.
+				td.ApplyOk(td.MessageProducer.Transfer(alice, bob, chain.Value(transferAmnt), chain.Nonce(nonce)))
+				return td.MessageProducer.Transfer(alice, bob, chain.Value(transferAmnt), chain.Nonce(nonce))
+			},
+			code:     exitcode_spec.SysErrSenderStateInvalid,
+			advances: false,
+		},
+		{
+			desc: "balance below gas cost",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.Transfer(alice, bob, chain.Value(transferAmnt), chain.Nonce(nonce),
+					chain.GasFeeCap(big_spec.Div(aliceBal, big_spec.NewInt(gasLimit)).Int64()+1))
+			},
+			code:     exitcode_spec.SysErrSenderStateInvalid,
+			advances: false,
+		},
+		{
+			desc: "value above balance after gas",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.Transfer(alice, bob, chain.Value(aliceBal), chain.Nonce(nonce))
+			},
+			code:     exitcode_spec.SysErrInsufficientFunds,
+			advances: true,
+		},
+		{
+			desc: "unknown receiver",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.Transfer(alice, utils.NewIDAddr(td.T, 10_000_000), chain.Value(transferAmnt), chain.Nonce(nonce))
+			},
+			code:     exitcode_spec.SysErrInvalidReceiver,
+			advances: true,
+		},
+		{
+			desc: "invalid method",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.MarketComputeDataCommitment(alice, bob, nil, chain.Nonce(nonce))
+			},
+			code:     exitcode_spec.SysErrInvalidMethod,
+			advances: true,
+		},
+		{
+			desc: "actor abort",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				// The init actor only execs actors with code it allows, which an account's isn't.
+				return td.MessageProducer.InitExec(alice, builtin_spec.InitActorAddr, &init_spec.ExecParams{
+					CodeCID: td.ActorCode(drivers.AccountActor),
+				}, chain.Nonce(nonce))
+			},
+			code:     exitcode_spec.ErrForbidden,
+			advances: true,
+		},
+		{
+			desc: "out of gas during execution",
+			msg: func(td *drivers.TestDriver, alice, bob address.Address, nonce uint64) *types.Message {
+				// Creating the receiver's account costs more than the message is allowed, though its on-chain size
+				// doesn't.
+				newAccount := utils.NewSECP256K1Addr(td.T, "nonce after failure")
+				call := td.Call(td.MessageProducer.Transfer(alice, newAccount, chain.Value(transferAmnt), chain.Nonce(nonce)))
+				return td.MessageProducer.Transfer(alice, newAccount, chain.Value(transferAmnt), chain.Nonce(nonce),
+					chain.GasLimit(int64(call.Receipt.GasUsed)-1))
+			},
+			code:     exitcode_spec.SysErrOutOfGas,
+			advances: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			alice, aliceId := td.NewAccountActor(drivers.SECP, aliceBal)
+			_, bobId := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+			msg := tc.msg(td, alice, bobId, td.GetCallSeqNum(aliceId))
+			nonce := td.GetCallSeqNum(aliceId)
+			td.ApplyFailure(msg, tc.code)
+
+			expected := nonce
+			if tc.advances {
+				expected++
+			}
+			td.AssertCallSeqNum(aliceId, expected)
+			td.ApplyOk(td.MessageProducer.Transfer(alice, bobId, chain.Value(big_spec.NewInt(1)), chain.Nonce(expected)))
+			td.AssertCallSeqNum(aliceId, expected+1)
+		})
+	}
+
+	t.Run("sender not an account", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		_, bobId := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		// Only accounts may send messages. The miner's nonce is untouched by its attempt.
+		miner := td.ExeCtx.Miner
+		nonce := td.GetCallSeqNum(miner)
+		td.ApplyFailure(td.MessageProducer.Transfer(miner, bobId, chain.Value(transferAmnt), chain.Nonce(nonce)),
+			exitcode_spec.SysErrSenderInvalid)
+		td.AssertCallSeqNum(miner, nonce)
+	})
+}
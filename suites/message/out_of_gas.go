@@ -0,0 +1,180 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	miner_spec "github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	paych_spec "github.com/filecoin-project/specs-actors/actors/builtin/paych"
+	crypto_spec "github.com/filecoin-project/specs-actors/actors/crypto"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// outOfGasSite is a message whose final gas charge is made at a particular charging site. Messages exit, successfully
+// or by aborting, right after their charge at the site, so that the gas they use is that used up to the site.
+type outOfGasSite struct {
+	desc string
+	// code is the exit code of the message applied with ample gas.
+	code exitcode_spec.ExitCode
+	// setup prepares any state the message depends on, for a sender `from` with no messages sent yet. It returns
+	// the sender's next call sequence number.
+	setup func(td *drivers.TestDriver, from address.Address) uint64
+	// message builds the message from `from`. Messages from different senders must serialize to the same length.
+	message func(td *drivers.TestDriver, from address.Address, nonce uint64) *types.Message
+}
+
+// Test messages running out of gas at each distinct charging site. Each message makes its last charge at the site,
+// and is first applied with ample gas to find the gas used up to the site, then applied again from another sender
+// with one unit less, so that it runs out of gas at the site. It must fail with SysErrOutOfGas, charge exactly its gas
+// limit, advance the sender's nonce and leave its receiver untouched.
+func MessageTest_OutOfGasAtChargingSites(t *testing.T, factory state.Factories) {
+	const gasPremium = 1
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(gasPremium).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var senderBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	var transferAmnt = abi_spec.NewTokenAmount(10)
+
+	t.Run("message inclusion", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, senderBal)
+		_, bob := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		// A gas limit of one can't cover the cost of including a message on chain. The miner is penalized for
+		// including it, while the sender isn't charged and its nonce doesn't advance.
+		const gasLimit = 1
+		result := td.ApplyFailure(
+			td.MessageProducer.Transfer(alice, bob, chain.Value(transferAmnt), chain.Nonce(0), chain.GasLimit(gasLimit)),
+			exitcode_spec.SysErrOutOfGas)
+		assert.Equal(t, drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit), result.Penalty)
+		td.AssertBalance(aliceID, senderBal)
+		td.AssertCallSeqNum(aliceID, 0)
+		td.AssertBalance(bob, big_spec.Zero())
+	})
+
+	// A payment channel from each sender to a shared receiver, whose voucher updates charge for signature
+	// verification.
+	var paychReceiver address.Address
+	paychs := make(map[address.Address]address.Address)
+
+	sites := []outOfGasSite{
+		{
+			// A transfer to an account makes no charge after that of the method invocation.
+			desc: "send value transfer",
+			code: exitcode_spec.Ok,
+			setup: func(td *drivers.TestDriver, from address.Address) uint64 {
+				return 0
+			},
+			message: func(td *drivers.TestDriver, from address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.Transfer(from, builtin_spec.BurntFundsActorAddr, chain.Value(transferAmnt), chain.Nonce(nonce))
+			},
+		},
+		{
+			// Changing the peer ID of a miner reads its state and info before checking the caller, which aborts for
+			// a caller other than the worker.
+			desc: "state read",
+			code: exitcode_spec.ErrForbidden,
+			setup: func(td *drivers.TestDriver, from address.Address) uint64 {
+				return 0
+			},
+			message: func(td *drivers.TestDriver, from address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.MinerChangePeerID(from, td.ExeCtx.Miner, &miner_spec.ChangePeerIDParams{NewID: []byte("peer")}, chain.Nonce(nonce))
+			},
+		},
+		{
+			// Adding to an escrow balance commits the market's state last, returning nothing.
+			desc: "state write",
+			code: exitcode_spec.Ok,
+			setup: func(td *drivers.TestDriver, from address.Address) uint64 {
+				return 0
+			},
+			message: func(td *drivers.TestDriver, from address.Address, nonce uint64) *types.Message {
+				return td.MessageProducer.MarketAddBalance(from, builtin_spec.StorageMarketActorAddr, &from, chain.Value(transferAmnt), chain.Nonce(nonce))
+			},
+		},
+		{
+			// A voucher update verifies the voucher's signature, by the channel's receiver, before changing any
+			// state, and aborts if it is invalid.
+			desc: "syscall charge",
+			code: exitcode_spec.ErrIllegalArgument,
+			setup: func(td *drivers.TestDriver, from address.Address) uint64 {
+				if paychReceiver == address.Undef {
+					var receiverID address.Address
+					paychReceiver, receiverID = td.NewAccountActor(drivers.SECP, big_spec.Zero())
+					// The signer may be given by either address.
+					td.SysCalls.FailSignatureVerification(paychReceiver)
+					td.SysCalls.FailSignatureVerification(receiverID)
+				}
+				result := td.ApplyMessage(td.MessageProducer.CreatePaymentChannelActor(from, paychReceiver, chain.Value(transferAmnt), chain.Nonce(0)))
+				require.Equal(td.T, exitcode_spec.Ok, result.Receipt.ExitCode)
+				var execRet init_spec.ExecReturn
+				require.NoError(td.T, execRet.UnmarshalCBOR(bytes.NewReader(result.Receipt.ReturnValue)))
+				paychs[from] = execRet.IDAddress
+				return 1
+			},
+			message: func(td *drivers.TestDriver, from address.Address, nonce uint64) *types.Message {
+				paychAddr := paychs[from]
+				return td.MessageProducer.PaychUpdateChannelState(from, paychAddr, &paych_spec.UpdateChannelStateParams{
+					Sv: paych_spec.SignedVoucher{
+						ChannelAddr: paychAddr,
+						Lane:        1,
+						Nonce:       1,
+						Amount:      transferAmnt,
+						Signature: &crypto_spec.Signature{
+							Type: crypto_spec.SigTypeBLS,
+							Data: []byte("signature goes here"),
+						},
+					},
+				}, chain.Nonce(nonce))
+			},
+		},
+	}
+
+	for _, site := range sites {
+		site := site
+		t.Run(site.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+			paychReceiver = address.Undef
+
+			// Both senders are created before either message is applied, so that they differ only in key.
+			calibrator, _ := td.NewAccountActor(drivers.SECP, senderBal)
+			sender, senderID := td.NewAccountActor(drivers.SECP, senderBal)
+
+			calibNonce := site.setup(td, calibrator)
+			calib := td.ApplyMessage(site.message(td, calibrator, calibNonce))
+			require.Equal(t, site.code, calib.Receipt.ExitCode, "calibration message exit code")
+			gasLimit := calib.Receipt.GasUsed - 1
+
+			nonce := site.setup(td, sender)
+			msg := site.message(td, sender, nonce)
+			msg.GasLimit = int64(gasLimit)
+			prevBal := td.GetBalance(senderID)
+
+			var result types.ApplyMessageResult
+			td.AssertHeadUnchanged(msg.To, func() {
+				result = td.ApplyFailure(msg, exitcode_spec.SysErrOutOfGas)
+			})
+			assert.Equal(t, gasLimit, result.Receipt.GasUsed, "out of gas message must use its whole gas limit")
+			td.AssertActorChange(senderID, prevBal, msg.GasLimit, msg.GasPremium, big_spec.Zero(), result.Receipt, nonce+1)
+		})
+	}
+}
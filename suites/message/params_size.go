@@ -0,0 +1,149 @@
+package message
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Tests the gas charged for the on-chain size of messages with params of sizes up to and beyond
+// drivers.MessageSizeLimit, and for return values of the same sizes. Transfers ignore their params, so the gas used by
+// transfers with different params differs only by the charge per byte of message, which must be the same at every
+// size; likewise for the probe actor returning values of different sizes. Sizes are chosen so that the CBOR header of
+// the params or return value is the same length for all of them. A message at the size limit may be included in a
+// block, while one a byte beyond it may not, though it applies as any other.
+func MessageTest_ParamsSizeGas(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var aliceBal = abi_spec.NewTokenAmount(1_000_000_000_000_000)
+	var transferAmnt = abi_spec.NewTokenAmount(10)
+	sizes := []int{1 << 10, 2 << 10, 16 << 10, drivers.MessageSizeLimit - 1<<10, drivers.MessageSizeLimit, drivers.MessageSizeLimit + 1<<10, 63 << 10}
+
+	t.Run("gas scales with params size", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, aliceBal)
+		_, bobID := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		gasUsed := make([]types.GasUnits, len(sizes))
+		for i, size := range sizes {
+			prevAliceBal := td.GetBalance(aliceID)
+			result := td.ApplyOk(td.MessageProducer.Build(alice, bobID, builtin_spec.MethodSend, bytes.Repeat([]byte{0xff}, size),
+				chain.Value(transferAmnt), chain.Nonce(uint64(i))))
+			td.AssertActorChange(aliceID, prevAliceBal, result.Msg.GasLimit, result.Msg.GasPremium, transferAmnt, result.Receipt, uint64(i+1))
+			gasUsed[i] = result.Receipt.GasUsed
+		}
+		td.AssertBalance(bobID, big_spec.Mul(transferAmnt, big_spec.NewInt(int64(len(sizes)))))
+
+		// Gas used is linear in size: the change in gas per change in size is the same between every size and the
+		// first.
+		dGas := gasUsed[1] - gasUsed[0]
+		dSize := types.GasUnits(sizes[1] - sizes[0])
+		assert.True(t, dGas > 0, "gas used doesn't increase with params size")
+		for i := 2; i < len(sizes); i++ {
+			assert.Equal(t, dGas*types.GasUnits(sizes[i]-sizes[0]), (gasUsed[i]-gasUsed[0])*dSize,
+				"gas used with %d bytes of params (%d) isn't linear in size, given %d with %d bytes and %d with %d bytes",
+				sizes[i], gasUsed[i], sizes[0], gasUsed[0], sizes[1], gasUsed[1])
+		}
+	})
+
+	t.Run("gas limit boundary scales with params size", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		_, bobID := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		// The least gas limit with which each message succeeds is its gas used, every unit of which is required.
+		boundaries := make([]int64, len(sizes))
+		for i, size := range sizes {
+			boundaries[i] = td.FindGasBoundary(td.MessageProducer.Build(alice, bobID, builtin_spec.MethodSend, bytes.Repeat([]byte{0xff}, size),
+				chain.Value(transferAmnt), chain.Nonce(0)))
+		}
+		dGas := boundaries[1] - boundaries[0]
+		dSize := int64(sizes[1] - sizes[0])
+		for i := 2; i < len(sizes); i++ {
+			assert.Equal(t, dGas*int64(sizes[i]-sizes[0]), (boundaries[i]-boundaries[0])*dSize,
+				"gas boundary with %d bytes of params (%d) isn't linear in size", sizes[i], boundaries[i])
+		}
+	})
+
+	t.Run("size limit for block inclusion", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, aliceID := td.NewAccountActor(drivers.SECP, aliceBal)
+		_, bobID := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		// sized returns a transfer whose signed serialization is `size` bytes, padded by its params. The length of the
+		// params' CBOR header, and of the signature, are the same for every size here, so the serialization grows by
+		// a byte per byte of params.
+		sized := func(size int) *types.Message {
+			transfer := func(paramsSize int) *types.Message {
+				return td.MessageProducer.Build(alice, bobID, builtin_spec.MethodSend, bytes.Repeat([]byte{0xff}, paramsSize),
+					chain.Value(transferAmnt), chain.Nonce(0))
+			}
+			paramsSize := size - 1<<10
+			ser, err := td.SignMessage(alice, transfer(paramsSize)).Serialize()
+			require.NoError(t, err)
+			msg := transfer(paramsSize + size - len(ser))
+			ser, err = td.SignMessage(alice, msg).Serialize()
+			require.NoError(t, err)
+			require.Len(t, ser, size)
+			return msg
+		}
+
+		td.AssertValidForBlock(nil, sized(drivers.MessageSizeLimit-1))
+		td.AssertValidForBlock(nil, sized(drivers.MessageSizeLimit))
+		td.AssertInvalidForBlock(nil, sized(drivers.MessageSizeLimit+1), "message beyond the size limit")
+
+		// The limit is a rule of block validity, not of message application.
+		prevAliceBal := td.GetBalance(aliceID)
+		result := td.ApplyOk(sized(drivers.MessageSizeLimit + 1))
+		td.AssertActorChange(aliceID, prevAliceBal, result.Msg.GasLimit, result.Msg.GasPremium, transferAmnt, result.Receipt, 1)
+		td.AssertBalance(bobID, transferAmnt)
+	})
+
+	t.Run("gas scales with return value size", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		// Sizes encode in params of the same length, so that the messages differ only in the size they return.
+		gasUsed := make([]types.GasUnits, len(sizes))
+		for i, size := range sizes {
+			ret := probe.Bytes(bytes.Repeat([]byte{0xff}, size))
+			result := td.ApplyExpect(td.MessageProducer.ProbeReturnBytes(alice, probeAddr, int64(size), chain.Nonce(uint64(i))),
+				chain.MustSerialize(&ret))
+			gasUsed[i] = result.Receipt.GasUsed
+		}
+
+		dGas := gasUsed[1] - gasUsed[0]
+		dSize := types.GasUnits(sizes[1] - sizes[0])
+		assert.True(t, dGas > 0, "gas used doesn't increase with return value size")
+		for i := 2; i < len(sizes); i++ {
+			assert.Equal(t, dGas*types.GasUnits(sizes[i]-sizes[0]), (gasUsed[i]-gasUsed[0])*dSize,
+				"gas used returning %d bytes (%d) isn't linear in size, given %d returning %d bytes and %d returning %d bytes",
+				sizes[i], gasUsed[i], gasUsed[0], sizes[0], gasUsed[1], sizes[1])
+		}
+	})
+}
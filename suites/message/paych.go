@@ -39,18 +39,10 @@ func MessageTest_Paych(t *testing.T, factory state.Factories) {
 
 		// the _expected_ address of the payment channel
 		paychAddr := utils.NewIDAddr(t, utils.IdFromAddress(receiverID)+1)
-		createRet := td.ComputeInitActorExecReturn(sender, 0, 0, paychAddr)
 
 		// init actor creates the payment channel
-		td.ApplyExpect(
-			td.MessageProducer.CreatePaymentChannelActor(sender, receiver, chain.Value(toSend), chain.Nonce(0)),
-			chain.MustSerialize(&createRet))
-
-		var pcState paych_spec.State
-		td.GetActorState(paychAddr, &pcState)
-		assert.Equal(t, senderID, pcState.From)
-		assert.Equal(t, receiverID, pcState.To)
-		td.AssertBalance(paychAddr, toSend)
+		td.ExpectPaychCreated(sender, 0, paychAddr, senderID, receiverID, toSend).Apply(
+			td.MessageProducer.CreatePaymentChannelActor(sender, receiver, chain.Value(toSend), chain.Nonce(0)))
 	})
 
 	t.Run("happy path update", func(t *testing.T) {
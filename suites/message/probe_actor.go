@@ -0,0 +1,69 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test behaviors of the VM through the probe actor, for implementations able to run it.
+func MessageTest_ProbeActor(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var aliceBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+
+	t.Run("gas charged by an actor is added to gas used", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		// The two messages only differ in the gas burnt by the probe, whose params encode in as many bytes.
+		const burnt = 1_000_000
+		base := td.ApplyOk(td.MessageProducer.ProbeBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 2_000_000}, chain.Nonce(0)))
+		more := td.ApplyOk(td.MessageProducer.ProbeBurnGas(alice, probeAddr, &probe.BurnParams{Gas: 2_000_000 + burnt}, chain.Nonce(1)))
+		assert.Equal(t, int64(burnt), int64(more.Receipt.GasUsed-base.Receipt.GasUsed))
+	})
+
+	t.Run("state set by an actor persists", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		for i, value := range []int64{42, -1, 0} {
+			td.ApplyOk(td.MessageProducer.ProbeSetValue(alice, probeAddr, value, chain.Nonce(uint64(i))))
+			var st probe.State
+			td.GetActorState(probeAddr, &st)
+			assert.Equal(t, probe.State(value), st)
+		}
+	})
+
+	t.Run("reentrant calls see each other's state", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		// Each of the 4 nested calls increments the state committed by its caller.
+		td.ApplyOk(td.MessageProducer.ProbeReenter(alice, probeAddr, 3, chain.Nonce(0)))
+		var st probe.State
+		td.GetActorState(probeAddr, &st)
+		assert.Equal(t, probe.State(4), st)
+	})
+}
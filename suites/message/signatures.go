@@ -0,0 +1,127 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	address "github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	crypto_spec "github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/ipfs/go-cid"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Messages are signed over the bytes of their CID, a CIDv1 of the DAG-CBOR encoded message with a blake2b-256
+// multihash. Signatures by the sender's key over any other bytes, such as the message with another CID prefix, are
+// invalid, and signed messages carrying them are rejected by implementations declaring the SignatureVerification
+// capability.
+func MessageTest_SignatureDomainSeparation(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi_spec.NewTokenAmount(10_000_000_000_000)
+	sendValue := abi_spec.NewTokenAmount(1)
+
+	// signBytes signs `data` with the key of `signer`.
+	signBytes := func(td *drivers.TestDriver, signer address.Address, data []byte) crypto_spec.Signature {
+		sig, err := td.Wallet().Sign(signer, data)
+		require.NoError(td.T, err)
+		return sig
+	}
+	// cidBytes returns the bytes of the CID of `msg` with prefix `pref`.
+	cidBytes := func(td *drivers.TestDriver, msg *types.Message, pref cid.Prefix) []byte {
+		data, err := msg.Serialize()
+		require.NoError(td.T, err)
+		c, err := pref.Sum(data)
+		require.NoError(td.T, err)
+		return c.Bytes()
+	}
+
+	rejectionCases := []struct {
+		desc string
+		// signature returns the signature to attach to msg, sent by `sender`. `other` is another account of the
+		// sender's key type.
+		signature func(td *drivers.TestDriver, sender, other address.Address, msg *types.Message) crypto_spec.Signature
+	}{
+		{"signature over serialized message", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			data, err := msg.Serialize()
+			require.NoError(td.T, err)
+			return signBytes(td, sender, data)
+		}},
+		{"signature over CID with sha2-256 multihash", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			return signBytes(td, sender, cidBytes(td, msg, cid.NewPrefixV1(cid.DagCBOR, multihash.SHA2_256)))
+		}},
+		{"signature over CID with raw codec", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			return signBytes(td, sender, cidBytes(td, msg, cid.NewPrefixV1(cid.Raw, multihash.BLAKE2B_MIN+31)))
+		}},
+		{"signature over CID multihash without prefix", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			return signBytes(td, sender, msg.Cid().Hash())
+		}},
+		{"signature over CID string", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			return signBytes(td, sender, []byte(msg.Cid().String()))
+		}},
+		{"signature over different message", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			other := *msg
+			other.CallSeqNum++
+			return td.SignMessage(sender, &other).Signature
+		}},
+		{"signature by another account", func(td *drivers.TestDriver, _, other address.Address, msg *types.Message) crypto_spec.Signature {
+			return td.SignMessage(other, msg).Signature
+		}},
+		{"mismatched signature type", func(td *drivers.TestDriver, sender, _ address.Address, msg *types.Message) crypto_spec.Signature {
+			sig := td.SignMessage(sender, msg).Signature
+			if sig.Type == crypto_spec.SigTypeBLS {
+				sig.Type = crypto_spec.SigTypeSecp256k1
+			} else {
+				sig.Type = crypto_spec.SigTypeBLS
+			}
+			return sig
+		}},
+	}
+
+	for _, protocol := range []address.Protocol{drivers.SECP, drivers.BLS} {
+		protocol := protocol
+		prefix := "secp "
+		if protocol == drivers.BLS {
+			prefix = "bls "
+		}
+
+		t.Run(prefix+"ok signed over message CID", func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, _ := td.NewAccountActor(protocol, acctDefaultBalance)
+			_, receiver := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+			td.ApplySignedOk(td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0)))
+			td.AssertBalance(receiver, sendValue)
+		})
+
+		for _, tc := range rejectionCases {
+			tc := tc
+			t.Run(prefix+"reject "+tc.desc, func(t *testing.T) {
+				td := builder.Build(t)
+				defer td.Complete()
+
+				sender, senderID := td.NewAccountActor(protocol, acctDefaultBalance)
+				other, _ := td.NewAccountActor(protocol, acctDefaultBalance)
+				_, receiver := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+				msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0))
+				td.ApplySignedExpectRejection(&types.SignedMessage{Message: *msg, Signature: tc.signature(td, sender, other, msg)})
+				td.AssertCallSeqNum(senderID, 0)
+				td.AssertBalance(receiver, big_spec.Zero())
+			})
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	address "github.com/filecoin-project/go-address"
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
+	account_spec "github.com/filecoin-project/specs-actors/actors/builtin/account"
+	init_spec "github.com/filecoin-project/specs-actors/actors/builtin/init"
+	multisig_spec "github.com/filecoin-project/specs-actors/actors/builtin/multisig"
+	exitcode_spec "github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+const (
+	// preMigrationVersion is the last network version running actors v0.
+	preMigrationVersion = drivers.NetworkVersion(3)
+	// postMigrationVersion is the network version whose upgrade migrates the state tree to actors v2.
+	postMigrationVersion = drivers.NetworkVersion(4)
+)
+
+// migrationFixture is a pre-upgrade state of accounts and a multisig with a pending transaction, built by messages.
+// Its accounts' keys are derived from seeds, so that the same fixture is built by every driver.
+type migrationFixture struct {
+	alice, aliceId address.Address
+	bob, bobId     address.Address
+	multisig       address.Address
+	multisigValue  abi_spec.TokenAmount
+	txn            multisig_spec.Transaction
+}
+
+func buildMigrationFixture(td *drivers.TestDriver) migrationFixture {
+	var f migrationFixture
+	f.alice, f.aliceId = td.NewAccountActorFromSeed(drivers.SECP, []byte("migration alice"), abi_spec.NewTokenAmount(1_000_000_000_000))
+	f.bob, f.bobId = td.NewAccountActorFromSeed(drivers.BLS, []byte("migration bob"), abi_spec.NewTokenAmount(1_000_000_000_000))
+	f.multisigValue = abi_spec.NewTokenAmount(10_000)
+
+	// Bob sends to alice, so that both accounts have a non-zero call sequence number.
+	td.ApplyOk(td.MessageProducer.Transfer(f.bob, f.alice, chain.Value(big_spec.NewInt(100)), chain.Nonce(0)))
+
+	f.multisig = utils.NewIDAddr(td.T, 1+utils.IdFromAddress(f.bobId))
+	createRet := td.ComputeInitActorExecReturn(f.alice, 0, 0, f.multisig)
+	td.MustCreateAndVerifyMultisigActor(0, f.multisigValue, f.multisig, f.alice,
+		&multisig_spec.ConstructorParams{
+			Signers:               []address.Address{f.aliceId, f.bobId},
+			NumApprovalsThreshold: 2,
+			UnlockDuration:        10,
+		},
+		exitcode_spec.Ok, chain.MustSerialize(&createRet))
+
+	// With a threshold of two, alice's proposal remains pending across the migration.
+	f.txn = multisig_spec.Transaction{
+		To:       f.bobId,
+		Value:    abi_spec.NewTokenAmount(1_000),
+		Method:   builtin_spec.MethodSend,
+		Params:   nil,
+		Approved: []address.Address{f.aliceId},
+	}
+	td.ApplyOk(td.MessageProducer.MultisigPropose(f.alice, f.multisig, &multisig_spec.ProposeParams{
+		To:     f.txn.To,
+		Value:  f.txn.Value,
+		Method: f.txn.Method,
+		Params: f.txn.Params,
+	}, chain.Nonce(1)))
+	return f
+}
+
+func MessageTest_StateMigration(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithNetworkVersion(preMigrationVersion).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	t.Run("actors keep their kind, balance and call sequence number", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		buildMigrationFixture(td)
+		td.CheckpointState("pre-migration")
+		td.Migrate(postMigrationVersion)
+		td.Checkpoint("migrated")
+
+		// Every actor's code changes, and no actor is added or removed.
+		for _, c := range td.DiffSince("pre-migration") {
+			assert.Equal(t, drivers.ActorModified, c.Kind, "actor %s", c.Address)
+		}
+	})
+
+	t.Run("actor states map to the new actors version", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		f := buildMigrationFixture(td)
+		var prevInit init_spec.State
+		td.GetActorState(builtin_spec.InitActorAddr, &prevInit)
+
+		td.Migrate(postMigrationVersion)
+		td.Checkpoint("migrated")
+
+		// The states of the account, init and multisig actors keep their layout in actors v2, so their fields are
+		// compared with those of actors v0.
+		for _, acct := range []struct{ pubkey, id address.Address }{{f.alice, f.aliceId}, {f.bob, f.bobId}} {
+			var st account_spec.State
+			td.GetActorState(acct.id, &st)
+			assert.Equal(t, acct.pubkey, st.Address, "account %s", acct.id)
+		}
+
+		var initSt init_spec.State
+		td.GetActorState(builtin_spec.InitActorAddr, &initSt)
+		assert.Equal(t, prevInit.NextID, initSt.NextID, "init actor next ID")
+		assert.Equal(t, prevInit.NetworkName, initSt.NetworkName, "init actor network name")
+
+		var msSt multisig_spec.State
+		td.GetActorState(f.multisig, &msSt)
+		assert.Equal(t, []address.Address{f.aliceId, f.bobId}, msSt.Signers)
+		assert.Equal(t, uint64(2), msSt.NumApprovalsThreshold)
+		assert.Equal(t, multisig_spec.TxnID(1), msSt.NextTxnID)
+		assert.Equal(t, f.multisigValue, msSt.InitialBalance)
+		assert.Equal(t, abi_spec.ChainEpoch(10), msSt.UnlockDuration)
+		td.AssertMultisigTransaction(f.multisig, multisig_spec.TxnID(0), f.txn)
+	})
+
+	t.Run("migration is deterministic", func(t *testing.T) {
+		first := builder.Build(t)
+		defer first.Complete()
+		buildMigrationFixture(first)
+		first.Migrate(postMigrationVersion)
+
+		second := builder.Build(t)
+		defer second.Complete()
+		buildMigrationFixture(second)
+		second.Migrate(postMigrationVersion)
+
+		assert.Equal(t, first.State().Root(), second.State().Root(), "roots of the same state migrated twice")
+	})
+}
@@ -0,0 +1,59 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// The number of unrelated actors added to the state, enough to deepen the state tree and the init actor's address
+// map by at least a level.
+const unrelatedActorCount = 2000
+
+// Test that gas charged for a message doesn't depend on the size of the state it's applied to. Each case applies a
+// message, adds thousands of unrelated actors, then applies an identical message from a different sender (created
+// up front, so the serialized messages have the same length) and compares gas used. Messages writing to collections
+// the padding grows, such as transfers creating accounts in the init actor's address map, are left out: they put
+// larger nodes of those collections in a larger state, and puts are charged by size.
+func MessageTest_GasIndependentOfStateSize(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var aliceBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+	var transferAmnt = abi_spec.NewTokenAmount(10)
+
+	padState := func(td *drivers.TestDriver) {
+		for i := 0; i < unrelatedActorCount; i++ {
+			td.NewAccountActor(drivers.SECP, big_spec.Zero())
+		}
+	}
+
+	t.Run("transfer between existing accounts", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		// Public key addresses are used throughout, as they have a fixed length and require resolution.
+		alice1, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		bob1, _ := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+		alice2, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		bob2, _ := td.NewAccountActor(drivers.SECP, big_spec.Zero())
+
+		small := td.ApplyOk(td.MessageProducer.Transfer(alice1, bob1, chain.Value(transferAmnt), chain.Nonce(0)))
+		padState(td)
+		large := td.ApplyOk(td.MessageProducer.Transfer(alice2, bob2, chain.Value(transferAmnt), chain.Nonce(0)))
+
+		assert.Equal(t, small.Receipt.GasUsed, large.Receipt.GasUsed, "gas used changed with state size")
+		td.AssertBalance(bob2, transferAmnt)
+	})
+
+}
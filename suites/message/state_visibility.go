@@ -0,0 +1,104 @@
+package message
+
+import (
+	"context"
+	"testing"
+
+	abi_spec "github.com/filecoin-project/specs-actors/actors/abi"
+	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test that the state changes of nested sends are visible to their callers when they succeed, and rolled back when
+// they abort, leaving the changes of their callers in place. Each call to the probe's Nest method checks what it sees
+// after its nested call, failing with probe.ErrStateNotVisible on a mismatch.
+func MessageTest_NestedStateVisibility(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	var aliceBal = abi_spec.NewTokenAmount(1_000_000_000_000)
+
+	// Nest calls run at depths 3, 2, 1 and 0, the top-level call at depth 3.
+	const depth = 3
+	testCases := []struct {
+		desc       string
+		abortDepth int64
+		// number of increments committed, i.e. calls above the aborting one
+		committed int64
+	}{
+		{"no abort", -1, depth + 1},
+		{"innermost call aborts", 0, depth},
+		{"middle call aborts", 1, depth - 1},
+		{"call below top-level aborts", 2, depth - 2},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+			probeAddr := td.NewProbeActor(big_spec.Zero())
+
+			committed := cbg.CborInt(tc.committed)
+			td.ApplyExpect(
+				td.MessageProducer.ProbeNest(alice, probeAddr, &probe.NestParams{Depth: depth, AbortDepth: tc.abortDepth}, chain.Nonce(0)),
+				chain.MustSerialize(&committed))
+
+			var st probe.State
+			td.GetActorState(probeAddr, &st)
+			assert.Equal(t, probe.State(tc.committed), st)
+		})
+	}
+
+	t.Run("top-level call aborts", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		td.AssertHeadUnchanged(probeAddr, func() {
+			td.ApplyFailure(
+				td.MessageProducer.ProbeNest(alice, probeAddr, &probe.NestParams{Depth: depth, AbortDepth: depth}, chain.Nonce(0)),
+				probe.ErrRequestedAbort)
+		})
+	})
+
+	t.Run("changes committed by earlier messages survive aborts", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, aliceBal)
+		probeAddr := td.NewProbeActor(big_spec.Zero())
+
+		td.ApplyOk(td.MessageProducer.ProbeSetValue(alice, probeAddr, 100, chain.Nonce(0)))
+
+		// Calls aborting at depth 3 (the top level), 2, 1 and 0 commit 0, 1, 2 and 3 increments.
+		nonce := uint64(1)
+		td.ApplyFailure(
+			td.MessageProducer.ProbeNest(alice, probeAddr, &probe.NestParams{Depth: depth, AbortDepth: depth}, chain.Nonce(nonce)),
+			probe.ErrRequestedAbort)
+		for abortDepth := int64(depth - 1); abortDepth >= 0; abortDepth-- {
+			nonce++
+			committed := cbg.CborInt(depth - abortDepth)
+			td.ApplyExpect(
+				td.MessageProducer.ProbeNest(alice, probeAddr, &probe.NestParams{Depth: depth, AbortDepth: abortDepth}, chain.Nonce(nonce)),
+				chain.MustSerialize(&committed))
+		}
+
+		var st probe.State
+		td.GetActorState(probeAddr, &st)
+		assert.Equal(t, probe.State(100+0+1+2+3), st)
+	})
+}
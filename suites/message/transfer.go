@@ -10,7 +10,6 @@ import (
 	require "github.com/stretchr/testify/require"
 
 	big_spec "github.com/filecoin-project/specs-actors/actors/abi/big"
-	builtin_spec "github.com/filecoin-project/specs-actors/actors/builtin"
 	account_spec "github.com/filecoin-project/specs-actors/actors/builtin/account"
 
 	chain "github.com/filecoin-project/chain-validation/chain"
@@ -119,10 +118,10 @@ func MessageTest_ValueTransferSimple(t *testing.T, factories state.Factories) {
 			defer td.Complete()
 
 			// Create the to and from actors with balance in the state tree
-			_, _, err := td.State().CreateActor(builtin_spec.AccountActorCodeID, tc.sender, tc.senderBal, &account_spec.State{Address: tc.sender})
+			_, _, err := td.State().CreateActor(td.ActorCode(drivers.AccountActor), tc.sender, tc.senderBal, &account_spec.State{Address: tc.sender})
 			require.NoError(t, err)
 			if tc.sender.String() != tc.receiver.String() {
-				_, _, err := td.State().CreateActor(builtin_spec.AccountActorCodeID, tc.receiver, tc.receiverBal, &account_spec.State{Address: tc.receiver})
+				_, _, err := td.State().CreateActor(td.ActorCode(drivers.AccountActor), tc.receiver, tc.receiverBal, &account_spec.State{Address: tc.receiver})
 				require.NoError(t, err)
 			}
 
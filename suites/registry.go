@@ -0,0 +1,261 @@
+package suites
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/report"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/message"
+	"github.com/filecoin-project/chain-validation/suites/tipset"
+)
+
+// Category is the kind of application a suite exercises.
+type Category string
+
+const (
+	// Suites applying messages one at a time.
+	CategoryMessage = Category("message")
+	// Suites applying messages in tipsets.
+	CategoryTipSet = Category("tipset")
+)
+
+// NetworkVersion is a version of the network's rules, which suites may be limited to.
+type NetworkVersion = drivers.NetworkVersion
+
+// Entry describes a suite.
+type Entry struct {
+	// Name is the name of the suite's function, e.g. "MessageTest_Paych", under which it is run.
+	Name     string
+	Case     TestCase
+	Category Category
+	// Actors are the builtin actors whose behavior the suite exercises, e.g. "multisig", or none for suites of the
+	// VM's behavior, such as gas charging.
+	Actors []string
+	// MinVersion and MaxVersion bound the network versions the suite applies to. A MaxVersion of zero is unbounded.
+	MinVersion NetworkVersion
+	MaxVersion NetworkVersion
+	// Parallel is whether the suite may run in parallel with others, i.e. it shares no mutable state with them.
+	Parallel bool
+}
+
+// AppliesTo returns whether the suite applies to network version `v`.
+func (e Entry) AppliesTo(v NetworkVersion) bool {
+	return v >= e.MinVersion && (e.MaxVersion == 0 || v <= e.MaxVersion)
+}
+
+// unsupported returns why an implementation with capabilities `caps` can't run the suite, or the empty string if it
+// can.
+func (e Entry) unsupported(caps state.Capabilities) string {
+	if e.Category == CategoryTipSet && !caps.TipSetApplication {
+		return "implementation doesn't support tipset application"
+	}
+	if len(caps.NetworkVersions) == 0 {
+		return ""
+	}
+	for _, v := range caps.NetworkVersions {
+		if e.AppliesTo(NetworkVersion(v)) {
+			return ""
+		}
+	}
+	return fmt.Sprintf("suite applies to none of the implementation's network versions %v", caps.NetworkVersions)
+}
+
+func entry(tc TestCase, category Category, actors ...string) Entry {
+	return Entry{Name: CaseName(tc), Case: tc, Category: category, Actors: actors, Parallel: true}
+}
+
+// Registry returns every suite, message suites first, in the order they are run.
+func Registry() []Entry {
+	return []Entry{
+		entry(message.MessageTest_AccountActorCreation, CategoryMessage, "account", "init"),
+		entry(message.MessageTest_AddressResolution, CategoryMessage, "account", "init", "paych"),
+		entry(message.MessageTest_CachedGenesis, CategoryMessage, "account", "miner", "market"),
+		entry(message.MessageTest_CallDepthLimit, CategoryMessage, "multisig"),
+		entry(message.MessageTest_ConcurrentApply, CategoryMessage, "account", "init"),
+		entry(message.MessageTest_ConsensusFault, CategoryMessage, "miner", "power"),
+		entry(message.MessageTest_DeterministicIterationOrder, CategoryMessage, "multisig"),
+		entry(message.MessageTest_ExitCodePropagation, CategoryMessage),
+		entry(message.MessageTest_GasIndependentOfStateSize, CategoryMessage),
+		entry(message.MessageTest_GasLimitBoundaries, CategoryMessage, "account", "init", "miner", "paych", "multisig"),
+		entry(message.MessageTest_GenesisRoot, CategoryMessage, "account", "init", "power", "market", "reward", "cron", "system"),
+		entry(message.MessageTest_InitActorSequentialIDAddressCreate, CategoryMessage, "init"),
+		entry(message.MessageTest_InitExecForbiddenCode, CategoryMessage, "init"),
+		entry(message.MessageTest_InvalidMethodNumbers, CategoryMessage, "account", "init", "multisig", "paych", "miner", "power", "market", "reward", "cron", "system"),
+		entry(message.MessageTest_InvalidParams, CategoryMessage, "account", "init", "multisig", "paych", "miner", "power", "market", "reward", "cron", "system"),
+		entry(message.MessageTest_LargeState, CategoryMessage, "account", "init", "market", "miner"),
+		entry(message.MessageTest_MessageApplicationEdgecases, CategoryMessage),
+		entry(message.MessageTest_MessageValidation, CategoryMessage, "account"),
+		entry(message.MessageTest_MultiSigActor, CategoryMessage, "multisig"),
+		entry(message.MessageTest_MultiSigVestingAndSigners, CategoryMessage, "multisig"),
+		entry(message.MessageTest_NestedActorCreation, CategoryMessage, "init", "multisig", "paych"),
+		entry(message.MessageTest_NestedOutOfGas, CategoryMessage),
+		entry(message.MessageTest_NestedSends, CategoryMessage, "multisig"),
+		entry(message.MessageTest_NestedStateVisibility, CategoryMessage),
+		entry(message.MessageTest_NonceAfterFailure, CategoryMessage, "account", "init"),
+		entry(message.MessageTest_OutOfGasAtChargingSites, CategoryMessage),
+		entry(message.MessageTest_ParamsSizeGas, CategoryMessage, "account"),
+		entry(message.MessageTest_Paych, CategoryMessage, "paych"),
+		entry(message.MessageTest_ProbeActor, CategoryMessage),
+		entry(message.MessageTest_SignatureDomainSeparation, CategoryMessage, "account"),
+		entry(message.MessageTest_StateMigration, CategoryMessage, "account", "init", "multisig"),
+		entry(message.MessageTest_ValueTransferAdvance, CategoryMessage, "account"),
+		entry(message.MessageTest_ValueTransferSimple, CategoryMessage, "account"),
+
+		entry(tipset.TipSetTest_BlockMessageApplication, CategoryTipSet),
+		entry(tipset.TipSetTest_BlockMessageDeduplication, CategoryTipSet),
+		entry(tipset.TipSetTest_DuplicateMessageAcrossBlocks, CategoryTipSet, "reward"),
+		entry(tipset.TipSetTest_GasPremiumAndFeeCap, CategoryTipSet, "reward"),
+		entry(tipset.TipSetTest_FeeCapAtBaseFeeBoundary, CategoryTipSet, "reward"),
+		entry(tipset.TipSetTest_MinerRewardsAndPenalties, CategoryTipSet, "reward"),
+		entry(tipset.TipSetTest_SECPMessageSignatures, CategoryTipSet),
+		entry(tipset.TipSetTest_BlockLimits, CategoryTipSet, "account"),
+		entry(tipset.TipSetTest_CronTick, CategoryTipSet, "cron"),
+		entry(tipset.TipSetTest_BatchSealVerification, CategoryTipSet, "power", "miner"),
+		entry(tipset.TipSetTest_BlockRewardWinCount, CategoryTipSet, "reward"),
+		entry(tipset.TipSetTest_RewardMinting, CategoryTipSet, "reward", "power"),
+		entry(tipset.TipSetTest_PledgeAndCollateral, CategoryTipSet, "miner", "power", "reward"),
+		entry(tipset.TipSetTest_SealProofTypes, CategoryTipSet, "miner", "power"),
+		entry(tipset.TipSetTest_ConsensusMinimumPower, CategoryTipSet, "miner", "power"),
+		entry(tipset.TipSetTest_MarketDealPayments, CategoryTipSet, "market", "miner"),
+		entry(tipset.TipSetTest_MissedWindowPoSt, CategoryTipSet, "miner", "power"),
+	}
+}
+
+// Filter selects suites to run. Empty fields select every suite.
+type Filter struct {
+	// Include lists the names of the suites to run, or patterns matching them as for path.Match, e.g. "*Multisig*".
+	Include []string
+	// Exclude lists the names of suites, or patterns matching them, not to run even if included.
+	Exclude []string
+	// Categories lists the categories of suites to run.
+	Categories []Category
+	// Actors lists actors, suites exercising any of which are run.
+	Actors []string
+	// NetworkVersion, if set, selects the suites applying to that version, and runs them at it.
+	NetworkVersion *NetworkVersion
+}
+
+// Matches returns whether the filter selects the suite `e`.
+func (f Filter) Matches(e Entry) bool {
+	if len(f.Include) > 0 && !matchesAny(f.Include, e.Name) {
+		return false
+	}
+	if matchesAny(f.Exclude, e.Name) {
+		return false
+	}
+	if len(f.Categories) > 0 {
+		found := false
+		for _, c := range f.Categories {
+			found = found || c == e.Category
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Actors) > 0 {
+		found := false
+		for _, a := range f.Actors {
+			for _, ea := range e.Actors {
+				found = found || a == ea
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return f.NetworkVersion == nil || e.AppliesTo(*f.NetworkVersion)
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, err := path.Match(p, name); ok && err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the suites selected by `filter`, in registry order.
+func Select(filter Filter) []Entry {
+	var selected []Entry
+	for _, e := range Registry() {
+		if filter.Matches(e) {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
+
+// RunSuite runs each suite selected by `filter` as a subtest of `t` named after the suite. Suites able to run in
+// parallel do so if `factory` supports it. Suites needing capabilities the implementation lacks are skipped, and
+// recorded as skipped in the report. Suites run in registry order, or in a random order if ShuffleEnvVar is set.
+func RunSuite(t *testing.T, factory state.Factories, filter Filter) {
+	if filter.NetworkVersion != nil {
+		factory = drivers.AtNetworkVersion(factory, *filter.NetworkVersion)
+	}
+	caps := factory.NewValidationConfig().Capabilities()
+	parallel := false
+	if pf, ok := factory.(state.ParallelFactories); ok {
+		parallel = pf.SupportsParallel()
+	}
+	entries := Select(filter)
+	seed, shuffled, err := shuffleSeed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shuffled {
+		shuffle(entries, seed)
+		t.Logf("suites shuffled, rerun in this order with %s=%d", ShuffleEnvVar, seed)
+	}
+	for _, e := range entries {
+		e := e
+		t.Run(e.Name, func(t *testing.T) {
+			if reason := e.unsupported(caps); reason != "" {
+				// No driver is built to record the outcome, so it is recorded here.
+				report.RecordTest(t, report.Skipped)
+				t.Skip(reason)
+			}
+			if parallel && e.Parallel {
+				t.Parallel()
+			}
+			e.Case(t, factory)
+		})
+	}
+}
+
+// RunSenderMatrix runs each message suite selected by `filter` as RunSuite does, once under each sender variant other
+// than the default, i.e. with its SECP accounts created as BLS accounts, addressed by their ID address, or both. The
+// variants are subtests of the suite's, e.g. "MessageTest_Paych/bls-id".
+func RunSenderMatrix(t *testing.T, factory state.Factories, filter Filter) {
+	if filter.NetworkVersion != nil {
+		factory = drivers.AtNetworkVersion(factory, *filter.NetworkVersion)
+	}
+	caps := factory.NewValidationConfig().Capabilities()
+	for _, e := range Select(filter) {
+		e := e
+		if e.Category != CategoryMessage {
+			continue
+		}
+		t.Run(e.Name, func(t *testing.T) {
+			if reason := e.unsupported(caps); reason != "" {
+				// No driver is built to record the outcome, so it is recorded here.
+				report.RecordTest(t, report.Skipped)
+				t.Skip(reason)
+			}
+			drivers.RunWithSenderVariants(t, factory, e.Case)
+		})
+	}
+}
+
+// CaseName returns the name of the function of `testCase`, e.g. "MessageTest_Paych".
+func CaseName(testCase TestCase) string {
+	fqName := runtime.FuncForPC(reflect.ValueOf(testCase).Pointer()).Name()
+	toks := strings.Split(fqName, ".")
+	return toks[len(toks)-1]
+}
@@ -0,0 +1,38 @@
+package suites
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ShuffleEnvVar, when set, causes RunSuite to run the suites it selects in a random order, to find tests depending on
+// state left by the tests run before them, e.g. in an implementation caching VM state between applications. Each
+// test builds its drivers afresh, so the order of suites must not change their results. A value of "random" shuffles
+// with a seed drawn from the clock; an integer is the seed itself, to reproduce the order of an earlier run. The seed
+// is logged by RunSuite, and so reported with the failures of any suite.
+const ShuffleEnvVar = "CHAIN_VALIDATION_SHUFFLE"
+
+// shuffleSeed returns the seed with which to shuffle suites, and whether they should be shuffled at all.
+func shuffleSeed() (int64, bool, error) {
+	v := os.Getenv(ShuffleEnvVar)
+	switch v {
+	case "":
+		return 0, false, nil
+	case "random":
+		return time.Now().UnixNano(), true, nil
+	}
+	seed, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid %s %q, expected \"random\" or an integer seed", ShuffleEnvVar, v)
+	}
+	return seed, true, nil
+}
+
+// shuffle permutes `entries` in place as determined by `seed`.
+func shuffle(entries []Entry, seed int64) {
+	rnd := rand.New(rand.NewSource(seed))
+	rnd.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+}
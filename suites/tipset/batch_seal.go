@@ -0,0 +1,118 @@
+package tipset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+	"github.com/filecoin-project/chain-validation/suites/utils"
+)
+
+// Test the power actor's batched verification of ProveCommit seals. Proofs submitted in a tipset are verified
+// together by cron at its end, and only the sectors whose seals are valid are confirmed with their miner.
+func TipSetTest_BatchSealVerification(t *testing.T, factory state.Factories) {
+	builder := newSectorBuilder(factory).
+		WithGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(1, drivers.SECP, drivers.FIL(10_000_000)).
+			WithMiners(1, drivers.GenesisMinerSpec{}))
+
+	// Enough to cover the miner's deposits and pledge for a few sectors at genesis network power.
+	var minerFunds = drivers.FIL(1_000_000)
+	var workerFunds = drivers.FIL(1_000)
+
+	// commitSectors funds the genesis miner and its worker, then pre-commits and proves sectors 0 to n-1, each step
+	// in a single tipset.
+	commitSectors := func(td *drivers.TestDriver, n uint64) {
+		funder := td.Genesis.Accounts[0].PubKey
+		minerAddr := td.Genesis.Miners[0].ID
+		worker := td.Genesis.Miners[0].Info.Worker
+
+		drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+			drivers.NewBlockBuilder(td, td.ExeCtx.Miner).
+				WithSECPMessageOk(td.MessageProducer.Transfer(funder, worker, chain.Value(workerFunds))).
+				WithSECPMessageOk(td.MessageProducer.Transfer(funder, minerAddr, chain.Value(minerFunds))),
+		).ApplyAndValidate()
+
+		td.SetEpoch(drivers.SectorPreCommitEpoch)
+		bb := drivers.NewBlockBuilder(td, td.ExeCtx.Miner)
+		for i := uint64(0); i < n; i++ {
+			info := drivers.SectorPreCommitInfo(drivers.TestSealProofType, abi.SectorNumber(i))
+			bb.WithBLSMessageOk(td.MessageProducer.MinerPreCommitSector(worker, minerAddr, info))
+		}
+		drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(bb).ApplyAndValidate()
+		for i := uint64(0); i < n; i++ {
+			td.Miner(minerAddr).AssertSectorPreCommitted(abi.SectorNumber(i), true)
+		}
+
+		// The proofs are only queued for verification by the messages, and verified by cron at the end of the tipset.
+		td.SetEpoch(drivers.SectorProveCommitEpoch)
+		bb = drivers.NewBlockBuilder(td, td.ExeCtx.Miner)
+		for i := uint64(0); i < n; i++ {
+			params := drivers.SectorProveCommitParams(abi.SectorNumber(i))
+			bb.WithBLSMessageOk(td.MessageProducer.MinerProveCommitSector(worker, minerAddr, params))
+		}
+		drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(bb).ApplyAndValidate()
+		td.Checkpoint("after-prove-commit")
+	}
+
+	// assertConfirmed asserts that exactly the sectors `confirmed` of the first `n` are committed, and the others
+	// remain pre-committed, and that the miner claims power for the committed sectors only.
+	assertConfirmed := func(td *drivers.TestDriver, n uint64, confirmed ...abi.SectorNumber) {
+		minerAddr := td.Genesis.Miners[0].ID
+		isConfirmed := make(map[abi.SectorNumber]bool)
+		for _, num := range confirmed {
+			isConfirmed[num] = true
+		}
+		checker := td.Miner(minerAddr).AssertSectorCount(uint64(len(confirmed)))
+		for i := uint64(0); i < n; i++ {
+			num := abi.SectorNumber(i)
+			checker.AssertSectorCommitted(num, isConfirmed[num]).AssertSectorPreCommitted(num, !isConfirmed[num])
+		}
+
+		ss, err := drivers.TestSealProofType.SectorSize()
+		require.NoError(td.T, err)
+		power := big.NewInt(int64(ss) * int64(len(confirmed)))
+		td.Power().AssertClaim(minerAddr, power, power)
+	}
+
+	sectorID := func(td *drivers.TestDriver, num abi.SectorNumber) abi.SectorID {
+		return abi.SectorID{
+			Miner:  abi.ActorID(utils.IdFromAddress(td.Genesis.Miners[0].ID)),
+			Number: num,
+		}
+	}
+
+	t.Run("all seals valid", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		commitSectors(td, 2)
+		assertConfirmed(td, 2, 0, 1)
+	})
+
+	t.Run("invalid seal rejected from batch", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		td.SysCalls.MockBatchSealOutcomes(map[abi.SectorID]bool{
+			sectorID(td, 1): false,
+		})
+		commitSectors(td, 3)
+		assertConfirmed(td, 3, 0, 2)
+	})
+
+	t.Run("all seals invalid", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		td.SysCalls.FailSealVerification(fmt.Errorf("invalid seal"))
+		commitSectors(td, 2)
+		assertConfirmed(td, 2)
+	})
+}
@@ -0,0 +1,147 @@
+package tipset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test blocks at and beyond the consensus limits on their messages, drivers.BlockMessageLimit messages and
+// drivers.BlockGasLimit gas allowed in total. A block at the limits is applied as any other, while a block beyond
+// either is invalid: its tipset is rejected outright, rather than applied with the excess dropped or its miner
+// penalized, and the state is unchanged. The limits apply to each block of a tipset apart. Blocks beyond the limits
+// are only applied to implementations declaring the BlockValidation capability.
+func TipSetTest_BlockLimits(t *testing.T, factory state.Factories) {
+	// Transfers are allowed enough gas for a full block of them to fit the block gas limit.
+	const gasLimit = drivers.BlockGasLimit / (2 * drivers.BlockMessageLimit)
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi.NewTokenAmount(1_000_000_000_000_000)
+	sendValue := abi.NewTokenAmount(1)
+
+	// fillBlock adds `n` transfers from `sender` to `bb`, all BLS messages but the last `secp`, as messages expected
+	// to apply if `ok`.
+	fillBlock := func(td *drivers.TestDriver, bb *drivers.BlockBuilder, sender, receiver address.Address, n, secp int, ok bool) {
+		for i := 0; i < n; i++ {
+			msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(uint64(i)))
+			switch {
+			case i >= n-secp && ok:
+				bb.WithSECPMessageOk(msg)
+			case i >= n-secp:
+				bb.WithSECPMessageDropped(msg)
+			case ok:
+				bb.WithBLSMessageOk(msg)
+			default:
+				bb.WithBLSMessageDropped(msg)
+			}
+		}
+	}
+
+	t.Run("messages at limit", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		sender, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		bb := drivers.NewBlockBuilder(td, td.ExeCtx.Miner)
+		fillBlock(td, bb, sender, receiver, drivers.BlockMessageLimit, 1, true)
+		result := drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(bb).ApplyAndValidate()
+		assert.Equal(t, drivers.BlockMessageLimit, len(result.Receipts))
+		td.AssertBalance(receiver, big.Mul(sendValue, big.NewInt(drivers.BlockMessageLimit)))
+	})
+
+	for _, secp := range []int{0, 1} {
+		desc := "one BLS message beyond limit"
+		if secp > 0 {
+			desc = "one SECP message beyond limit"
+		}
+		secp := secp
+		t.Run(desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			sender, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+			_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+			bb := drivers.NewBlockBuilder(td, td.ExeCtx.Miner)
+			fillBlock(td, bb, sender, receiver, drivers.BlockMessageLimit+1, secp, false)
+			drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(bb).ApplyExpectRejection()
+			td.AssertBalance(receiver, big.Zero())
+		})
+	}
+
+	t.Run("gas at limit", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		bob, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		// Two messages whose gas limits sum to the block gas limit.
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, td.ExeCtx.Miner).
+				WithBLSMessageOk(td.MessageProducer.Transfer(alice, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit/2))).
+				WithSECPMessageOk(td.MessageProducer.Transfer(bob, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit/2)))).
+			ApplyAndValidate()
+		assert.Equal(t, 2, len(result.Receipts))
+		td.AssertBalance(receiver, big.Mul(sendValue, big.NewInt(2)))
+	})
+
+	t.Run("gas beyond limit", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		bob, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		// A single message allowed more gas than a block.
+		drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, td.ExeCtx.Miner).
+				WithBLSMessageDropped(td.MessageProducer.Transfer(alice, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit+1)))).
+			ApplyExpectRejection()
+
+		// Two messages each within the block gas limit, whose gas limits sum beyond it.
+		drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, td.ExeCtx.Miner).
+				WithBLSMessageDropped(td.MessageProducer.Transfer(alice, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit/2))).
+				WithSECPMessageDropped(td.MessageProducer.Transfer(bob, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit/2+1)))).
+			ApplyExpectRejection()
+		td.AssertBalance(receiver, big.Zero())
+	})
+
+	t.Run("limits apply to each block", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		minerA := td.ExeCtx.Miner
+		minerB, _ := td.NewDefaultMinerActor()
+		alice, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		bob, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		// Each block's messages are allowed the block gas limit, twice it across the tipset.
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerA).
+				WithBLSMessageOk(td.MessageProducer.Transfer(alice, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit)))).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerB).
+				WithBLSMessageOk(td.MessageProducer.Transfer(bob, receiver, chain.Value(sendValue), chain.Nonce(0), chain.GasLimit(drivers.BlockGasLimit)))).
+			ApplyAndValidate()
+		assert.Equal(t, 2, len(result.Receipts))
+		td.AssertBalance(receiver, big.Mul(sendValue, big.NewInt(2)))
+	})
+}
@@ -0,0 +1,92 @@
+package tipset
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test the power actor's accounting of miners below the consensus minimum power. Every miner's power counts towards
+// the bytes committed to the network, and its initial pledge towards the total pledge, but only the power of miners
+// meeting the minimum counts towards the network's total power. A miner proving the sector which takes it to the
+// minimum adds its whole claim to the total.
+func TipSetTest_ConsensusMinimumPower(t *testing.T, factory state.Factories) {
+	const sectorNum = abi.SectorNumber(0)
+	// The largest sectors, so that the minimum is a modest number of them.
+	const sealProof = abi.RegisteredSealProof_StackedDrg64GiBV1
+
+	builder := newSectorBuilder(factory).
+		WithGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(1, drivers.SECP, drivers.FIL(100_000_000)))
+
+	ss, err := sealProof.SectorSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorPower := big.NewInt(int64(ss))
+
+	testCases := []struct {
+		desc string
+		// Power claimed by the miner before it proves its sector.
+		claimed abi.StoragePower
+		// Whether proving the sector takes the miner to the minimum.
+		crosses bool
+	}{
+		{"remaining below minimum", big.Sub(power.ConsensusMinerMinPower, big.Mul(big.NewInt(2), sectorPower)), false},
+		{"reaching minimum", big.Sub(power.ConsensusMinerMinPower, sectorPower), true},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			funder := td.Genesis.Accounts[0].PubKey
+			minerAddr, info := td.NewMinerActor(sealProof, drivers.FIL(1_000), abi.PeerID("chain-validation"), nil)
+			td.ApplyOk(td.MessageProducer.Transfer(funder, info.Worker, chain.Value(drivers.FIL(1_000))))
+			td.ApplyOk(td.MessageProducer.Transfer(funder, minerAddr, chain.Value(drivers.FIL(50_000_000))))
+
+			// Claimed power below the minimum is committed, but not counted in the network's power.
+			prev := *td.Power().State()
+			td.AddMinerClaim(minerAddr, tc.claimed, tc.claimed)
+			td.Power().
+				AssertClaim(minerAddr, tc.claimed, tc.claimed).
+				AssertMinerAboveMinPowerCount(prev.MinerAboveMinPowerCount).
+				AssertTotalRawPower(prev.TotalRawBytePower).
+				AssertTotalQualityAdjPower(prev.TotalQualityAdjPower).
+				AssertTotalBytesCommitted(
+					big.Add(prev.TotalBytesCommitted, tc.claimed),
+					big.Add(prev.TotalQABytesCommitted, tc.claimed))
+
+			td.PreCommitSector(info.Worker, minerAddr, sealProof, sectorNum)
+
+			// The proof is verified by cron at the end of the tipset, confirming the sector, its power and its pledge.
+			prev = *td.Power().State()
+			td.ProveCommitSector(info.Worker, minerAddr, sectorNum)
+
+			pledge := td.Miner(minerAddr).State().InitialPledgeRequirement
+			claim := big.Add(tc.claimed, sectorPower)
+			checker := td.Power().
+				AssertClaim(minerAddr, claim, claim).
+				AssertTotalPledgeCollateral(big.Add(prev.TotalPledgeCollateral, pledge)).
+				AssertTotalBytesCommitted(
+					big.Add(prev.TotalBytesCommitted, sectorPower),
+					big.Add(prev.TotalQABytesCommitted, sectorPower))
+			if tc.crosses {
+				checker.AssertMinerAboveMinPowerCount(prev.MinerAboveMinPowerCount + 1).
+					AssertTotalRawPower(big.Add(prev.TotalRawBytePower, claim)).
+					AssertTotalQualityAdjPower(big.Add(prev.TotalQualityAdjPower, claim))
+			} else {
+				checker.AssertMinerAboveMinPowerCount(prev.MinerAboveMinPowerCount).
+					AssertTotalRawPower(prev.TotalRawBytePower).
+					AssertTotalQualityAdjPower(prev.TotalQualityAdjPower)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package tipset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/cron"
+	"github.com/filecoin-project/specs-actors/actors/builtin/market"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/actors/probe"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+var (
+	powerTickEntry = cron.Entry{
+		Receiver:  builtin.StoragePowerActorAddr,
+		MethodNum: builtin.MethodsPower.OnEpochTickEnd,
+	}
+	marketTickEntry = cron.Entry{
+		Receiver:  builtin.StorageMarketActorAddr,
+		MethodNum: builtin.MethodsMarket.CronTick,
+	}
+	// The account constructor may only be invoked by the system actor, so this entry always aborts.
+	abortingEntry = cron.Entry{
+		Receiver:  builtin.BurntFundsActorAddr,
+		MethodNum: builtin.MethodsAccount.Constructor,
+	}
+)
+
+// Test that cron entries run in order at the end of each tipset, free of charge, and that an entry whose callee
+// aborts does not prevent the remaining entries from running.
+func TipSetTest_CronTick(t *testing.T, factory state.Factories) {
+	const tickEpoch = abi.ChainEpoch(10)
+
+	newBuilder := func(entries ...cron.Entry) *drivers.TestDriverBuilder {
+		return drivers.NewBuilder(context.Background(), factory).
+			WithDefaultGasLimit(1_000_000_000).
+			WithDefaultGasFeeCap(200).
+			WithDefaultGasPremium(1).
+			WithActorState(builtinActorsWithCronEntries(entries...)...)
+	}
+
+	// applyEmptyTipSet ticks cron at tickEpoch with a single block carrying no messages, asserting that
+	// nobody is charged for the cron entries.
+	applyEmptyTipSet := func(td *drivers.TestDriver) {
+		td.SetEpoch(tickEpoch)
+		miner := td.ExeCtx.Miner
+
+		prevRewards := td.GetRewardSummary()
+		prevMinerBal := td.GetBalance(miner)
+		prevBurnBal := td.GetBalance(builtin.BurntFundsActorAddr)
+		prevPowerBal := td.GetBalance(builtin.StoragePowerActorAddr)
+		prevMarketBal := td.GetBalance(builtin.StorageMarketActorAddr)
+
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, miner)).
+			ApplyAndValidate()
+
+		// Cron is an implicit message: it yields no receipt and its gas is paid by nobody.
+		assert.Equal(td.T, 0, len(result.Receipts))
+		validateRewards(td, prevRewards, td.GetRewardSummary(), prevMinerBal, td.GetBalance(miner), big.Zero(), big.Zero())
+		td.AssertBalance(builtin.BurntFundsActorAddr, prevBurnBal)
+		td.AssertBalance(builtin.SystemActorAddr, big.Zero())
+		td.AssertBalance(builtin.CronActorAddr, big.Zero())
+		td.AssertBalance(builtin.StoragePowerActorAddr, prevPowerBal)
+		td.AssertBalance(builtin.StorageMarketActorAddr, prevMarketBal)
+	}
+
+	assertMarketTicked := func(td *drivers.TestDriver) {
+		var st market.State
+		td.GetActorState(builtin.StorageMarketActorAddr, &st)
+		assert.Equal(td.T, tickEpoch, st.LastCron)
+	}
+
+	assertEntries := func(td *drivers.TestDriver, expected ...cron.Entry) {
+		var st cron.State
+		td.GetActorState(builtin.CronActorAddr, &st)
+		require.Equal(td.T, expected, st.Entries)
+	}
+
+	t.Run("power and market entries tick for free", func(t *testing.T) {
+		entries := []cron.Entry{powerTickEntry, marketTickEntry}
+		td := newBuilder(entries...).Build(t)
+		defer td.Complete()
+
+		applyEmptyTipSet(td)
+		assertMarketTicked(td)
+		// Running the entries never reorders or consumes them.
+		assertEntries(td, entries...)
+	})
+
+	t.Run("aborting entry does not prevent later entries", func(t *testing.T) {
+		entries := []cron.Entry{abortingEntry, powerTickEntry, marketTickEntry}
+		td := newBuilder(entries...).Build(t)
+		defer td.Complete()
+
+		applyEmptyTipSet(td)
+		assertMarketTicked(td)
+		assertEntries(td, entries...)
+	})
+
+	t.Run("many entries", func(t *testing.T) {
+		entries := []cron.Entry{powerTickEntry}
+		for i := 0; i < 16; i++ {
+			entries = append(entries, marketTickEntry, abortingEntry)
+		}
+		td := newBuilder(entries...).Build(t)
+		defer td.Complete()
+
+		applyEmptyTipSet(td)
+		assertMarketTicked(td)
+		assertEntries(td, entries...)
+	})
+
+	t.Run("entries run in order", func(t *testing.T) {
+		// From a state of 1, the probe's state ends 4 if incremented then doubled, and 3 if doubled then incremented.
+		for _, tc := range []struct {
+			desc    string
+			methods []abi.MethodNum
+			final   probe.State
+		}{
+			{"increment then double", []abi.MethodNum{probe.MethodsProbe.Increment, probe.MethodsProbe.Double}, 4},
+			{"double then increment", []abi.MethodNum{probe.MethodsProbe.Double, probe.MethodsProbe.Increment}, 3},
+		} {
+			tc := tc
+			t.Run(tc.desc, func(t *testing.T) {
+				td := newBuilder().Build(t)
+				defer td.Complete()
+
+				probeAddr := td.NewProbeActor(big.Zero())
+				initial := probe.State(1)
+				_, err := td.State().SetActorState(probeAddr, big.Zero(), &initial)
+				require.NoError(t, err)
+
+				// The probe's entries run between the power and market ticks, the market's confirming that the
+				// entries after them still run.
+				entries := []cron.Entry{powerTickEntry}
+				for _, method := range tc.methods {
+					entries = append(entries, cron.Entry{Receiver: probeAddr, MethodNum: method})
+				}
+				entries = append(entries, marketTickEntry)
+				cronAct, err := td.State().Actor(builtin.CronActorAddr)
+				require.NoError(t, err)
+				_, err = td.State().SetActorState(builtin.CronActorAddr, cronAct.Balance(), &cron.State{Entries: entries})
+				require.NoError(t, err)
+
+				applyEmptyTipSet(td)
+				assertMarketTicked(td)
+				var st probe.State
+				td.GetActorState(probeAddr, &st)
+				assert.Equal(t, tc.final, st, "probe state after cron")
+			})
+		}
+	})
+}
+
+// builtinActorsWithCronEntries returns the default builtin actors with the cron actor's entries replaced.
+func builtinActorsWithCronEntries(entries ...cron.Entry) []drivers.ActorState {
+	var states []drivers.ActorState
+	for _, as := range drivers.DefaultBuiltinActorsState {
+		if as.Addr == builtin.CronActorAddr {
+			continue
+		}
+		states = append(states, as)
+	}
+	return append(states, drivers.ActorState{
+		Addr:    builtin.CronActorAddr,
+		Balance: big.Zero(),
+		Code:    drivers.DefaultCronActorState.Code,
+		State:   &cron.State{Entries: entries},
+	})
+}
@@ -0,0 +1,116 @@
+package tipset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/market"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/commitments"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test the market actor's processing of an active deal by cron. From the deal's start, the storage fee for the epochs
+// elapsed since the last update is paid from the client's escrow to the provider's at each scheduled update, and once
+// the deal ends its collateral is unlocked and the deal removed. The deal is published at genesis, so that it may be
+// much shorter than the market's minimum duration, and the state root is checkpointed at every tick.
+func TipSetTest_MarketDealPayments(t *testing.T, factory state.Factories) {
+	const sectorNum = abi.SectorNumber(0)
+	// The deal starts after its sector is proven, and ends part way through an update interval.
+	const startEpoch = drivers.SectorProveCommitEpoch + 50
+	var endEpoch = startEpoch + 2*market.DealUpdatesInterval + market.DealUpdatesInterval/2
+	var price = abi.NewTokenAmount(1_000_000)
+	var providerCollateral = abi.NewTokenAmount(1_000_000_000)
+	var clientCollateral = abi.NewTokenAmount(500_000_000)
+
+	td := newSectorBuilder(factory).
+		WithGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(2, drivers.SECP, drivers.FIL(10_000_000)).
+			WithMiners(1, drivers.GenesisMinerSpec{}).
+			WithDeal(drivers.GenesisDealSpec{
+				Client:               0,
+				Provider:             0,
+				Piece:                commitments.PieceOfSize(commitments.MaxPieceSize),
+				StartEpoch:           startEpoch,
+				EndEpoch:             endEpoch,
+				StoragePricePerEpoch: price,
+				ProviderCollateral:   providerCollateral,
+				ClientCollateral:     clientCollateral,
+			})).
+		Build(t)
+	defer td.Complete()
+
+	client := td.Genesis.Accounts[0].ID
+	funder := td.Genesis.Accounts[1].PubKey
+	minerAddr := td.Genesis.Miners[0].ID
+	worker := td.Genesis.Miners[0].Info.Worker
+	dealID := td.Genesis.Deals[0]
+
+	td.ApplyOk(td.MessageProducer.Transfer(funder, worker, chain.Value(drivers.FIL(1_000))))
+	td.ApplyOk(td.MessageProducer.Transfer(funder, minerAddr, chain.Value(drivers.FIL(1_000_000))))
+
+	// The deal is activated when the proof of its sector is verified by cron at the end of the tipset.
+	td.CommitSector(worker, minerAddr, drivers.TestSealProofType, sectorNum, dealID)
+	td.Market().AssertDealState(dealID, market.DealState{
+		SectorStartEpoch: drivers.SectorProveCommitEpoch,
+		LastUpdatedEpoch: -1,
+		SlashEpoch:       -1,
+	})
+	td.Checkpoint("deal-activated")
+
+	// The deal is updated at its start, then at every interval until its end. Epochs between updates are null rounds.
+	lastPaid := startEpoch
+	for tick := startEpoch; ; tick += market.DealUpdatesInterval {
+		if tick > endEpoch {
+			tick = endEpoch
+		}
+		prev := td.Market()
+		prevClientEscrow, prevClientLocked := prev.Escrow(client), prev.Locked(client)
+		prevProviderEscrow, prevProviderLocked := prev.Escrow(minerAddr), prev.Locked(minerAddr)
+		prevMarketBal := td.GetBalance(builtin.StorageMarketActorAddr)
+
+		td.SetEpoch(tick)
+		drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, td.ExeCtx.Miner)).
+			ApplyAndValidate()
+
+		payment := big.Mul(big.NewInt(int64(tick-lastPaid)), price)
+		lastPaid = tick
+		// Payments move funds between escrow balances, all held by the market actor.
+		td.AssertBalance(builtin.StorageMarketActorAddr, prevMarketBal)
+		checker := td.Market().
+			AssertEscrow(client, big.Sub(prevClientEscrow, payment)).
+			AssertEscrow(minerAddr, big.Add(prevProviderEscrow, payment))
+		if tick < endEpoch {
+			checker.
+				AssertLocked(client, big.Sub(prevClientLocked, payment)).
+				AssertLocked(minerAddr, prevProviderLocked).
+				AssertDealState(dealID, market.DealState{
+					SectorStartEpoch: drivers.SectorProveCommitEpoch,
+					LastUpdatedEpoch: tick,
+					SlashEpoch:       -1,
+				})
+			td.Checkpoint(fmt.Sprintf("deal-tick-%d", tick))
+			continue
+		}
+
+		// With the last payment, the whole storage fee has been paid and both parties' collateral is unlocked.
+		checker.
+			AssertLocked(client, big.Sub(big.Sub(prevClientLocked, payment), clientCollateral)).
+			AssertLocked(minerAddr, big.Sub(prevProviderLocked, providerCollateral)).
+			AssertNoDeal(dealID)
+		td.Checkpoint("deal-expired")
+		break
+	}
+	totalFee := big.Mul(big.NewInt(int64(endEpoch-startEpoch)), price)
+	td.Market().
+		AssertEscrow(client, clientCollateral).
+		AssertEscrow(minerAddr, big.Add(providerCollateral, totalFee)).
+		AssertLocked(client, big.Zero()).
+		AssertLocked(minerAddr, big.Zero())
+}
@@ -0,0 +1,152 @@
+package tipset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test messages included in more than one block of a tipset. A message is executed only the first time it is
+// encountered, in block order, and only the miner of that block is paid its gas reward.
+func TipSetTest_DuplicateMessageAcrossBlocks(t *testing.T, factory state.Factories) {
+	const gasLimit = 1_000_000_000
+	const gasPremium = 1
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(gasPremium).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi.NewTokenAmount(10_000_000_000_000)
+	sendValue := abi.NewTokenAmount(100)
+	// Each executed message pays its miner the premium on its whole gas limit.
+	gasReward := big.Mul(big.NewInt(gasPremium), big.NewInt(gasLimit))
+
+	t.Run("BLS message in two blocks", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		minerA := td.ExeCtx.Miner
+		minerB, _ := td.NewDefaultMinerActor()
+
+		_, sender := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0))
+
+		prevRewards := td.GetRewardSummary()
+		prevBalA, prevBalB := td.GetBalance(minerA), td.GetBalance(minerB)
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerA).WithBLSMessageOk(msg)).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerB).WithBLSMessageDropped(msg)).
+			ApplyAndValidate()
+		assert.Equal(t, 1, len(result.Receipts))
+
+		td.AssertBalance(receiver, sendValue)
+		td.AssertActorChange(sender, acctDefaultBalance, msg.GasLimit, msg.GasPremium, msg.Value, result.Receipts[0], msg.CallSeqNum+1)
+
+		// Both miners earn a block reward, only the first earns the message's gas reward.
+		td.AssertBalance(minerA, big.Sum(prevBalA, prevRewards.NextPerBlockReward, gasReward))
+		td.AssertBalance(minerB, big.Add(prevBalB, prevRewards.NextPerBlockReward))
+	})
+
+	t.Run("SECP message in two blocks", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		minerA := td.ExeCtx.Miner
+		minerB, _ := td.NewDefaultMinerActor()
+
+		_, sender := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0))
+
+		prevRewards := td.GetRewardSummary()
+		prevBalA, prevBalB := td.GetBalance(minerA), td.GetBalance(minerB)
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerA).WithSECPMessageOk(msg)).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerB).WithSECPMessageDropped(msg)).
+			ApplyAndValidate()
+		assert.Equal(t, 1, len(result.Receipts))
+
+		td.AssertBalance(receiver, sendValue)
+		td.AssertActorChange(sender, acctDefaultBalance, msg.GasLimit, msg.GasPremium, msg.Value, result.Receipts[0], msg.CallSeqNum+1)
+
+		td.AssertBalance(minerA, big.Sum(prevBalA, prevRewards.NextPerBlockReward, gasReward))
+		td.AssertBalance(minerB, big.Add(prevBalB, prevRewards.NextPerBlockReward))
+	})
+
+	t.Run("duplicate followed by next nonce", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		minerA := td.ExeCtx.Miner
+		minerB, _ := td.NewDefaultMinerActor()
+
+		_, sender := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		msg0 := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0))
+		msg1 := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(1))
+
+		prevRewards := td.GetRewardSummary()
+		prevBalA, prevBalB := td.GetBalance(minerA), td.GetBalance(minerB)
+		// The second block repeats the first block's message before the sender's next one. Were the duplicate
+		// executed again it would fail with a bad nonce and leave msg1 out of sequence.
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerA).WithBLSMessageOk(msg0)).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerB).WithBLSMessageDropped(msg0).WithBLSMessageOk(msg1)).
+			ApplyAndValidate()
+		assert.Equal(t, 2, len(result.Receipts))
+
+		td.AssertBalance(receiver, big.Add(sendValue, sendValue))
+		senderCost := big.Add(
+			td.CalcMessageCost(msg0.GasLimit, msg0.GasPremium, msg0.Value, result.Receipts[0]),
+			td.CalcMessageCost(msg1.GasLimit, msg1.GasPremium, msg1.Value, result.Receipts[1]),
+		)
+		td.AssertBalance(sender, big.Sub(acctDefaultBalance, senderCost))
+		td.AssertCallSeqNum(sender, 2)
+
+		// Each miner is paid for the message first included in its block.
+		td.AssertBalance(minerA, big.Sum(prevBalA, prevRewards.NextPerBlockReward, gasReward))
+		td.AssertBalance(minerB, big.Sum(prevBalB, prevRewards.NextPerBlockReward, gasReward))
+	})
+
+	t.Run("duplicate within and across blocks", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		minerA := td.ExeCtx.Miner
+		minerB, _ := td.NewDefaultMinerActor()
+		minerC, _ := td.NewDefaultMinerActor()
+
+		_, sender := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0))
+
+		prevRewards := td.GetRewardSummary()
+		prevBalB, prevBalC := td.GetBalance(minerB), td.GetBalance(minerC)
+		// The first block carries no messages, so the second block's miner is the first to include the message.
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerA)).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerB).WithBLSMessageOk(msg).WithBLSMessageDropped(msg)).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerC).WithBLSMessageDropped(msg)).
+			ApplyAndValidate()
+		assert.Equal(t, 1, len(result.Receipts))
+
+		td.AssertBalance(receiver, sendValue)
+		td.AssertCallSeqNum(sender, 1)
+
+		td.AssertBalance(minerB, big.Sum(prevBalB, prevRewards.NextPerBlockReward, gasReward))
+		td.AssertBalance(minerC, big.Add(prevBalC, prevRewards.NextPerBlockReward))
+	})
+}
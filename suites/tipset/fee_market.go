@@ -0,0 +1,210 @@
+package tipset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+type feeMarketTestCase struct {
+	desc string
+
+	gasFeeCap  int64
+	gasPremium int64
+}
+
+// Test the split of message fees between the block miner (tip) and the burnt funds actor (base fee burn and
+// penalties) across combinations of fee cap, premium and base fee.
+func TipSetTest_GasPremiumAndFeeCap(t *testing.T, factory state.Factories) {
+	const gasLimit = 1_000_000_000
+	const baseFee = 100
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithBaseFee(baseFee).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi.NewTokenAmount(10_000_000_000_000)
+	sendValue := abi.NewTokenAmount(1)
+
+	testCases := []feeMarketTestCase{
+		{desc: "premium within fee cap", gasFeeCap: 200, gasPremium: 10},
+		{desc: "premium exceeds fee cap headroom", gasFeeCap: 150, gasPremium: 100},
+		{desc: "premium greater than fee cap", gasFeeCap: 120, gasPremium: 500},
+		{desc: "zero premium", gasFeeCap: 200, gasPremium: 0},
+		{desc: "fee cap below base fee", gasFeeCap: 50, gasPremium: 10},
+		{desc: "fee cap below base fee and zero premium", gasFeeCap: 50, gasPremium: 0},
+	}
+
+	t.Run("miner tip and burn per message", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		miner := td.ExeCtx.Miner
+		bb := drivers.NewBlockBuilder(td, miner)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		senders := make([]address.Address, len(testCases))
+		msgs := make([]*types.Message, len(testCases))
+		for i, tc := range testCases {
+			_, senders[i] = td.NewAccountActor(drivers.BLS, acctDefaultBalance)
+			msgs[i] = td.MessageProducer.Transfer(senders[i], receiver, chain.Value(sendValue), chain.Nonce(0),
+				chain.GasFeeCap(tc.gasFeeCap), chain.GasPremium(tc.gasPremium))
+			bb.WithBLSMessageOk(msgs[i])
+		}
+
+		prevRewards := td.GetRewardSummary()
+		prevMinerBal := td.GetBalance(miner)
+		prevBurnBal := td.GetBalance(builtin.BurntFundsActorAddr)
+		result := drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(bb).ApplyAndValidate()
+		require.Equal(t, len(testCases), len(result.Receipts))
+
+		totalTip := big.Zero()
+		totalPenalty := big.Zero()
+		totalBurn := big.Zero()
+		for i, tc := range testCases {
+			msg, rct := msgs[i], result.Receipts[i]
+
+			fees := drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, rct.GasUsed)
+			tip, burn, penalty := fees.MinerTip(), fees.Burn(), fees.MinerPenalty()
+
+			// The sender pays the tip, the burn, and the value sent. It never pays more than its fee cap.
+			senderCost := big.Sum(tip, burn, sendValue)
+			assert.Equal(t, big.Sub(acctDefaultBalance, senderCost), td.GetBalance(senders[i]), tc.desc)
+			maxCost := big.Add(big.Mul(msg.GasFeeCap, big.NewInt(msg.GasLimit)), sendValue)
+			assert.True(t, senderCost.LessThanEqual(maxCost), "%s: sender paid %s, more than max cost %s", tc.desc, senderCost, maxCost)
+
+			totalTip = big.Add(totalTip, tip)
+			totalPenalty = big.Add(totalPenalty, penalty)
+			totalBurn = big.Add(totalBurn, burn)
+		}
+
+		// The miner earns the block reward plus all tips, less the penalties for messages under the base fee.
+		newRewards := td.GetRewardSummary()
+		validateRewards(td, prevRewards, newRewards, prevMinerBal, td.GetBalance(miner), totalTip, totalPenalty)
+
+		// Base fee burns and miner penalties both end up with the burnt funds actor.
+		td.AssertBalance(builtin.BurntFundsActorAddr, big.Sum(prevBurnBal, totalBurn, totalPenalty))
+		td.AssertBalance(receiver, big.Mul(sendValue, big.NewInt(int64(len(testCases)))))
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			miner := td.ExeCtx.Miner
+			_, sender := td.NewAccountActor(drivers.BLS, acctDefaultBalance)
+			_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+			msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0),
+				chain.GasFeeCap(tc.gasFeeCap), chain.GasPremium(tc.gasPremium))
+
+			prevRewards := td.GetRewardSummary()
+			prevMinerBal := td.GetBalance(miner)
+			result := drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+				drivers.NewBlockBuilder(td, miner).WithBLSMessageOk(msg),
+			).ApplyAndValidate()
+			rct := result.Receipts[0]
+
+			fees := drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, rct.GasUsed)
+			tip, burn, penalty := fees.MinerTip(), fees.Burn(), fees.MinerPenalty()
+
+			td.AssertBalance(sender, big.Sub(acctDefaultBalance, big.Sum(tip, burn, sendValue)))
+			validateRewards(td, prevRewards, td.GetRewardSummary(), prevMinerBal, td.GetBalance(miner), tip, penalty)
+			td.AssertBalance(builtin.BurntFundsActorAddr, big.Add(burn, penalty))
+		})
+	}
+}
+
+type feeCapBoundaryTestCase struct {
+	desc string
+
+	gasFeeCap  int64
+	gasPremium int64
+
+	// AttoFIL per unit of gas limit paid to the miner as a tip.
+	expTipPerGas int64
+	// AttoFIL per unit of gas used or burnt as over-estimated charged to the miner for the fee cap shortfall against
+	// the base fee.
+	expPenaltyPerGas int64
+}
+
+// Test fee accounting for fee caps and premiums at exactly the base fee boundaries, where the tip becomes zero
+// and the miner starts paying a penalty.
+func TipSetTest_FeeCapAtBaseFeeBoundary(t *testing.T, factory state.Factories) {
+	const gasLimit = 1_000_000_000
+	const baseFee = 100
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithBaseFee(baseFee).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi.NewTokenAmount(10_000_000_000_000)
+	sendValue := abi.NewTokenAmount(1)
+
+	testCases := []feeCapBoundaryTestCase{
+		{desc: "fee cap equals base fee", gasFeeCap: baseFee, gasPremium: 10, expTipPerGas: 0, expPenaltyPerGas: 0},
+		{desc: "fee cap equals base fee and premium equals fee cap", gasFeeCap: baseFee, gasPremium: baseFee, expTipPerGas: 0, expPenaltyPerGas: 0},
+		{desc: "fee cap one below base fee", gasFeeCap: baseFee - 1, gasPremium: 10, expTipPerGas: 0, expPenaltyPerGas: 1},
+		{desc: "fee cap one below base fee and zero premium", gasFeeCap: baseFee - 1, gasPremium: 0, expTipPerGas: 0, expPenaltyPerGas: 1},
+		{desc: "premium equals fee cap", gasFeeCap: 200, gasPremium: 200, expTipPerGas: 200 - baseFee, expPenaltyPerGas: 0},
+		{desc: "premium equals fee cap headroom", gasFeeCap: 200, gasPremium: 200 - baseFee, expTipPerGas: 200 - baseFee, expPenaltyPerGas: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			miner := td.ExeCtx.Miner
+			_, sender := td.NewAccountActor(drivers.BLS, acctDefaultBalance)
+			_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+			msg := td.MessageProducer.Transfer(sender, receiver, chain.Value(sendValue), chain.Nonce(0),
+				chain.GasFeeCap(tc.gasFeeCap), chain.GasPremium(tc.gasPremium))
+
+			prevRewards := td.GetRewardSummary()
+			prevMinerBal := td.GetBalance(miner)
+			prevBurnBal := td.GetBalance(builtin.BurntFundsActorAddr)
+
+			// Messages at or below the base fee are still included and executed.
+			result := drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+				drivers.NewBlockBuilder(td, miner).WithBLSMessageOk(msg),
+			).ApplyAndValidate()
+			require.Equal(t, 1, len(result.Receipts))
+			rct := result.Receipts[0]
+
+			tip := big.Mul(big.NewInt(tc.expTipPerGas), big.NewInt(gasLimit))
+			// The gas limit is over twice the gas used, so all the unused gas is burnt as over-estimated, and the
+			// miner is penalized for the whole gas limit.
+			penalty := big.Mul(big.NewInt(tc.expPenaltyPerGas), big.NewInt(gasLimit))
+			// The fee model must agree with the values computed by hand at the boundary.
+			fees := drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, rct.GasUsed)
+			require.Equal(t, types.GasUnits(gasLimit), rct.GasUsed+fees.OverestimationGas())
+			assert.Equal(t, tip, fees.MinerTip())
+			assert.Equal(t, penalty, fees.MinerPenalty())
+
+			burn := fees.Burn()
+
+			td.AssertBalance(sender, big.Sub(acctDefaultBalance, big.Sum(tip, burn, sendValue)))
+			td.AssertBalance(receiver, sendValue)
+			validateRewards(td, prevRewards, td.GetRewardSummary(), prevMinerBal, td.GetBalance(miner), tip, penalty)
+			td.AssertBalance(builtin.BurntFundsActorAddr, big.Sum(prevBurnBal, burn, penalty))
+		})
+	}
+}
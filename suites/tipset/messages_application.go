@@ -52,6 +52,28 @@ func TipSetTest_BlockMessageApplication(t *testing.T, factory state.Factories) {
 		assert.Greater(t, secpGasUsed, blsGasUsed)
 	})
 
+	t.Run("BLS messages with signature aggregate", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+		tipB := drivers.NewTipSetMessageBuilder(td)
+		blkB := drivers.NewBlockBuilder(td, td.ExeCtx.Miner).WithBLSAggregate()
+
+		alice, _ := td.NewAccountActor(address.BLS, big_spec.NewInt(10*gasFeeCap*gasLimit))
+		bob, bobID := td.NewAccountActor(address.BLS, big_spec.NewInt(10*gasFeeCap*gasLimit))
+		_, receiver := td.NewAccountActor(address.SECP256K1, big_spec.Zero())
+		transferAmnt := abi.NewTokenAmount(100)
+
+		// Senders are given by both public key and ID address, the latter resolved to sign.
+		results := tipB.WithBlockBuilder(
+			blkB.
+				WithBLSMessageOk(td.MessageProducer.Transfer(alice, receiver, chain.Nonce(0), chain.Value(transferAmnt))).
+				WithBLSMessageOk(td.MessageProducer.Transfer(bobID, receiver, chain.Nonce(0), chain.Value(transferAmnt))).
+				WithBLSMessageOk(td.MessageProducer.Transfer(bob, receiver, chain.Nonce(1), chain.Value(transferAmnt))),
+		).ApplyAndValidate()
+
+		require.Equal(t, 3, len(results.Receipts))
+		td.AssertBalance(receiver, big_spec.Mul(transferAmnt, big_spec.NewInt(3)))
+	})
 }
 
 func TipSetTest_BlockMessageDeduplication(t *testing.T, factory state.Factories) {
@@ -0,0 +1,155 @@
+package tipset
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	"github.com/filecoin-project/specs-actors/actors/builtin/power"
+	"github.com/filecoin-project/specs-actors/actors/builtin/reward"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test a miner's accounting of the funds backing its sectors across their onboarding and termination: the
+// pre-commit deposit, the initial pledge replacing it once the sector is proven, funds locked for vesting, and the
+// limit these place on the balance its owner may withdraw. The circulating supply is controlled, so that the initial
+// pledge, which depends on it, may be computed from known values.
+func TipSetTest_PledgeAndCollateral(t *testing.T, factory state.Factories) {
+	const sectorNum = abi.SectorNumber(0)
+
+	// The reward and power actors' states, as of the last cron tick, from which miners compute their deposits and
+	// pledges.
+	networkState := func(td *drivers.TestDriver) (*reward.State, *power.State) {
+		var rst reward.State
+		td.GetActorState(builtin.RewardActorAddr, &rst)
+		return &rst, td.Power().State()
+	}
+
+	// Circulating supplies, in FIL, low enough that the initial pledge of a test sector isn't capped per byte.
+	for _, supply := range []int64{50_000_000, 100_000_000} {
+		circSupply := drivers.FIL(supply)
+		t.Run(fmt.Sprintf("circulating supply %d FIL", supply), func(t *testing.T) {
+			td := newSectorBuilder(factory).
+				WithBurnReconciliation().
+				WithCirculatingSupply(func(abi.ChainEpoch) abi.TokenAmount { return circSupply }).
+				WithGenesis(drivers.NewGenesisBuilder().
+					WithAccounts(1, drivers.SECP, drivers.FIL(10_000_000)).
+					WithMiners(1, drivers.GenesisMinerSpec{})).
+				Build(t)
+			defer td.Complete()
+
+			funder := td.Genesis.Accounts[0].PubKey
+			minerAddr := td.Genesis.Miners[0].ID
+			owner := td.Genesis.Miners[0].Info.Owner
+			worker := td.Genesis.Miners[0].Info.Worker
+
+			ss, err := drivers.TestSealProofType.SectorSize()
+			require.NoError(t, err)
+			// Without deals, a sector's quality adjusted power is its size.
+			qaPower := big.NewInt(int64(ss))
+
+			// withdrawAll requests the whole of the miner's balance, asserting that exactly its available balance is
+			// withdrawn: all but its pre-commit deposits, initial pledge, and funds vesting at the current epoch or
+			// later, as funds only vest once their epoch has passed.
+			withdrawAll := func() {
+				c := td.Miner(minerAddr)
+				locked := big.Zero()
+				for _, vf := range c.VestingFunds() {
+					if vf.Epoch >= td.CurrentEpoch() {
+						locked = big.Add(locked, vf.Amount)
+					}
+				}
+				retained := big.Add(big.Add(c.State().PreCommitDeposits, c.State().InitialPledgeRequirement), locked)
+				available := big.Sub(td.GetBalance(minerAddr), retained)
+				prevOwnerBal := td.GetBalance(owner)
+
+				result := td.ApplyOk(td.MessageProducer.MinerWithdrawBalance(owner, minerAddr, &miner.WithdrawBalanceParams{
+					AmountRequested: td.GetBalance(minerAddr),
+				}))
+				td.AssertBalance(minerAddr, retained)
+				td.Miner(minerAddr).AssertLockedFunds(locked)
+				cost := td.CalcMessageCost(result.Msg.GasLimit, result.Msg.GasPremium, big.Zero(), result.Receipt)
+				td.AssertBalance(owner, big.Sub(big.Add(prevOwnerBal, available), cost))
+			}
+
+			td.ApplyOk(td.MessageProducer.Transfer(funder, owner, chain.Value(drivers.FIL(1_000))))
+			td.ApplyOk(td.MessageProducer.Transfer(funder, worker, chain.Value(drivers.FIL(1_000))))
+			td.ApplyOk(td.MessageProducer.Transfer(funder, minerAddr, chain.Value(drivers.FIL(1_000_000))))
+
+			// The pre-commit deposit is held until the sector is proven.
+			rst, pst := networkState(td)
+			deposit := miner.PreCommitDepositForPower(rst.ThisEpochRewardSmoothed, pst.ThisEpochQAPowerSmoothed, qaPower)
+			td.PreCommitSector(worker, minerAddr, drivers.TestSealProofType, sectorNum)
+			td.Miner(minerAddr).AssertPreCommitDeposits(deposit).AssertInitialPledge(big.Zero())
+
+			// The proof is verified by cron at the end of the tipset, when the sector's initial pledge is computed
+			// from the network's state as of the previous tick.
+			rst, pst = networkState(td)
+			pledge := miner.InitialPledgeForPower(qaPower, rst.ThisEpochBaselinePower, pst.ThisEpochPledgeCollateral,
+				rst.ThisEpochRewardSmoothed, pst.ThisEpochQAPowerSmoothed, circSupply)
+			prevTotalPledge := pst.TotalPledgeCollateral
+
+			msg, result := td.ProveCommitSector(worker, minerAddr, sectorNum)
+			td.Burns().Expect(drivers.BurnBaseFee, drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, result.Receipts[0].GasUsed).Burn())
+
+			td.Miner(minerAddr).
+				AssertPreCommitDeposits(big.Zero()).
+				AssertInitialPledge(pledge)
+			td.Power().AssertTotalPledgeCollateral(big.Add(prevTotalPledge, pledge))
+			withdrawAll()
+
+			// Locked funds vest on a schedule, after which they may be withdrawn.
+			locked := drivers.FIL(100)
+			lockEpoch := td.CurrentEpoch()
+			td.ApplyOk(td.MessageProducer.MinerAddLockedFund(owner, minerAddr, &locked, chain.Value(locked)))
+			schedule := td.Miner(minerAddr).AssertLockedFunds(locked).VestingFunds()
+			require.True(t, len(schedule) > 1, "locked funds vest at once")
+			scheduled := big.Zero()
+			for i, vf := range schedule {
+				assert.True(t, vf.Epoch > lockEpoch, "funds locked at epoch %d vest at epoch %d", lockEpoch, vf.Epoch)
+				if i > 0 {
+					assert.True(t, vf.Epoch > schedule[i-1].Epoch, "vesting schedule out of order")
+				}
+				scheduled = big.Add(scheduled, vf.Amount)
+			}
+			assert.Equal(t, locked, scheduled)
+
+			// Just past the first vesting epoch, only the first tranche is withdrawn.
+			td.SetEpoch(schedule[0].Epoch + 1)
+			withdrawAll()
+
+			// Terminating the sector releases its initial pledge, less the termination fee, which is burnt. The fee
+			// is computed from the sector's expected rewards and age, and the network's state as of the last tick.
+			dlIdx, partIdx, err := td.Miner(minerAddr).State().FindSector(drivers.AsStore(td.State()), sectorNum)
+			require.NoError(t, err)
+			sector := td.Miner(minerAddr).Sector(sectorNum)
+			rst, pst = networkState(td)
+			fee := miner.PledgePenaltyForTermination(sector.ExpectedDayReward, sector.ExpectedStoragePledge,
+				td.CurrentEpoch()-sector.Activation, rst.ThisEpochRewardSmoothed, pst.ThisEpochQAPowerSmoothed, qaPower)
+			require.True(t, fee.GreaterThan(big.Zero()), "no termination fee due")
+			prevMinerBal := td.GetBalance(minerAddr)
+			td.ApplyExpect(td.MessageProducer.MinerTerminateSectors(worker, minerAddr, &miner.TerminateSectorsParams{
+				Terminations: []miner.TerminationDeclaration{{
+					Deadline:  dlIdx,
+					Partition: partIdx,
+					Sectors:   bitfield.NewFromSet([]uint64{uint64(sectorNum)}),
+				}},
+			}), drivers.ExpectAnyReturn)
+			td.AssertBalance(minerAddr, big.Sub(prevMinerBal, fee))
+			td.Burns().Expect(drivers.BurnSlash, fee)
+
+			td.Miner(minerAddr).AssertInitialPledge(big.Zero())
+			td.Power().AssertClaim(minerAddr, big.Zero(), big.Zero())
+			withdrawAll()
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package tipset
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-bitfield"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin/miner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test that cron detects a window PoSt missed by a miner at the close of the deadline its sector is assigned to,
+// marking the sector faulty and removing its power. The miner's proving period starts just after its sector is
+// proven, so that the deadline closes within a few hundred epochs rather than a whole proving period later.
+func TipSetTest_MissedWindowPoSt(t *testing.T, factory state.Factories) {
+	const sectorNum = abi.SectorNumber(0)
+	// The epoch before which the proving period starts is that of the miner's first cron event, which must come
+	// after the tipset proving the sector.
+	const periodStart = drivers.SectorProveCommitEpoch + 2
+
+	td := newSectorBuilder(factory).
+		WithProvingPeriodStart(periodStart).
+		WithGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(1, drivers.SECP, drivers.FIL(10_000_000))).
+		Build(t)
+	defer td.Complete()
+
+	funder := td.Genesis.Accounts[0].PubKey
+	minerAddr, info := td.NewMinerActor(drivers.TestSealProofType, drivers.FIL(1_000), abi.PeerID("chain-validation"), nil)
+	td.ApplyOk(td.MessageProducer.Transfer(funder, info.Worker, chain.Value(drivers.FIL(1_000))))
+	td.ApplyOk(td.MessageProducer.Transfer(funder, minerAddr, chain.Value(drivers.FIL(1_000_000))))
+
+	td.CommitSector(info.Worker, minerAddr, drivers.TestSealProofType, sectorNum)
+	ss, err := drivers.TestSealProofType.SectorSize()
+	require.NoError(t, err)
+	sectorPower := big.NewInt(int64(ss))
+	td.Power().AssertClaim(minerAddr, sectorPower, sectorPower)
+
+	dlIdx, _, err := td.Miner(minerAddr).State().FindSector(drivers.AsStore(td.State()), sectorNum)
+	require.NoError(t, err)
+
+	// applyCronTipSet applies a tipset carrying no messages at `epoch`, at the end of which cron runs the miner's
+	// proving deadline event.
+	applyCronTipSet := func(epoch abi.ChainEpoch) {
+		td.SetEpoch(epoch)
+		drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, td.ExeCtx.Miner)).
+			ApplyAndValidate()
+	}
+
+	// The first event, before the period starts, only enrolls that at the close of deadline 0. Each event then
+	// processes the deadline closing, and enrolls that at the close of the next, so every deadline up to the sector's
+	// must be reached in turn.
+	applyCronTipSet(periodStart - 1)
+	for dl := uint64(0); dl <= dlIdx; dl++ {
+		td.Miner(minerAddr).AssertFaults(bitfield.New())
+		applyCronTipSet(periodStart + abi.ChainEpoch(dl+1)*miner.WPoStChallengeWindow - 1)
+		assert.Equal(t, (dl+1)%miner.WPoStPeriodDeadlines, td.Miner(minerAddr).State().CurrentDeadline, "miner current deadline")
+	}
+
+	// No proof was submitted for the sector's partition, so the sector is faulty, and its power lost.
+	td.Miner(minerAddr).AssertFaults(bitfield.NewFromSet([]uint64{uint64(sectorNum)}))
+	td.Power().AssertClaim(minerAddr, big.Zero(), big.Zero())
+}
@@ -0,0 +1,150 @@
+package tipset
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/builtin/reward"
+	"github.com/filecoin-project/specs-actors/actors/util/smoothing"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Number of fractional bits of the Q.128 fixed point numbers of the reward actor's computations.
+const rewardPrecision = 128
+
+// Test the reward actor's baseline and simple minting over many epochs, including null rounds. After each tipset,
+// the reward actor's cumulative sums, effective network time, baseline power and smoothed reward estimate are
+// checked against values computed here from its state before the tipset and the network power it was told of.
+func TipSetTest_RewardMinting(t *testing.T, factory state.Factories) {
+	// Gaps between the epochs of successive tipsets. Gaps of more than one epoch are null rounds the reward actor
+	// catches up on at the next tipset.
+	gaps := []abi.ChainEpoch{1, 1, 1, 2, 1, 5, 1, 1, 10, 1, 3, 1, 1, 20, 1, 1, 1, 50, 1, 1}
+
+	newBuilder := func() *drivers.TestDriverBuilder {
+		return drivers.NewBuilder(context.Background(), factory).
+			WithDefaultGasLimit(1_000_000_000).
+			WithDefaultGasFeeCap(200).
+			WithDefaultGasPremium(1)
+	}
+
+	// applyEpochs applies an empty tipset after each gap, validating the reward actor's state after each.
+	applyEpochs := func(td *drivers.TestDriver) {
+		for i, gap := range gaps {
+			var prev reward.State
+			td.GetActorState(builtin.RewardActorAddr, &prev)
+
+			td.AdvanceEpoch(gap)
+			drivers.NewTipSetMessageBuilder(td).
+				WithBlockBuilder(drivers.NewBlockBuilder(td, td.ExeCtx.Miner)).
+				ApplyAndValidate()
+
+			var st reward.State
+			td.GetActorState(builtin.RewardActorAddr, &st)
+
+			// The reward actor processes every epoch since it was last updated, null rounds included. The first
+			// tipset also catches up on the epochs since genesis.
+			if i > 0 {
+				assert.Equal(t, prev.Epoch+gap, st.Epoch, "tipset %d: Epoch", i)
+			}
+			expected := nextRewardState(&prev, st.Epoch-prev.Epoch, td.Power().State().ThisEpochRawBytePower)
+			assert.Equal(t, expected.CumsumBaseline, st.CumsumBaseline, "tipset %d: CumsumBaseline", i)
+			assert.Equal(t, expected.CumsumRealized, st.CumsumRealized, "tipset %d: CumsumRealized", i)
+			assert.Equal(t, expected.EffectiveNetworkTime, st.EffectiveNetworkTime, "tipset %d: EffectiveNetworkTime", i)
+			assert.Equal(t, expected.EffectiveBaselinePower, st.EffectiveBaselinePower, "tipset %d: EffectiveBaselinePower", i)
+			assert.Equal(t, expected.ThisEpochBaselinePower, st.ThisEpochBaselinePower, "tipset %d: ThisEpochBaselinePower", i)
+
+			// The estimate is updated with the reward newly computed for the epoch.
+			smoothed := nextRewardEstimate(prev.ThisEpochRewardSmoothed, st.ThisEpochReward, st.Epoch-prev.Epoch)
+			assert.Equal(t, smoothed.PositionEstimate, st.ThisEpochRewardSmoothed.PositionEstimate, "tipset %d: smoothed reward position", i)
+			assert.Equal(t, smoothed.VelocityEstimate, st.ThisEpochRewardSmoothed.VelocityEstimate, "tipset %d: smoothed reward velocity", i)
+
+			assert.True(t, st.ThisEpochReward.GreaterThan(big.Zero()), "tipset %d: no reward", i)
+			// The driver's genesis reward is arbitrary, so the reward is compared only from the second tipset on.
+			// Without network power the effective network time stands still and only the simple reward, which
+			// decays, is minted.
+			if i > 0 && td.Power().State().ThisEpochRawBytePower.IsZero() {
+				assert.True(t, st.ThisEpochReward.LessThanEqual(prev.ThisEpochReward), "tipset %d: reward grew from %s to %s", i, prev.ThisEpochReward, st.ThisEpochReward)
+			}
+		}
+	}
+
+	t.Run("simple minting without network power", func(t *testing.T) {
+		td := newBuilder().WithActorState(drivers.DefaultBuiltinActorsState...).Build(t)
+		defer td.Complete()
+
+		applyEpochs(td)
+
+		var st reward.State
+		td.GetActorState(builtin.RewardActorAddr, &st)
+		assert.Equal(t, big.Zero(), st.CumsumRealized)
+		assert.Equal(t, abi.ChainEpoch(0), st.EffectiveNetworkTime)
+	})
+
+	t.Run("baseline minting below baseline power", func(t *testing.T) {
+		td := newBuilder().WithGenesis(drivers.NewGenesisBuilder().
+			WithMiners(2, drivers.GenesisMinerSpec{Sectors: 4, Expiration: 1000 * builtin.EpochsInDay})).
+			Build(t)
+		defer td.Complete()
+
+		applyEpochs(td)
+
+		// The network's power is far below the baseline, all of it is realized and it counts towards the
+		// effective network time.
+		var st reward.State
+		td.GetActorState(builtin.RewardActorAddr, &st)
+		assert.True(t, st.CumsumRealized.GreaterThan(big.Zero()))
+		assert.True(t, st.CumsumRealized.LessThanEqual(st.CumsumBaseline))
+	})
+}
+
+// nextRewardState returns the reward actor's baseline accounting after `epochs` epochs past `prev`, with the network
+// having `realized` power throughout.
+func nextRewardState(prev *reward.State, epochs abi.ChainEpoch, realized abi.StoragePower) reward.State {
+	st := reward.State{
+		CumsumBaseline:         prev.CumsumBaseline,
+		CumsumRealized:         prev.CumsumRealized,
+		EffectiveNetworkTime:   prev.EffectiveNetworkTime,
+		EffectiveBaselinePower: prev.EffectiveBaselinePower,
+		ThisEpochBaselinePower: prev.ThisEpochBaselinePower,
+	}
+	for e := abi.ChainEpoch(0); e < epochs; e++ {
+		st.ThisEpochBaselinePower = nextBaselinePower(st.ThisEpochBaselinePower)
+		// Power above the baseline isn't rewarded.
+		capped := realized
+		if capped.GreaterThan(st.ThisEpochBaselinePower) {
+			capped = st.ThisEpochBaselinePower
+		}
+		st.CumsumRealized = big.Add(st.CumsumRealized, capped)
+		// The effective network time is the number of epochs of the baseline whose cumulative sum the realized
+		// power has reached.
+		for st.CumsumRealized.GreaterThan(st.CumsumBaseline) {
+			st.EffectiveNetworkTime++
+			st.EffectiveBaselinePower = nextBaselinePower(st.EffectiveBaselinePower)
+			st.CumsumBaseline = big.Add(st.CumsumBaseline, st.EffectiveBaselinePower)
+		}
+	}
+	return st
+}
+
+// nextBaselinePower returns the baseline power one epoch after `prev`, growing by the Q.128 baseline exponent.
+func nextBaselinePower(prev abi.StoragePower) abi.StoragePower {
+	return big.Rsh(big.Mul(prev, reward.BaselineExponent), rewardPrecision)
+}
+
+// nextRewardEstimate returns the alpha-beta filter estimate of the reward after observing `observed` `deltaT`
+// epochs after `prev`.
+func nextRewardEstimate(prev *smoothing.FilterEstimate, observed abi.TokenAmount, deltaT abi.ChainEpoch) smoothing.FilterEstimate {
+	deltaTQ := big.Lsh(big.NewInt(int64(deltaT)), rewardPrecision)
+	position := big.Add(prev.PositionEstimate, big.Rsh(big.Mul(deltaTQ, prev.VelocityEstimate), rewardPrecision))
+
+	residual := big.Sub(big.Lsh(observed, rewardPrecision), position)
+	position = big.Add(position, big.Rsh(big.Mul(smoothing.DefaultAlpha, residual), rewardPrecision))
+	velocity := big.Add(prev.VelocityEstimate, big.Div(big.Mul(smoothing.DefaultBeta, residual), deltaTQ))
+	return smoothing.FilterEstimate{PositionEstimate: position, VelocityEstimate: velocity}
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/filecoin-project/chain-validation/chain"
-	"github.com/filecoin-project/chain-validation/chain/types"
 	"github.com/filecoin-project/chain-validation/drivers"
 	"github.com/filecoin-project/chain-validation/state"
 	"github.com/filecoin-project/chain-validation/suites/utils"
@@ -81,7 +80,7 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 				thisReward := big.Add(prevRewards.NextPerBlockReward, big.NewInt(gasSum))
 				assert.Equal(t, big.Add(prevMinerBal, thisReward), td.GetBalance(miner))
 
-				newBurn := big.Add(drivers.GetBurn(types.GasUnits(msg1.GasLimit), result.Receipts[0].GasUsed), drivers.GetBurn(types.GasUnits(msg2.GasLimit), result.Receipts[1].GasUsed))
+				newBurn := big.Add(drivers.NewFeeModel(td.ExeCtx.BaseFee, msg1, result.Receipts[0].GasUsed).Burn(), drivers.NewFeeModel(td.ExeCtx.BaseFee, msg2, result.Receipts[1].GasUsed).Burn())
 				td.AssertBalance(builtin.BurntFundsActorAddr, big.Add(burnBal, newBurn))
 
 				callSeq++
@@ -122,7 +121,7 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 		newRewards := td.GetRewardSummary()
 		newMinerBalance := td.GetBalance(miner)
 
-		gasPenalty := drivers.GetMinerPenalty(gasLimit)
+		gasPenalty := drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit)
 		gasPenalty = big.Mul(gasPenalty, big.NewInt(int64(len(badSenders))))
 
 		// The penalty amount has been burnt by the reward actor, and subtracted from the miner's block reward
@@ -159,7 +158,7 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 		newRewards := td.GetRewardSummary()
 		newMinerBalance := td.GetBalance(miner)
 
-		gasPenalty := drivers.GetMinerPenalty(gasLimit)
+		gasPenalty := drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit)
 		gasPenalty = big.Mul(gasPenalty, big.NewInt(int64(len(senders))))
 
 		// The penalty amount has been burnt by the reward actor, and subtracted from the miner's block reward.
@@ -189,7 +188,7 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 		newRewards := td.GetRewardSummary()
 		newMinerBalance := td.GetBalance(miner)
 
-		gasPenalty := drivers.GetMinerPenalty(gasLimit)
+		gasPenalty := drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit)
 
 		validateRewards(td, prevRewards, newRewards, prevMinerBalance, newMinerBalance, big.Zero(), gasPenalty)
 		td.AssertBalance(builtin.BurntFundsActorAddr, gasPenalty)
@@ -218,7 +217,7 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 		newRewards := td.GetRewardSummary()
 		newMinerBalance := td.GetBalance(miner)
 		// The penalty charged to the miner is not present in the receipt so we just have to hardcode it here.
-		validateRewards(td, prevRewards, newRewards, prevMinerBalance, newMinerBalance, big.Zero(), drivers.GetMinerPenalty(gasLimit))
+		validateRewards(td, prevRewards, newRewards, prevMinerBalance, newMinerBalance, big.Zero(), drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit))
 		td.AssertBalance(aliceId, balance)
 	})
 
@@ -249,7 +248,7 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 		gasPenalty := big.NewInt(0)
 		validateRewards(td, prevRewards, newRewards, prevMinerBalance, newMinerBalance, big.NewInt(msgOk.GasLimit+msgFail.GasLimit), gasPenalty)
 
-		burn := big.Add(drivers.GetBurn(types.GasUnits(msgOk.GasLimit), result.Receipts[0].GasUsed), drivers.GetBurn(types.GasUnits(msgFail.GasLimit), result.Receipts[1].GasUsed))
+		burn := big.Add(drivers.NewFeeModel(td.ExeCtx.BaseFee, msgOk, result.Receipts[0].GasUsed).Burn(), drivers.NewFeeModel(td.ExeCtx.BaseFee, msgFail, result.Receipts[1].GasUsed).Burn())
 		td.AssertBalance(builtin.BurntFundsActorAddr, big.Add(burn, big.Add(halfBalance, gasPenalty)))
 	})
 
@@ -311,9 +310,75 @@ func TipSetTest_MinerRewardsAndPenalties(t *testing.T, factory state.Factories)
 		assert.Equal(td.T, big.Sub(rewardsBefore.Treasury, rewardsBefore.NextPerBlockReward), newRewards.Treasury)
 	})
 
-	// TODO more tests:
-	// - miner penalty causes subsequent otherwise-valid message to have wrong nonce (another miner penalty)
-	// - miner penalty followed by non-miner penalty with same nonce (in different block)
+	t.Run("penalty causes subsequent message to have wrong nonce", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		miner := td.ExeCtx.Miner
+		tb := drivers.NewTipSetMessageBuilder(td)
+		bb := drivers.NewBlockBuilder(td, td.ExeCtx.Miner)
+
+		_, aliceId := td.NewAccountActor(drivers.BLS, acctDefaultBalance)
+
+		// The first message's fee cap is too high for alice's balance to cover its gas, so it is not applied and its
+		// nonce is not used. The second message, otherwise valid, then has the wrong nonce, and is penalized too.
+		feeCap := big.Div(acctDefaultBalance, big.NewInt(gasLimit)).Int64() + 1
+		bb.WithBLSMessageAndCode(
+			td.MessageProducer.Transfer(aliceId, builtin.BurntFundsActorAddr, chain.Value(sendValue), chain.Nonce(0), chain.GasFeeCap(feeCap)),
+			exitcode.SysErrSenderStateInvalid,
+		).WithBLSMessageAndCode(
+			td.MessageProducer.Transfer(aliceId, builtin.BurntFundsActorAddr, chain.Value(sendValue), chain.Nonce(1)),
+			exitcode.SysErrSenderStateInvalid,
+		)
+
+		prevRewards := td.GetRewardSummary()
+		prevMinerBalance := td.GetBalance(miner)
+		tb.WithBlockBuilder(bb).ApplyAndValidate()
+
+		newRewards := td.GetRewardSummary()
+		newMinerBalance := td.GetBalance(miner)
+
+		gasPenalty := big.Mul(drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit), big.NewInt(2))
+		validateRewards(td, prevRewards, newRewards, prevMinerBalance, newMinerBalance, big.Zero(), gasPenalty)
+		td.AssertBalance(builtin.BurntFundsActorAddr, gasPenalty)
+		td.AssertBalance(aliceId, acctDefaultBalance)
+		td.AssertCallSeqNum(aliceId, 0)
+	})
+
+	t.Run("penalized nonce succeeds in another block", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		minerA := td.ExeCtx.Miner
+		minerB, _ := td.NewDefaultMinerActor()
+
+		_, aliceId := td.NewAccountActor(drivers.BLS, acctDefaultBalance)
+
+		// The first block's message is penalized without using its nonce, which a different message in the second
+		// block then uses successfully.
+		feeCap := big.Div(acctDefaultBalance, big.NewInt(gasLimit)).Int64() + 1
+		msgPenalized := td.MessageProducer.Transfer(aliceId, builtin.BurntFundsActorAddr, chain.Value(sendValue), chain.Nonce(0), chain.GasFeeCap(feeCap))
+		msgOk := td.MessageProducer.Transfer(aliceId, builtin.BurntFundsActorAddr, chain.Value(sendValue), chain.Nonce(0))
+
+		prevRewards := td.GetRewardSummary()
+		prevBalA, prevBalB := td.GetBalance(minerA), td.GetBalance(minerB)
+		result := drivers.NewTipSetMessageBuilder(td).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerA).WithBLSMessageAndCode(msgPenalized, exitcode.SysErrSenderStateInvalid)).
+			WithBlockBuilder(drivers.NewBlockBuilder(td, minerB).WithBLSMessageOk(msgOk)).
+			ApplyAndValidate()
+		assert.Equal(t, 2, len(result.Receipts))
+
+		// The first miner is penalized for its message, the second paid for its message, and each earns a block
+		// reward.
+		gasPenalty := drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit)
+		gasReward := big.Mul(big.NewInt(gasPremium), big.NewInt(msgOk.GasLimit))
+		td.AssertBalance(minerA, big.Sub(big.Add(prevBalA, prevRewards.NextPerBlockReward), gasPenalty))
+		td.AssertBalance(minerB, big.Sum(prevBalB, prevRewards.NextPerBlockReward, gasReward))
+
+		burn := drivers.NewFeeModel(td.ExeCtx.BaseFee, msgOk, result.Receipts[1].GasUsed).Burn()
+		td.AssertBalance(builtin.BurntFundsActorAddr, big.Sum(gasPenalty, burn, sendValue))
+		td.AssertActorChange(aliceId, acctDefaultBalance, msgOk.GasLimit, msgOk.GasPremium, sendValue, result.Receipts[1], 1)
+	})
 }
 
 func validateRewards(td *drivers.TestDriver, prevRewards *drivers.RewardSummary, newRewards *drivers.RewardSummary, oldMinerBalance abi.TokenAmount, newMinerBalance abi.TokenAmount, gasReward big.Int, gasPenalty big.Int) {
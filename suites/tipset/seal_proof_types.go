@@ -0,0 +1,68 @@
+package tipset
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test miners of each supported seal proof type. A miner's sector size and window PoSt partition size follow from its
+// proof type, and each sector it commits adds exactly one sector size of power to its claim.
+func TipSetTest_SealProofTypes(t *testing.T, factory state.Factories) {
+	const sectorNum = abi.SectorNumber(0)
+
+	builder := newSectorBuilder(factory).
+		WithGenesis(drivers.NewGenesisBuilder().
+			WithAccounts(1, drivers.SECP, drivers.FIL(10_000_000)))
+
+	testCases := []struct {
+		desc             string
+		sealProof        abi.RegisteredSealProof
+		sectorSize       abi.SectorSize
+		partitionSectors uint64
+	}{
+		{"2KiB", abi.RegisteredSealProof_StackedDrg2KiBV1, 2 << 10, 2},
+		{"32GiB", abi.RegisteredSealProof_StackedDrg32GiBV1, 32 << 30, 2349},
+		{"64GiB", abi.RegisteredSealProof_StackedDrg64GiBV1, 64 << 30, 2300},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			peerID := abi.PeerID("miner of " + tc.desc + " sectors")
+			multiaddrs := []abi.Multiaddrs{[]byte("/ip4/127.0.0.1/tcp/1234")}
+			prevMinerCount := td.Power().State().MinerCount
+			minerAddr, info := td.NewMinerActor(tc.sealProof, drivers.FIL(1_000), peerID, multiaddrs)
+			td.AssertBalance(info.OwnerID, drivers.FIL(1_000))
+			td.Power().AssertMinerCount(prevMinerCount+1).AssertClaim(minerAddr, big.Zero(), big.Zero())
+
+			mi := td.Miner(minerAddr).Info()
+			assert.Equal(t, tc.sealProof, mi.SealProofType)
+			assert.Equal(t, tc.sectorSize, mi.SectorSize)
+			assert.Equal(t, tc.partitionSectors, mi.WindowPoStPartitionSectors)
+			assert.Equal(t, peerID, mi.PeerId)
+			assert.Equal(t, multiaddrs, mi.Multiaddrs)
+			ss, err := tc.sealProof.SectorSize()
+			require.NoError(t, err)
+			require.Equal(t, tc.sectorSize, ss, "sector size of proof type %d", tc.sealProof)
+
+			funder := td.Genesis.Accounts[0].PubKey
+			td.ApplyOk(td.MessageProducer.Transfer(funder, info.Worker, chain.Value(drivers.FIL(1_000))))
+			td.ApplyOk(td.MessageProducer.Transfer(funder, minerAddr, chain.Value(drivers.FIL(1_000_000))))
+
+			// The proof is verified by cron at the end of the tipset, confirming the sector and its power.
+			td.CommitSector(info.Worker, minerAddr, tc.sealProof, sectorNum)
+			power := big.NewInt(int64(tc.sectorSize))
+			td.Power().AssertClaim(minerAddr, power, power)
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package tipset
+
+import (
+	"context"
+	"testing"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/filecoin-project/specs-actors/actors/crypto"
+	"github.com/filecoin-project/specs-actors/actors/runtime/exitcode"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/chain/types"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test inclusion of SECP messages, whose signatures are carried per message. A correctly signed message from a
+// sender that can't send is included and penalized, while a block carrying a bad signature is rejected entirely.
+func TipSetTest_SECPMessageSignatures(t *testing.T, factory state.Factories) {
+	const gasLimit = 1_000_000_000
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(gasLimit).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi.NewTokenAmount(10_000_000_000_000)
+	sendValue := abi.NewTokenAmount(1)
+
+	t.Run("ok signed by sender", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		alice, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		msg := td.MessageProducer.Transfer(alice, receiver, chain.Value(sendValue), chain.Nonce(0))
+		drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+			drivers.NewBlockBuilder(td, td.ExeCtx.Miner).WithSignedSECPMessageAndCode(td.SignMessage(alice, msg), exitcode.Ok),
+		).ApplyAndValidate()
+		td.AssertBalance(receiver, sendValue)
+	})
+
+	t.Run("penalize correctly signed message from unknown sender", func(t *testing.T) {
+		td := builder.Build(t)
+		defer td.Complete()
+
+		miner := td.ExeCtx.Miner
+		// The key exists in the wallet, so the message can be signed, but no actor exists for it.
+		unknown := td.Wallet().NewSECP256k1AccountAddress()
+		_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+		msg := td.MessageProducer.Transfer(unknown, receiver, chain.Value(sendValue), chain.Nonce(0))
+
+		prevRewards := td.GetRewardSummary()
+		prevMinerBal := td.GetBalance(miner)
+		drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+			drivers.NewBlockBuilder(td, miner).WithSignedSECPMessageAndCode(td.SignMessage(unknown, msg), exitcode.SysErrSenderInvalid),
+		).ApplyAndValidate()
+
+		gasPenalty := drivers.GetMinerPenalty(td.ExeCtx.BaseFee, gasLimit)
+		validateRewards(td, prevRewards, td.GetRewardSummary(), prevMinerBal, td.GetBalance(miner), big.Zero(), gasPenalty)
+		td.AssertBalance(builtin.BurntFundsActorAddr, gasPenalty)
+		td.AssertBalance(receiver, big.Zero())
+	})
+
+	rejectionCases := []struct {
+		desc string
+		// signature returns the signature to attach to msg, sent by alice. Bob is another SECP account.
+		signature func(td *drivers.TestDriver, alice, bob addr.Address, msg *types.Message) crypto.Signature
+	}{
+		{"missing signature", func(_ *drivers.TestDriver, _, _ addr.Address, _ *types.Message) crypto.Signature {
+			return crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: nil}
+		}},
+		{"corrupted signature", func(td *drivers.TestDriver, alice, _ addr.Address, msg *types.Message) crypto.Signature {
+			sig := td.SignMessage(alice, msg).Signature
+			data := append([]byte{}, sig.Data...)
+			data[0] ^= 0xff
+			return crypto.Signature{Type: sig.Type, Data: data}
+		}},
+		{"truncated signature", func(td *drivers.TestDriver, alice, _ addr.Address, msg *types.Message) crypto.Signature {
+			sig := td.SignMessage(alice, msg).Signature
+			return crypto.Signature{Type: sig.Type, Data: sig.Data[:len(sig.Data)-1]}
+		}},
+		{"signed by another account", func(td *drivers.TestDriver, _, bob addr.Address, msg *types.Message) crypto.Signature {
+			return td.SignMessage(bob, msg).Signature
+		}},
+		{"signature over different message", func(td *drivers.TestDriver, alice, _ addr.Address, msg *types.Message) crypto.Signature {
+			other := *msg
+			other.Value = big.Add(msg.Value, big.NewInt(1))
+			return td.SignMessage(alice, &other).Signature
+		}},
+		{"BLS signature type", func(td *drivers.TestDriver, alice, _ addr.Address, msg *types.Message) crypto.Signature {
+			sig := td.SignMessage(alice, msg).Signature
+			return crypto.Signature{Type: crypto.SigTypeBLS, Data: sig.Data}
+		}},
+	}
+
+	for _, tc := range rejectionCases {
+		tc := tc
+		t.Run("reject block with "+tc.desc, func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			alice, aliceID := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+			bob, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+			_, receiver := td.NewAccountActor(drivers.SECP, big.Zero())
+
+			msg := td.MessageProducer.Transfer(alice, receiver, chain.Value(sendValue), chain.Nonce(0))
+			sm := &types.SignedMessage{Message: *msg, Signature: tc.signature(td, alice, bob, msg)}
+
+			prevMinerBal := td.GetBalance(td.ExeCtx.Miner)
+			drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+				drivers.NewBlockBuilder(td, td.ExeCtx.Miner).WithSignedSECPMessageDropped(sm),
+			).ApplyExpectRejection()
+
+			// Neither the sender, the receiver nor the miner are affected by a rejected block.
+			td.AssertBalance(aliceID, acctDefaultBalance)
+			td.AssertBalance(receiver, big.Zero())
+			td.AssertBalance(td.ExeCtx.Miner, prevMinerBal)
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package tipset
+
+import (
+	"context"
+
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// newSectorBuilder returns a builder for the suites onboarding sectors, with gas to spare for miner messages and the
+// nonce tracking drivers.TestDriver.CommitSector and its parts rely on.
+func newSectorBuilder(factory state.Factories) *drivers.TestDriverBuilder {
+	return drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithNonceTracking()
+}
@@ -0,0 +1,58 @@
+package tipset
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/filecoin-project/specs-actors/actors/abi"
+	"github.com/filecoin-project/specs-actors/actors/abi/big"
+	"github.com/filecoin-project/specs-actors/actors/builtin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/chain-validation/chain"
+	"github.com/filecoin-project/chain-validation/drivers"
+	"github.com/filecoin-project/chain-validation/state"
+)
+
+// Test that a block's reward is scaled by its number of election wins and debited from the reward actor's treasury,
+// while the gas reward for its messages is paid only once.
+func TipSetTest_BlockRewardWinCount(t *testing.T, factory state.Factories) {
+	builder := drivers.NewBuilder(context.Background(), factory).
+		WithDefaultGasLimit(1_000_000_000).
+		WithDefaultGasFeeCap(200).
+		WithDefaultGasPremium(1).
+		WithActorState(drivers.DefaultBuiltinActorsState...)
+
+	acctDefaultBalance := abi.NewTokenAmount(10_000_000_000_000)
+
+	// A win count of ExpectedLeadersPerEpoch earns the whole epoch's reward.
+	for _, winCount := range []int64{1, 2, builtin.ExpectedLeadersPerEpoch} {
+		winCount := winCount
+		t.Run(fmt.Sprintf("win count %d", winCount), func(t *testing.T) {
+			td := builder.Build(t)
+			defer td.Complete()
+
+			miner := td.ExeCtx.Miner
+			alice, _ := td.NewAccountActor(drivers.SECP, acctDefaultBalance)
+			bob, _ := td.NewAccountActor(drivers.SECP, big.Zero())
+
+			prevRewards := td.GetRewardSummary()
+			prevMinerBal := td.GetBalance(miner)
+
+			msg := td.MessageProducer.Transfer(alice, bob, chain.Value(big.NewInt(1)), chain.Nonce(0))
+			drivers.NewTipSetMessageBuilder(td).WithBlockBuilder(
+				drivers.NewBlockBuilder(td, miner).
+					WithTicketCount(winCount).
+					WithBLSMessageOk(msg),
+			).ApplyAndValidate()
+
+			blockReward := big.Div(big.Mul(prevRewards.NextPerEpochReward, big.NewInt(winCount)), big.NewInt(builtin.ExpectedLeadersPerEpoch))
+			gasReward := drivers.NewFeeModel(td.ExeCtx.BaseFee, msg, 0).MinerTip()
+
+			newRewards := td.GetRewardSummary()
+			assert.Equal(t, big.Sub(prevRewards.Treasury, blockReward), newRewards.Treasury)
+			assert.Equal(t, big.Add(prevMinerBal, big.Add(blockReward, gasReward)), td.GetBalance(miner))
+		})
+	}
+}
@@ -4,29 +4,24 @@ import (
 	"testing"
 
 	"github.com/filecoin-project/chain-validation/state"
-	"github.com/filecoin-project/chain-validation/suites/message"
-	"github.com/filecoin-project/chain-validation/suites/tipset"
 )
 
 type TestCase func(t *testing.T, factory state.Factories)
 
+// MessageTestCases returns the message suites. Prefer RunSuite, which also runs each as a named subtest.
 func MessageTestCases() []TestCase {
-	return []TestCase{
-		message.MessageTest_AccountActorCreation,
-		message.MessageTest_InitActorSequentialIDAddressCreate,
-		message.MessageTest_MessageApplicationEdgecases,
-		message.MessageTest_MultiSigActor,
-		message.MessageTest_NestedSends,
-		message.MessageTest_Paych,
-		message.MessageTest_ValueTransferAdvance,
-		message.MessageTest_ValueTransferSimple,
-	}
+	return casesOf(Select(Filter{Categories: []Category{CategoryMessage}}))
 }
 
+// TipSetTestCases returns the tipset suites. Prefer RunSuite, which also runs each as a named subtest.
 func TipSetTestCases() []TestCase {
-	return []TestCase{
-		tipset.TipSetTest_BlockMessageApplication,
-		tipset.TipSetTest_BlockMessageDeduplication,
-		tipset.TipSetTest_MinerRewardsAndPenalties,
+	return casesOf(Select(Filter{Categories: []Category{CategoryTipSet}}))
+}
+
+func casesOf(entries []Entry) []TestCase {
+	cases := make([]TestCase, len(entries))
+	for i, e := range entries {
+		cases[i] = e.Case
 	}
+	return cases
 }
@@ -0,0 +1,161 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// GasBaselineEnvVar names a file of gas used per method, aggregated over a run, that later runs are checked against
+// by CheckGasBaseline. Gas isn't aggregated if it is unset.
+const GasBaselineEnvVar = "CHAIN_VALIDATION_GAS_BASELINE"
+
+// GasBaselineRecordEnvVar, when set to a non-empty value, causes CheckGasBaseline to write the run's gas used as the
+// new baseline rather than check it.
+const GasBaselineRecordEnvVar = "CHAIN_VALIDATION_GAS_BASELINE_RECORD"
+
+// GasToleranceEnvVar is the change in the mean gas used by a method, in percent, above which CheckGasBaseline fails.
+// It defaults to zero, i.e. any change fails.
+const GasToleranceEnvVar = "CHAIN_VALIDATION_GAS_TOLERANCE"
+
+// TotalGasKey aggregates the gas used by every message.
+const TotalGasKey = "total"
+
+// GasBaselineEnabled returns whether gas used should be aggregated for a baseline.
+func GasBaselineEnabled() bool {
+	return os.Getenv(GasBaselineEnvVar) != ""
+}
+
+// GasAggregate is the gas used by the messages of a run calling a method.
+type GasAggregate struct {
+	Count int64 `json:"count"`
+	Total int64 `json:"total"`
+}
+
+// Mean returns the mean gas used per message.
+func (a GasAggregate) Mean() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return float64(a.Total) / float64(a.Count)
+}
+
+var gasAggregates struct {
+	sync.Mutex
+	m map[string]*GasAggregate
+}
+
+// TrackGas adds the gas used by a message to the aggregates of its method, identified by `key`, and to the total.
+func TrackGas(key string, gasUsed types.GasUnits) {
+	gasAggregates.Lock()
+	defer gasAggregates.Unlock()
+	if gasAggregates.m == nil {
+		gasAggregates.m = make(map[string]*GasAggregate)
+	}
+	for _, k := range []string{key, TotalGasKey} {
+		a, ok := gasAggregates.m[k]
+		if !ok {
+			a = &GasAggregate{}
+			gasAggregates.m[k] = a
+		}
+		a.Count++
+		a.Total += int64(gasUsed)
+	}
+}
+
+// GasAggregates returns the gas used per method so far.
+func GasAggregates() map[string]GasAggregate {
+	gasAggregates.Lock()
+	defer gasAggregates.Unlock()
+	out := make(map[string]GasAggregate, len(gasAggregates.m))
+	for k, a := range gasAggregates.m {
+		out[k] = *a
+	}
+	return out
+}
+
+// CheckGasBaseline writes the gas used per method as the baseline, if GasBaselineRecordEnvVar is set, or otherwise
+// writes to `w` the methods whose mean gas used changed from the baseline and returns an error if any changed by more
+// than the tolerance. Methods absent from either the run or the baseline are listed but don't fail the check.
+// Implementations should call it once all suites have run, e.g. from TestMain. It does nothing unless
+// GasBaselineEnvVar is set.
+func CheckGasBaseline(w io.Writer) error {
+	if !GasBaselineEnabled() {
+		return nil
+	}
+	path := os.Getenv(GasBaselineEnvVar)
+	actual := GasAggregates()
+
+	if os.Getenv(GasBaselineRecordEnvVar) != "" {
+		data, err := json.MarshalIndent(actual, "", "  ")
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(path, append(data, '\n'), 0644)
+	}
+
+	tolerance := 0.0
+	if s := os.Getenv(GasToleranceEnvVar); s != "" {
+		var err error
+		if tolerance, err = strconv.ParseFloat(s, 64); err != nil {
+			return fmt.Errorf("invalid %s %q: %w", GasToleranceEnvVar, s, err)
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read gas baseline: %w", err)
+	}
+	var baseline map[string]GasAggregate
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return fmt.Errorf("failed to parse gas baseline %s: %w", path, err)
+	}
+
+	keys := make(map[string]struct{})
+	for k := range baseline {
+		keys[k] = struct{}{}
+	}
+	for k := range actual {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var regressions int
+	for _, k := range sorted {
+		b, inBaseline := baseline[k]
+		a, inRun := actual[k]
+		switch {
+		case !inBaseline:
+			_, err = fmt.Fprintf(w, "  %s: new, mean %.0f over %d messages\n", k, a.Mean(), a.Count)
+		case !inRun:
+			_, err = fmt.Fprintf(w, "  %s: not run, baseline mean %.0f\n", k, b.Mean())
+		case a.Mean() != b.Mean():
+			change := 100 * (a.Mean() - b.Mean()) / b.Mean()
+			verdict := "within tolerance"
+			if math.Abs(change) > tolerance {
+				verdict = "REGRESSION"
+				regressions++
+			}
+			_, err = fmt.Fprintf(w, "  %s: mean %.0f -> %.0f (%+.2f%%) %s\n", k, b.Mean(), a.Mean(), change, verdict)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if regressions > 0 {
+		return fmt.Errorf("gas used by %d methods changed by more than %.2f%% from baseline %s", regressions, tolerance, path)
+	}
+	return nil
+}
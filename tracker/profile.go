@@ -0,0 +1,161 @@
+package tracker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/chain-validation/chain/types"
+)
+
+// ProfileEnvVar, when set to a non-empty value, causes test drivers to time the messages they apply, for a summary
+// per suite written by WriteProfile. If its value ends in ".json", the summary is also written as JSON to the file it
+// names.
+const ProfileEnvVar = "CHAIN_VALIDATION_PROFILE"
+
+// slowestPerSuite is the number of slowest messages listed per suite.
+const slowestPerSuite = 5
+
+// ProfilingEnabled returns whether messages should be timed.
+func ProfilingEnabled() bool {
+	return os.Getenv(ProfileEnvVar) != ""
+}
+
+// MessageTiming is the time taken by an implementation to apply a message or tipset.
+type MessageTiming struct {
+	Test string `json:"test"`
+	// Desc identifies the message or tipset within its test, e.g. "message 3 from t0101".
+	Desc     string         `json:"desc"`
+	GasUsed  types.GasUnits `json:"gasUsed"`
+	Duration time.Duration  `json:"duration"`
+}
+
+// SuiteProfile summarizes the messages applied by the tests of a suite.
+type SuiteProfile struct {
+	Suite string `json:"suite"`
+	Tests int    `json:"tests"`
+	// Messages counts each message applied, whether alone or in a tipset.
+	Messages int64          `json:"messages"`
+	GasUsed  types.GasUnits `json:"gasUsed"`
+	// WallClock is the total time taken by the suite's tests, from building their drivers to completing them.
+	WallClock time.Duration `json:"wallClock"`
+	// Applying is the part of WallClock spent applying messages and tipsets.
+	Applying time.Duration   `json:"applying"`
+	Slowest  []MessageTiming `json:"slowest"`
+}
+
+var profiles struct {
+	sync.Mutex
+	m map[string]*SuiteProfile
+}
+
+func suiteProfile(t testing.TB) *SuiteProfile {
+	suite := suiteFromTest(t)
+	if profiles.m == nil {
+		profiles.m = make(map[string]*SuiteProfile)
+	}
+	p, ok := profiles.m[suite]
+	if !ok {
+		p = &SuiteProfile{Suite: suite}
+		profiles.m[suite] = p
+	}
+	return p
+}
+
+// TrackApplication adds the application of `messages` messages, together using `gasUsed` and taking `d`, to the
+// profile of the suite of `t`.
+func TrackApplication(t testing.TB, desc string, messages int, gasUsed types.GasUnits, d time.Duration) {
+	profiles.Lock()
+	defer profiles.Unlock()
+	p := suiteProfile(t)
+	p.Messages += int64(messages)
+	p.GasUsed += gasUsed
+	p.Applying += d
+
+	timing := MessageTiming{Test: t.Name(), Desc: desc, GasUsed: gasUsed, Duration: d}
+	i := sort.Search(len(p.Slowest), func(i int) bool { return p.Slowest[i].Duration < d })
+	if i == slowestPerSuite {
+		return
+	}
+	p.Slowest = append(p.Slowest, MessageTiming{})
+	copy(p.Slowest[i+1:], p.Slowest[i:])
+	p.Slowest[i] = timing
+	if len(p.Slowest) > slowestPerSuite {
+		p.Slowest = p.Slowest[:slowestPerSuite]
+	}
+}
+
+// TrackTest adds a test of `t`'s suite taking `d` to its profile.
+func TrackTest(t testing.TB, d time.Duration) {
+	profiles.Lock()
+	defer profiles.Unlock()
+	p := suiteProfile(t)
+	p.Tests++
+	p.WallClock += d
+}
+
+// Profiles returns the profiles of the suites run so far, sorted by suite.
+func Profiles() []SuiteProfile {
+	profiles.Lock()
+	defer profiles.Unlock()
+	out := make([]SuiteProfile, 0, len(profiles.m))
+	for _, p := range profiles.m {
+		c := *p
+		c.Slowest = append([]MessageTiming(nil), p.Slowest...)
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Suite < out[j].Suite })
+	return out
+}
+
+// WriteProfile writes to `w` the number of messages applied, the gas they used, and the time taken by each suite,
+// with its slowest messages, and writes the profiles as JSON to the file named by ProfileEnvVar if it ends in ".json".
+// Implementations should call it once all suites have run, e.g. from TestMain. It does nothing unless ProfileEnvVar
+// is set.
+func WriteProfile(w io.Writer) error {
+	if !ProfilingEnabled() {
+		return nil
+	}
+	ps := Profiles()
+
+	var total SuiteProfile
+	for _, p := range ps {
+		total.Tests += p.Tests
+		total.Messages += p.Messages
+		total.GasUsed += p.GasUsed
+		total.WallClock += p.WallClock
+		total.Applying += p.Applying
+	}
+	if _, err := fmt.Fprintf(w, "chain-validation: %d tests applied %d messages using %d gas in %s (%s applying)\n",
+		total.Tests, total.Messages, total.GasUsed, total.WallClock, total.Applying); err != nil {
+		return err
+	}
+	for _, p := range ps {
+		if _, err := fmt.Fprintf(w, "  %s: %d tests, %d messages, %d gas, %s (%s applying)\n",
+			p.Suite, p.Tests, p.Messages, p.GasUsed, p.WallClock, p.Applying); err != nil {
+			return err
+		}
+		for _, m := range p.Slowest {
+			if _, err := fmt.Fprintf(w, "    %s %s: %s, %d gas\n", m.Test, m.Desc, m.Duration, m.GasUsed); err != nil {
+				return err
+			}
+		}
+	}
+
+	path := os.Getenv(ProfileEnvVar)
+	if !strings.HasSuffix(path, ".json") {
+		return nil
+	}
+	data, err := json.MarshalIndent(ps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -13,11 +13,28 @@ import (
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/chain-validation/box"
+	"github.com/filecoin-project/chain-validation/chain/commitments"
 	"github.com/filecoin-project/chain-validation/chain/types"
 )
 
 const ValidationDataEnvVar = "CHAIN_VALIDATION_DATA"
 
+// RecordEnvVar, when set to a non-empty value, causes test drivers to record their results on completion.
+const RecordEnvVar = "CHAIN_VALIDATION_RECORD"
+
+// RecordingEnabled returns whether results should be recorded as new expectations.
+func RecordingEnabled() bool {
+	return os.Getenv(RecordEnvVar) != ""
+}
+
+// CheckpointsFileSuffix is appended to a test's data file name to name the file holding its checkpoints.
+const CheckpointsFileSuffix = ".checkpoints"
+
+// CommitmentsFileSuffix is appended to a test's data file name to name the file listing the well-known commitments
+// of package commitments found in its messages and return values, with their derivations, so that consumers of the
+// vector may reproduce them. It is metadata, not an expectation, and isn't baked into the box.
+const CommitmentsFileSuffix = ".commitments"
+
 type StateTracker struct {
 	tracker *list.List
 	T       testing.TB
@@ -26,11 +43,23 @@ type StateTracker struct {
 	gasIdx int
 	// slice of gas units used by the test
 	expectedGasUnits []types.GasUnits
+	// breakdown of each of expectedGasUnits by category of charge, nil where none is recorded
+	expectedGasBreakdowns []map[string]types.GasUnits
 
 	// index in stateroots of expected cid
 	rootIdx int
 	// slice of state roots used by the test
 	expectedStateRoots []cid.Cid
+
+	// index in expectedReceiptsRoots of the next tipset's expected receipts root
+	receiptsRootIdx int
+	// receipts root of each tipset applied by the test, undefined where none is recorded
+	expectedReceiptsRoots []cid.Cid
+
+	// checkpoints reached by the test, in order
+	checkpoints []types.Checkpoint
+	// expected state root of each named checkpoint
+	expectedCheckpoints map[string]cid.Cid
 }
 
 func NewStateTracker(t testing.TB) *StateTracker {
@@ -42,6 +71,11 @@ func NewStateTracker(t testing.TB) *StateTracker {
 		expectedGasUnits:   gasUsed,
 		rootIdx:            0,
 		expectedStateRoots: stateRoots,
+
+		expectedGasBreakdowns: loadGasBreakdownsForTest(t),
+		expectedReceiptsRoots: loadReceiptsRootsForTest(t),
+
+		expectedCheckpoints: loadCheckpointsForTest(t),
 	}
 }
 
@@ -58,6 +92,16 @@ func (st *StateTracker) NextExpectedGas() (types.GasUnits, bool) {
 	return st.expectedGasUnits[st.gasIdx], true
 }
 
+// ExpectedGasBreakdown returns the expected breakdown by category of charge of the gas last returned by
+// NextExpectedGas, if one is recorded.
+func (st *StateTracker) ExpectedGasBreakdown() (map[string]types.GasUnits, bool) {
+	idx := st.gasIdx - 1
+	if idx < 0 || idx >= len(st.expectedGasBreakdowns) || st.expectedGasBreakdowns[idx] == nil {
+		return nil, false
+	}
+	return st.expectedGasBreakdowns[idx], true
+}
+
 func (st *StateTracker) NextExpectedStateRoot() (cid.Cid, bool) {
 	defer func() { st.rootIdx += 1 }()
 	if st.rootIdx > len(st.expectedStateRoots)-1 {
@@ -67,6 +111,33 @@ func (st *StateTracker) NextExpectedStateRoot() (cid.Cid, bool) {
 	return st.expectedStateRoots[st.rootIdx], true
 }
 
+// NextExpectedReceiptsRoot returns the expected root of the receipts AMT of the next tipset applied, if one is
+// recorded.
+func (st *StateTracker) NextExpectedReceiptsRoot() (cid.Cid, bool) {
+	defer func() { st.receiptsRootIdx += 1 }()
+	if st.receiptsRootIdx > len(st.expectedReceiptsRoots)-1 || !st.expectedReceiptsRoots[st.receiptsRootIdx].Defined() {
+		return cid.Undef, false
+	}
+	return st.expectedReceiptsRoots[st.receiptsRootIdx], true
+}
+
+// TrackCheckpoint records that the test reached the checkpoint `name` with state root `root`. Checkpoint names must
+// be unique within a test.
+func (st *StateTracker) TrackCheckpoint(name string, root cid.Cid) {
+	for _, c := range st.checkpoints {
+		if c.Name == name {
+			st.T.Fatalf("duplicate checkpoint %q", name)
+		}
+	}
+	st.checkpoints = append(st.checkpoints, types.Checkpoint{Name: name, Root: root.String()})
+}
+
+// ExpectedCheckpoint returns the expected state root at the checkpoint `name`, if one is recorded.
+func (st *StateTracker) ExpectedCheckpoint(name string) (cid.Cid, bool) {
+	root, found := st.expectedCheckpoints[name]
+	return root, found
+}
+
 // write the contents of gm.tracker to a file using the format:
 // GasUnit
 // GasUnit
@@ -81,27 +152,79 @@ func (st *StateTracker) Record() {
 	defer func() { _ = f.Close() }()
 	enc := json.NewEncoder(f)
 
+	// Messages and return values, in which well-known commitments are looked for.
+	var data [][]byte
 	for e := st.tracker.Front(); e != nil; e = e.Next() {
 		switch ele := e.Value.(type) {
 		case types.ApplyMessageResult:
 			if err := enc.Encode(ele); err != nil {
 				st.T.Fatal(err)
 			}
+			data = append(data, ele.Msg.Params, ele.Receipt.ReturnValue)
 		case types.ApplyTipSetResult:
 			if err := enc.Encode(ele); err != nil {
 				st.T.Fatal(err)
 			}
+			for _, rct := range ele.Receipts {
+				data = append(data, rct.ReturnValue)
+			}
 		default:
 			st.T.Fatalf("Unknown type: %T", ele)
 		}
 	}
+
+	if len(st.checkpoints) > 0 {
+		st.recordCheckpoints(file + CheckpointsFileSuffix)
+	}
+	st.recordCommitments(file+CommitmentsFileSuffix, commitments.Find(data...))
+}
+
+// recordCommitments writes the well-known commitments `found` to `file`, one per line, or removes the file of an
+// earlier recording if there are none.
+func (st *StateTracker) recordCommitments(file string, found []commitments.WellKnown) {
+	if len(found) == 0 {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			st.T.Log(err)
+		}
+		return
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		st.T.Log(err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	enc := json.NewEncoder(f)
+
+	for _, c := range found {
+		if err := enc.Encode(c); err != nil {
+			st.T.Fatal(err)
+		}
+	}
+}
+
+// recordCheckpoints writes the checkpoints reached by the test to `file`, one per line.
+func (st *StateTracker) recordCheckpoints(file string) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		st.T.Log(err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	enc := json.NewEncoder(f)
+
+	for _, c := range st.checkpoints {
+		if err := enc.Encode(c); err != nil {
+			st.T.Fatal(err)
+		}
+	}
 }
 
 func LoadDataForTest(t testing.TB) (gasUsed []types.GasUnits, stateRoots []cid.Cid) {
 	fileName := filenameFromTest(t)
 	data, found := box.Get(fileName)
 	if !found {
-		t.Logf("WARNING (does NOT indicate test failure): can't find file: %s", fileName)
+		ReportSoftFailure(t, MissingExpectations, fmt.Sprintf("can't find file: %s", fileName))
 		// return an empty slice here since `NextExpectedGas` performs bounds checking
 		return []types.GasUnits{}, []cid.Cid{}
 	}
@@ -137,6 +260,79 @@ func LoadDataForTest(t testing.TB) (gasUsed []types.GasUnits, stateRoots []cid.C
 	panic("unreachable")
 }
 
+// loadGasBreakdownsForTest returns the gas breakdowns recorded for the test, indexed as the gas returned by
+// LoadDataForTest. Receipts of tipsets carry no breakdown.
+func loadGasBreakdownsForTest(t testing.TB) []map[string]types.GasUnits {
+	data, found := box.Get(filenameFromTest(t))
+	if !found {
+		return nil
+	}
+
+	var breakdowns []map[string]types.GasUnits
+	switch v := data.(type) {
+	case types.ApplyMessageResult:
+		breakdowns = append(breakdowns, v.GasBreakdown)
+	case []types.ApplyMessageResult:
+		for _, res := range v {
+			breakdowns = append(breakdowns, res.GasBreakdown)
+		}
+	case types.ApplyTipSetResult:
+		breakdowns = append(breakdowns, make([]map[string]types.GasUnits, len(v.Receipts))...)
+	case []types.ApplyTipSetResult:
+		for _, res := range v {
+			breakdowns = append(breakdowns, make([]map[string]types.GasUnits, len(res.Receipts))...)
+		}
+	}
+	return breakdowns
+}
+
+// loadReceiptsRootsForTest returns the receipts root recorded for each tipset applied by the test, in order,
+// undefined for tipsets recorded without one.
+func loadReceiptsRootsForTest(t testing.TB) []cid.Cid {
+	data, found := box.Get(filenameFromTest(t))
+	if !found {
+		return nil
+	}
+
+	var results []types.ApplyTipSetResult
+	switch v := data.(type) {
+	case types.ApplyTipSetResult:
+		results = append(results, v)
+	case []types.ApplyTipSetResult:
+		results = v
+	}
+	roots := make([]cid.Cid, len(results))
+	for i, res := range results {
+		if res.ReceiptsRoot == "" {
+			continue
+		}
+		root, err := cid.Decode(res.ReceiptsRoot)
+		if err != nil {
+			t.Fatalf("invalid receipts root of tipset %d: %v", i, err)
+		}
+		roots[i] = root
+	}
+	return roots
+}
+
+// loadCheckpointsForTest returns the expected state root of each checkpoint recorded for the test. Checkpoints
+// are stored apart from message and tipset results, so that they don't depend on how many of those precede them.
+func loadCheckpointsForTest(t testing.TB) map[string]cid.Cid {
+	expected := make(map[string]cid.Cid)
+	data, found := box.Get(filenameFromTest(t) + CheckpointsFileSuffix)
+	if !found {
+		return expected
+	}
+	checkpoints, ok := data.([]types.Checkpoint)
+	if !ok {
+		t.Fatalf("Unknown Checkpoint Data Type: %T", data)
+	}
+	for _, c := range checkpoints {
+		expected[c.Name] = c.StateRoot()
+	}
+	return expected
+}
+
 func getTestDataFilePath(t testing.TB) string {
 	dataPath := os.Getenv(ValidationDataEnvVar)
 	if dataPath == "" {
@@ -145,6 +341,11 @@ func getTestDataFilePath(t testing.TB) string {
 	return filepath.Join(dataPath, filenameFromTest(t))
 }
 
+// TestFileName returns the name under which files belonging to test `t` are stored, with a leading slash.
+func TestFileName(t testing.TB) string {
+	return filenameFromTest(t)
+}
+
 // return a string containing only letters and number.
 func filenameFromTest(t testing.TB) string {
 	// only want letters and numbers